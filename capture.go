@@ -0,0 +1,106 @@
+package metrics
+
+import "sort"
+
+// MetricSnapshot is one metric as captured by Capture: its name, its kind
+// ("counter", "floatcounter", "gauge", "gaugefloat64", "healthcheck",
+// "histogram", "meter", "resettingtimer" or "timer") and its values keyed by
+// the names GetAll uses.
+type MetricSnapshot struct {
+	Name   string             `json:"name"`
+	Kind   string             `json:"kind"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Capture returns every metric in r, or DefaultRegistry if r is nil, in
+// lexical order by name, flattened into MetricSnapshots so exporters can
+// iterate over them without type switches.  The metrics are read from a
+// single Snapshot of the registry, which leaves ResettingTimers uncleared.  A
+// healthcheck is checked and has a single value, healthy, of one or zero.
+func Capture(r Registry) []MetricSnapshot {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	snapshot := SnapshotRegistry(r)
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	captured := make([]MetricSnapshot, 0, len(names))
+	for _, name := range names {
+		i := snapshot[name]
+		kind := metricKind(i)
+		if "" == kind {
+			continue
+		}
+		captured = append(captured, MetricSnapshot{
+			Name:   name,
+			Kind:   kind,
+			Values: captureValues(i),
+		})
+	}
+	return captured
+}
+
+// captureValues flattens a metric into a map of its numeric values.
+func captureValues(i interface{}) map[string]float64 {
+	values := make(map[string]float64)
+	switch metric := i.(type) {
+	case Healthcheck:
+		values["healthy"] = 1
+		metric.Check()
+		if nil != metric.Error() {
+			values["healthy"] = 0
+		}
+		return values
+	case ResettingTimer:
+		vs := metric.Values()
+		ps := metric.Percentiles([]float64{0, 0.5, 0.75, 0.95, 0.99, 0.999, 1})
+		values["count"] = float64(len(vs))
+		values["mean"] = metric.Mean()
+		values["min"] = float64(ps[0])
+		values["median"] = float64(ps[1])
+		values["75%"] = float64(ps[2])
+		values["95%"] = float64(ps[3])
+		values["99%"] = float64(ps[4])
+		values["99.9%"] = float64(ps[5])
+		values["max"] = float64(ps[6])
+		return values
+	}
+	for k, v := range metricValues(i) {
+		switch v := v.(type) {
+		case float64:
+			values[k] = v
+		case int64:
+			values[k] = float64(v)
+		}
+	}
+	return values
+}
+
+// metricKind returns the kind of a metric as named in a MetricSnapshot, or
+// the empty string if it isn't a kind of metric a registry holds.
+func metricKind(i interface{}) string {
+	switch i.(type) {
+	case Counter:
+		return "counter"
+	case FloatCounter:
+		return "floatcounter"
+	case Gauge:
+		return "gauge"
+	case GaugeFloat64:
+		return "gaugefloat64"
+	case Healthcheck:
+		return "healthcheck"
+	case Histogram:
+		return "histogram"
+	case ResettingTimer:
+		return "resettingtimer"
+	case ThisMeter:
+		return "meter"
+	case Timer:
+		return "timer"
+	}
+	return ""
+}
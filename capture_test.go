@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCapture(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("counter", r).Inc(3)
+	NewRegisteredFloatCounter("floatcounter", r).Inc(1.5)
+	NewRegisteredGauge("gauge", r).Update(4)
+	NewRegisteredGaugeFloat64("gaugefloat64", r).Update(2.5)
+	r.Register("healthcheck", NewHealthcheck(func(h Healthcheck) { h.Unhealthy(errors.New("down")) }))
+	NewRegisteredHistogram("histogram", r, NewUniformSample(100)).Update(7)
+	NewRegisteredThisMeter("meter", r).Mark(5)
+	rt := NewRegisteredResettingTimer("resettingtimer", r)
+	rt.Update(10)
+	rt.Update(30)
+	NewRegisteredTimer("timer", r).Update(time.Second)
+	defer r.UnregisterAll()
+
+	captured := Capture(r)
+	if 9 != len(captured) {
+		t.Fatalf("len(captured): 9 != %v\n", len(captured))
+	}
+	for _, test := range []struct {
+		kind, key string
+		value     float64
+	}{
+		{"counter", "count", 3},
+		{"floatcounter", "count", 1.5},
+		{"gauge", "value", 4},
+		{"gaugefloat64", "value", 2.5},
+		{"healthcheck", "healthy", 0},
+		{"histogram", "max", 7},
+		{"meter", "count", 5},
+		{"resettingtimer", "mean", 20},
+		{"resettingtimer", "max", 30},
+		{"timer", "max", float64(time.Second)},
+	} {
+		var m *MetricSnapshot
+		for i := range captured {
+			if test.kind == captured[i].Name {
+				m = &captured[i]
+			}
+		}
+		if nil == m {
+			t.Errorf("%s: not captured\n", test.kind)
+			continue
+		}
+		if test.kind != m.Kind {
+			t.Errorf("%s: kind: %v != %v\n", test.kind, test.kind, m.Kind)
+		}
+		if v, ok := m.Values[test.key]; !ok || test.value != v {
+			t.Errorf("%s: %s: %v != %v\n", test.kind, test.key, test.value, v)
+		}
+	}
+	if 2 != len(rt.Values()) {
+		t.Errorf("rt.Values(): [10 30] != %v\n", rt.Values())
+	}
+	for i := 1; i < len(captured); i++ {
+		if captured[i-1].Name >= captured[i].Name {
+			t.Errorf("captured out of order: %v before %v\n", captured[i-1].Name, captured[i].Name)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import "time"
+
+// Clocks tell the time to metrics which compute rates or windows, so that
+// tests can control it rather than sleep.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock metrics use unless told otherwise.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time { return time.Now() }
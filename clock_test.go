@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// manualClock is a Clock which only moves when told to.
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Unix(0, 0)}
+}
+
+// Add moves the clock forward by d.
+func (c *manualClock) Add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Now returns the clock's time.
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
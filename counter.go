@@ -1,6 +1,10 @@
 package metrics
 
-import "sync/atomic"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 // Counters hold an int64 value that can be incremented and decremented.
 type Counter interface {
@@ -23,6 +27,16 @@ type Counter interface {
 	//////////////////
 }
 
+// GetCounter returns the Counter registered under the given name or nil if there is
+// none or it is another kind of metric.
+func GetCounter(name string, r Registry) Counter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(Counter)
+	return m
+}
+
 // GetOrRegisterCounter returns an existing Counter or constructs and registers
 // a new StandardCounter.
 func GetOrRegisterCounter(name string, r Registry) Counter {
@@ -34,10 +48,10 @@ func GetOrRegisterCounter(name string, r Registry) Counter {
 
 // NewCounter constructs a new StandardCounter.
 func NewCounter() Counter {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilCounters {
 		return NilCounter{}
 	}
-	return &StandardCounter{0}
+	return &StandardCounter{}
 }
 
 // NewRegisteredCounter constructs and registers a new StandardCounter.
@@ -95,6 +109,29 @@ func (c CounterSnapshot) Stop() {}
 //////////////////
 //////////////////
 
+// PooledCounterSnapshot is a CounterSnapshot taken by SnapshotPooled.
+type PooledCounterSnapshot struct {
+	CounterSnapshot
+	pooled   bool
+	released uint32
+}
+
+var counterSnapshotPool = sync.Pool{
+	New: func() interface{} { return &PooledCounterSnapshot{} },
+}
+
+// Release returns the snapshot to the pool for reuse.  It panics if the
+// snapshot has already been released, which would let two holders share it.
+func (c *PooledCounterSnapshot) Release() {
+	if !c.pooled {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&c.released, 0, 1) {
+		panic("Release called twice on a PooledCounterSnapshot")
+	}
+	counterSnapshotPool.Put(c)
+}
+
 // NilCounter is a no-op Counter.
 type NilCounter struct{}
 
@@ -135,12 +172,14 @@ func (NilCounter) Stop() {}
 // StandardCounter is the standard implementation of a Counter and uses the
 // sync/atomic package to manage a single int64 value.
 type StandardCounter struct {
-	count int64
+	count      int64
+	lastUpdate int64 // nanoseconds since the Unix epoch
 }
 
 // Clear sets the counter to zero.
 func (c *StandardCounter) Clear() {
 	atomic.StoreInt64(&c.count, 0)
+	touchLastUpdate(&c.lastUpdate)
 }
 
 // Count returns the current count.
@@ -151,11 +190,19 @@ func (c *StandardCounter) Count() int64 {
 // Dec decrements the counter by the given amount.
 func (c *StandardCounter) Dec(i int64) {
 	atomic.AddInt64(&c.count, -i)
+	touchLastUpdate(&c.lastUpdate)
 }
 
 // Inc increments the counter by the given amount.
 func (c *StandardCounter) Inc(i int64) {
 	atomic.AddInt64(&c.count, i)
+	touchLastUpdate(&c.lastUpdate)
+}
+
+// LastUpdate returns when the counter was last changed, or the zero Time if
+// it never has been.
+func (c *StandardCounter) LastUpdate() time.Time {
+	return loadLastUpdate(&c.lastUpdate)
 }
 
 // Snapshot returns a read-only copy of the counter.
@@ -163,6 +210,17 @@ func (c *StandardCounter) Snapshot() Counter {
 	return CounterSnapshot(c.Count())
 }
 
+// SnapshotPooled returns a read-only copy of the counter like Snapshot but
+// reuses snapshots previously returned by Release so exporters don't allocate
+// one per counter on every flush.  Call Release once done with it and don't
+// use it afterwards.
+func (c *StandardCounter) SnapshotPooled() *PooledCounterSnapshot {
+	snapshot := counterSnapshotPool.Get().(*PooledCounterSnapshot)
+	snapshot.CounterSnapshot = CounterSnapshot(c.Count())
+	snapshot.pooled, snapshot.released = true, 0
+	return snapshot
+}
+
 //////////////////
 // Meter functions
 //////////////////
@@ -1,6 +1,9 @@
 package metrics
 
-import "testing"
+import (
+	"sync"
+	"testing"
+)
 
 func BenchmarkCounter(b *testing.B) {
 	c := NewCounter()
@@ -10,6 +13,80 @@ func BenchmarkCounter(b *testing.B) {
 	}
 }
 
+func BenchmarkCounterParallel(b *testing.B) {
+	c := NewCounter()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc(1)
+		}
+	})
+}
+
+func BenchmarkCounterSnapshot(b *testing.B) {
+	c := NewCounter()
+	c.Inc(1 << 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Snapshot().Count()
+	}
+}
+
+func BenchmarkCounterSnapshotPooled(b *testing.B) {
+	c := NewCounter().(*StandardCounter)
+	c.Inc(1 << 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		snapshot := c.SnapshotPooled()
+		snapshot.Count()
+		snapshot.Release()
+	}
+}
+
+// BenchmarkMutexCounterParallel is the baseline for BenchmarkCounterParallel.
+func BenchmarkMutexCounterParallel(b *testing.B) {
+	c := &mutexCounter{}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc(1)
+		}
+	})
+}
+
+// mutexCounter is a counter guarded by a mutex rather than sync/atomic.
+type mutexCounter struct {
+	sync.Mutex
+	count int64
+}
+
+func (c *mutexCounter) Inc(i int64) {
+	c.Lock()
+	c.count += i
+	c.Unlock()
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	c := NewCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc(2)
+				c.Dec(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Count(); 8000 != count {
+		t.Errorf("c.Count(): 8000 != %v\n", count)
+	}
+}
+
 func TestCounterClear(t *testing.T) {
 	c := NewCounter()
 	c.Inc(1)
@@ -61,6 +138,52 @@ func TestCounterSnapshot(t *testing.T) {
 	}
 }
 
+func TestCounterSnapshotPooled(t *testing.T) {
+	c := NewCounter().(*StandardCounter)
+	c.Inc(1)
+	snapshot := c.SnapshotPooled()
+	c.Inc(1)
+	other := c.SnapshotPooled()
+	if count := snapshot.Count(); 1 != count {
+		t.Errorf("snapshot.Count(): 1 != %v\n", count)
+	}
+	if count := other.Count(); 2 != count {
+		t.Errorf("other.Count(): 2 != %v\n", count)
+	}
+	snapshot.Release()
+	func() {
+		defer func() {
+			if nil == recover() {
+				t.Error("snapshot.Release() twice didn't panic")
+			}
+		}()
+		snapshot.Release()
+	}()
+	if a, b := c.SnapshotPooled(), c.SnapshotPooled(); a == b {
+		t.Fatal("c.SnapshotPooled() returned the same snapshot twice")
+	}
+	other.Release()
+}
+
+func TestCounterSnapshotMutatorsPanic(t *testing.T) {
+	snapshot := NewCounter().Snapshot()
+	for name, f := range map[string]func(){
+		"Clear": snapshot.Clear,
+		"Dec":   func() { snapshot.Dec(1) },
+		"Inc":   func() { snapshot.Inc(1) },
+		"Mark":  func() { snapshot.Mark(1) },
+	} {
+		func() {
+			defer func() {
+				if nil == recover() {
+					t.Errorf("snapshot.%s() didn't panic\n", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
 func TestCounterZero(t *testing.T) {
 	c := NewCounter()
 	if count := c.Count(); 0 != count {
@@ -68,6 +191,21 @@ func TestCounterZero(t *testing.T) {
 	}
 }
 
+func TestGetCounter(t *testing.T) {
+	r := NewRegistry()
+	m := NewRegisteredCounter("foo", r)
+	NewRegisteredGauge("bar", r)
+	if got := GetCounter("foo", r); m != got {
+		t.Errorf("GetCounter(\"foo\", r): %v != %v\n", m, got)
+	}
+	if got := GetCounter("bar", r); nil != got {
+		t.Errorf("GetCounter(\"bar\", r): nil != %v\n", got)
+	}
+	if got := GetCounter("baz", r); nil != got {
+		t.Errorf("GetCounter(\"baz\", r): nil != %v\n", got)
+	}
+}
+
 func TestGetOrRegisterCounter(t *testing.T) {
 	r := NewRegistry()
 	NewRegisteredCounter("foo", r).Inc(47)
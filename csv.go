@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVConfig provides a container with configuration parameters for the CSV
+// exporter.
+type CSVConfig struct {
+	Registry      Registry      // Registry to be exported
+	FlushInterval time.Duration // Interval between rows
+	Writer        io.Writer     // Writer the CSV is written to
+	Fields        []string      // Columns after the timestamp, each a metric name and one of the keys GetAll reports for it joined by a dot
+	Logger        Logger        // Logger for errors, the standard library's if nil
+	AlignFlush    bool          // Whether to flush on multiples of FlushInterval, say on the minute, rather than every FlushInterval from starting
+}
+
+// CSVExporter is a blocking exporter function which writes a header naming
+// the timestamp column and the given fields to w and then, every interval,
+// appends one row of their values.  Each field is a metric name and one of
+// the keys GetAll reports for it, joined by a dot, as in "requests.count" or
+// "requests.1m.rate".  Fields whose metric isn't registered at a flush are
+// left empty so the columns stay aligned.
+func CSVExporter(r Registry, interval time.Duration, w io.Writer, fields []string) {
+	CSVExporterWithConfig(CSVConfig{
+		Registry:      r,
+		FlushInterval: interval,
+		Writer:        w,
+		Fields:        fields,
+	})
+}
+
+// CSVExporterWithConfig is a blocking exporter function just like
+// CSVExporter, but it takes a CSVConfig instead.
+func CSVExporterWithConfig(c CSVConfig) {
+	l := loggerOrDefault(c.Logger)
+	cw := csv.NewWriter(c.Writer)
+	if err := writeCSVHeader(cw, c.Fields); nil != err {
+		l.Printf("%v", err)
+	}
+	ticker := NewFlushTicker(c.FlushInterval, c.AlignFlush)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := writeCSVRow(cw, c.Registry, c.Fields, now); nil != err {
+			l.Printf("%v", err)
+		}
+	}
+}
+
+func writeCSVHeader(cw *csv.Writer, fields []string) error {
+	cw.Write(append([]string{"timestamp"}, fields...))
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVRow writes the values of the given fields, read from a single
+// snapshot of the registry, timestamped now.
+func writeCSVRow(cw *csv.Writer, r Registry, fields []string, now time.Time) error {
+	values := make(map[string]interface{})
+	for name, data := range r.GetAll() {
+		for key, value := range data {
+			values[name+"."+key] = value
+		}
+	}
+	row := make([]string, 1+len(fields))
+	row[0] = now.UTC().Format(time.RFC3339Nano)
+	for i, field := range fields {
+		if value := values[field]; nil != value {
+			row[1+i] = fmt.Sprint(value)
+		}
+	}
+	cw.Write(row)
+	cw.Flush()
+	return cw.Error()
+}
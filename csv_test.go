@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVExporter(t *testing.T) {
+	r := NewRegistry()
+	c := NewRegisteredCounter("foo", r)
+	c.Inc(47)
+	fields := []string{"foo.count", "bar.value"}
+	var b bytes.Buffer
+	cw := csv.NewWriter(&b)
+	if err := writeCSVHeader(cw, fields); nil != err {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2015, 8, 12, 0, 0, 0, 0, time.UTC)
+	if err := writeCSVRow(cw, r, fields, t0); nil != err {
+		t.Fatal(err)
+	}
+	c.Inc(1)
+	NewRegisteredGauge("bar", r).Update(49)
+	if err := writeCSVRow(cw, r, fields, t0.Add(time.Second)); nil != err {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"timestamp", "foo.count", "bar.value"},
+		{"2015-08-12T00:00:00Z", "47", ""},
+		{"2015-08-12T00:00:01Z", "48", "49"},
+	}
+	if !reflect.DeepEqual(want, records) {
+		t.Errorf("records: %v != %v\n", want, records)
+	}
+}
+
+func TestCSVExporterWithConfigLogger(t *testing.T) {
+	l := make(chanLogger, 1)
+	go CSVExporterWithConfig(CSVConfig{
+		Registry:      NewRegistry(),
+		FlushInterval: time.Hour,
+		Writer:        errWriter{},
+		Fields:        []string{"foo.count"},
+		Logger:        l,
+	})
+	select {
+	case <-l:
+	case <-time.After(time.Second):
+		t.Error("failed header wasn't logged")
+	}
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"runtime"
 	"runtime/debug"
 	"time"
 )
@@ -8,11 +9,11 @@ import (
 var (
 	debugMetrics struct {
 		GCStats struct {
-			LastGC Gauge
-			NumGC  Gauge
-			Pause  Histogram
-			//PauseQuantiles Histogram
-			PauseTotal Gauge
+			LastGC         Gauge
+			NumGC          Gauge
+			Pause          Histogram
+			PauseQuantiles Histogram
+			PauseTotal     Counter
 		}
 		ReadGCStats Timer
 	}
@@ -37,6 +38,7 @@ func CaptureDebugGCStats(r Registry, d time.Duration) {
 // operation, isn't something you want to be doing all the time.
 func CaptureDebugGCStatsOnce(r Registry) {
 	lastGC := gcStats.LastGC
+	pauseTotal := gcStats.PauseTotal
 	t := time.Now()
 	debug.ReadGCStats(&gcStats)
 	debugMetrics.ReadGCStats.UpdateSince(t)
@@ -46,8 +48,12 @@ func CaptureDebugGCStatsOnce(r Registry) {
 	if lastGC != gcStats.LastGC && 0 < len(gcStats.Pause) {
 		debugMetrics.GCStats.Pause.Update(int64(gcStats.Pause[0]))
 	}
-	//debugMetrics.GCStats.PauseQuantiles.Update(gcStats.PauseQuantiles)
-	debugMetrics.GCStats.PauseTotal.Update(int64(gcStats.PauseTotal))
+	if lastGC != gcStats.LastGC {
+		for _, pause := range gcStats.PauseQuantiles {
+			debugMetrics.GCStats.PauseQuantiles.Update(int64(pause))
+		}
+	}
+	debugMetrics.GCStats.PauseTotal.Inc(int64(gcStats.PauseTotal - pauseTotal))
 }
 
 // Register metrics for the Go garbage collector statistics exported in
@@ -57,20 +63,24 @@ func RegisterDebugGCStats(r Registry) {
 	debugMetrics.GCStats.LastGC = NewGauge()
 	debugMetrics.GCStats.NumGC = NewGauge()
 	debugMetrics.GCStats.Pause = NewHistogram(NewExpDecaySample(1028, 0.015))
-	//debugMetrics.GCStats.PauseQuantiles = NewHistogram(NewExpDecaySample(1028, 0.015))
-	debugMetrics.GCStats.PauseTotal = NewGauge()
+	debugMetrics.GCStats.PauseQuantiles = NewHistogram(NewExpDecaySample(1028, 0.015))
+	debugMetrics.GCStats.PauseTotal = NewCounter()
 	debugMetrics.ReadGCStats = NewTimer()
 
 	r.Register("debug.GCStats.LastGC", debugMetrics.GCStats.LastGC)
 	r.Register("debug.GCStats.NumGC", debugMetrics.GCStats.NumGC)
 	r.Register("debug.GCStats.Pause", debugMetrics.GCStats.Pause)
-	//r.Register("debug.GCStats.PauseQuantiles", debugMetrics.GCStats.PauseQuantiles)
+	r.Register("debug.GCStats.PauseQuantiles", debugMetrics.GCStats.PauseQuantiles)
 	r.Register("debug.GCStats.PauseTotal", debugMetrics.GCStats.PauseTotal)
 	r.Register("debug.ReadGCStats", debugMetrics.ReadGCStats)
 }
 
-// Allocate an initial slice for gcStats.Pause to avoid allocations during
-// normal operation.
+// Allocate initial slices for gcStats.Pause and gcStats.PauseQuantiles to
+// avoid allocations during normal operation.  debug.ReadGCStats reallocates
+// Pause unless its capacity can hold two copies of the runtime's pause
+// history.
 func init() {
-	gcStats.Pause = make([]time.Duration, 11)
+	const maxPause = len(runtime.MemStats{}.PauseNs)
+	gcStats.Pause = make([]time.Duration, 11, 2*maxPause+3)
+	gcStats.PauseQuantiles = make([]time.Duration, 5)
 }
@@ -16,6 +16,37 @@ func BenchmarkDebugGCStats(b *testing.B) {
 	}
 }
 
+func TestDebugGCStatsAdvance(t *testing.T) {
+	r := NewRegistry()
+	RegisterDebugGCStats(r)
+	CaptureDebugGCStatsOnce(r)
+	numGC := debugMetrics.GCStats.NumGC.Value()
+	pauseTotal := debugMetrics.GCStats.PauseTotal.Count()
+	pauseQuantiles := debugMetrics.GCStats.PauseQuantiles.Count()
+	runtime.GC()
+	CaptureDebugGCStatsOnce(r)
+	if n := debugMetrics.GCStats.NumGC.Value(); n <= numGC {
+		t.Errorf("NumGC: %v <= %v\n", n, numGC)
+	}
+	if n := debugMetrics.GCStats.PauseTotal.Count(); n <= pauseTotal {
+		t.Errorf("PauseTotal: %v <= %v\n", n, pauseTotal)
+	}
+	if n := debugMetrics.GCStats.PauseQuantiles.Count(); n != pauseQuantiles+5 {
+		t.Errorf("PauseQuantiles.Count(): %v != %v\n", pauseQuantiles+5, n)
+	}
+}
+
+func TestDebugGCStatsNoAllocs(t *testing.T) {
+	r := NewRegistry()
+	RegisterDebugGCStats(r)
+	CaptureDebugGCStatsOnce(r)
+	pause := &gcStats.Pause[:1][0]
+	CaptureDebugGCStatsOnce(r)
+	if &gcStats.Pause[:1][0] != pause {
+		t.Error("debug.ReadGCStats reallocated gcStats.Pause")
+	}
+}
+
 func TestDebugGCStatsBlocking(t *testing.T) {
 	if g := runtime.GOMAXPROCS(0); g < 2 {
 		t.Skipf("skipping TestDebugGCMemStatsBlocking with GOMAXPROCS=%d\n", g)
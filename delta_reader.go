@@ -0,0 +1,27 @@
+package metrics
+
+import "sync/atomic"
+
+// DeltaReader reads the change in a Counter since it was last read, for
+// exporters to backends such as StatsD which want per-interval deltas rather
+// than cumulative counts.
+type DeltaReader struct {
+	last    int64 // accessed atomically, first for 64-bit alignment
+	counter Counter
+}
+
+// NewDeltaReader constructs a new DeltaReader whose first Delta is the
+// counter's count when it's read.
+func NewDeltaReader(c Counter) *DeltaReader {
+	return &DeltaReader{counter: c}
+}
+
+// Delta returns the counter's count less its count at the previous call.  The
+// baseline is swapped atomically so that, however increments and calls
+// interleave, the deltas sum to the change in the count with none lost or
+// counted twice, though with concurrent callers an individual delta may be
+// negative.
+func (d *DeltaReader) Delta() int64 {
+	count := d.counter.Count()
+	return count - atomic.SwapInt64(&d.last, count)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeltaReader(t *testing.T) {
+	c := NewCounter()
+	d := NewDeltaReader(c)
+	c.Inc(3)
+	if delta := d.Delta(); 3 != delta {
+		t.Errorf("d.Delta(): 3 != %v\n", delta)
+	}
+	if delta := d.Delta(); 0 != delta {
+		t.Errorf("d.Delta(): 0 != %v\n", delta)
+	}
+	c.Inc(2)
+	c.Dec(4)
+	if delta := d.Delta(); -2 != delta {
+		t.Errorf("d.Delta(): -2 != %v\n", delta)
+	}
+}
+
+func TestDeltaReaderConcurrent(t *testing.T) {
+	c := NewCounter()
+	d := NewDeltaReader(c)
+	var sum int64
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Inc(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				atomic.AddInt64(&sum, d.Delta())
+			}
+		}()
+	}
+	wg.Wait()
+	sum += d.Delta()
+	if 4000 != sum {
+		t.Errorf("sum: 4000 != %v\n", sum)
+	}
+}
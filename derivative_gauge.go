@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// NewDerivativeGauge constructs a new DerivativeGauge of the rate of change
+// per second of source and launches a goroutine which samples source every
+// interval.
+// Be sure to call Stop() once the gauge is of no use to allow for garbage collection.
+func NewDerivativeGauge(source Gauge, interval time.Duration) GaugeFloat64 {
+	if UseNilMetrics || UseNilGauges {
+		return NilGaugeFloat64{}
+	}
+	g := newDerivativeGauge(source)
+	go g.run(interval)
+	return g
+}
+
+// NewRegisteredDerivativeGauge constructs and registers a new
+// DerivativeGauge.
+// Be sure to unregister the gauge from the registry once it is of no use to
+// allow for garbage collection.
+func NewRegisteredDerivativeGauge(name string, r Registry, source Gauge, interval time.Duration) GaugeFloat64 {
+	c := NewDerivativeGauge(source, interval)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+func newDerivativeGauge(source Gauge) *DerivativeGauge {
+	return &DerivativeGauge{source: source, done: make(chan struct{})}
+}
+
+// DerivativeGauge is the rate of change per second of another gauge between
+// its last two samples, say to watch how fast a disk is filling.  Its value is
+// zero until the source has been sampled twice.
+type DerivativeGauge struct {
+	done     chan struct{}
+	last     int64
+	lastTime time.Time // zero until the first sample
+	mutex    sync.Mutex
+	once     sync.Once
+	rate     float64
+	source   Gauge
+}
+
+// Snapshot returns a read-only copy of the gauge.
+func (g *DerivativeGauge) Snapshot() GaugeFloat64 { return GaugeFloat64Snapshot(g.Value()) }
+
+// Stop stops sampling the source.  The last rate is kept.
+func (g *DerivativeGauge) Stop() {
+	g.once.Do(func() { close(g.done) })
+}
+
+// Update panics.
+func (*DerivativeGauge) Update(float64) {
+	panic("Update called on a DerivativeGauge")
+}
+
+// UpdateMax panics.
+func (*DerivativeGauge) UpdateMax(float64) {
+	panic("UpdateMax called on a DerivativeGauge")
+}
+
+// UpdateMin panics.
+func (*DerivativeGauge) UpdateMin(float64) {
+	panic("UpdateMin called on a DerivativeGauge")
+}
+
+// Value returns the rate of change per second of the source between its last
+// two samples.
+func (g *DerivativeGauge) Value() float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.rate
+}
+
+// sample reads the source and, if it's been read before, computes the rate
+// of change since then, dividing by the time actually elapsed so a late tick
+// doesn't inflate the rate.
+func (g *DerivativeGauge) sample(now time.Time) {
+	v := g.source.Value()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if !g.lastTime.IsZero() {
+		if elapsed := now.Sub(g.lastTime).Seconds(); 0 < elapsed {
+			g.rate = float64(v-g.last) / elapsed
+		}
+	}
+	g.last, g.lastTime = v, now
+}
+
+func (g *DerivativeGauge) run(d time.Duration) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	g.sample(time.Now())
+	for {
+		select {
+		case now := <-ticker.C:
+			g.sample(now)
+		case <-g.done:
+			return
+		}
+	}
+}
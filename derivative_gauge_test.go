@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDerivativeGauge(t *testing.T) {
+	source := NewGauge()
+	g := newDerivativeGauge(source)
+	now := time.Now()
+	source.Update(10)
+	g.sample(now)
+	if v := g.Value(); 0 != v {
+		t.Errorf("g.Value(): 0 != %v\n", v)
+	}
+	source.Update(40)
+	now = now.Add(10 * time.Second)
+	g.sample(now)
+	if v := g.Value(); 3 != v {
+		t.Errorf("g.Value(): 3 != %v\n", v)
+	}
+	source.Update(20)
+	now = now.Add(5 * time.Second)
+	g.sample(now)
+	if v := g.Value(); -4 != v {
+		t.Errorf("g.Value(): -4 != %v\n", v)
+	}
+	if v := g.Snapshot().Value(); -4 != v {
+		t.Errorf("g.Snapshot().Value(): -4 != %v\n", v)
+	}
+}
+
+func TestDerivativeGaugeStop(t *testing.T) {
+	g := NewRegisteredDerivativeGauge("foo", NewRegistry(), NewGauge(), time.Millisecond)
+	g.(*DerivativeGauge).Stop()
+	g.(*DerivativeGauge).Stop()
+}
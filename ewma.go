@@ -4,6 +4,7 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // EWMAs continuously calculate an exponentially-weighted moving average
@@ -20,22 +21,37 @@ func NewEWMA(alpha float64) EWMA {
 	if UseNilMetrics {
 		return NilEWMA{}
 	}
-	return &StandardEWMA{alpha: alpha}
+	return &StandardEWMA{alpha: alpha, interval: 5 * time.Second}
 }
 
-// NewEWMA1 constructs a new EWMA for a one-minute moving average.
+// NewEWMAWithInterval constructs a new EWMA for a moving average over the
+// given window which expects to be ticked every interval.
+func NewEWMAWithInterval(window, interval time.Duration) EWMA {
+	if UseNilMetrics {
+		return NilEWMA{}
+	}
+	return &StandardEWMA{
+		alpha:    1 - math.Exp(-interval.Seconds()/60.0/window.Minutes()),
+		interval: interval,
+	}
+}
+
+// NewEWMA1 constructs a new EWMA for a one-minute moving average ticked every
+// five seconds.
 func NewEWMA1() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/1))
+	return NewEWMAWithInterval(time.Minute, 5*time.Second)
 }
 
-// NewEWMA5 constructs a new EWMA for a five-minute moving average.
+// NewEWMA5 constructs a new EWMA for a five-minute moving average ticked
+// every five seconds.
 func NewEWMA5() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/5))
+	return NewEWMAWithInterval(5*time.Minute, 5*time.Second)
 }
 
-// NewEWMA15 constructs a new EWMA for a fifteen-minute moving average.
+// NewEWMA15 constructs a new EWMA for a fifteen-minute moving average ticked
+// every five seconds.
 func NewEWMA15() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/15))
+	return NewEWMAWithInterval(15*time.Minute, 5*time.Second)
 }
 
 // EWMASnapshot is a read-only copy of another EWMA.
@@ -79,6 +95,7 @@ func (NilEWMA) Update(n int64) {}
 type StandardEWMA struct {
 	uncounted int64 // /!\ this should be the first member to ensure 64-bit alignment
 	alpha     float64
+	interval  time.Duration
 	rate      float64
 	init      bool
 	mutex     sync.Mutex
@@ -97,11 +114,11 @@ func (a *StandardEWMA) Snapshot() EWMA {
 }
 
 // Tick ticks the clock to update the moving average.  It assumes it is called
-// every five seconds.
+// once per interval, which is five seconds unless constructed otherwise.
 func (a *StandardEWMA) Tick() {
 	count := atomic.LoadInt64(&a.uncounted)
 	atomic.AddInt64(&a.uncounted, -count)
-	instantRate := float64(count) / float64(5e9)
+	instantRate := float64(count) / float64(a.interval)
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 	if a.init {
@@ -112,6 +129,16 @@ func (a *StandardEWMA) Tick() {
 	}
 }
 
+// clear discards uncounted events and resets the moving average to zero, so
+// the next tick starts it afresh.
+func (a *StandardEWMA) clear() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	atomic.StoreInt64(&a.uncounted, 0)
+	a.rate = 0
+	a.init = false
+}
+
 // Update adds n uncounted events.
 func (a *StandardEWMA) Update(n int64) {
 	atomic.AddInt64(&a.uncounted, n)
@@ -1,6 +1,10 @@
 package metrics
 
-import "testing"
+import (
+	"math"
+	"testing"
+	"time"
+)
 
 func BenchmarkEWMA(b *testing.B) {
 	a := NewEWMA1()
@@ -218,6 +222,60 @@ func TestEWMA15(t *testing.T) {
 	}
 }
 
+func TestEWMASnapshot(t *testing.T) {
+	a := NewEWMA1()
+	a.Update(3)
+	a.Tick()
+	snapshot := a.Snapshot()
+	a.Update(3)
+	a.Tick()
+	if rate := snapshot.Rate(); 0.6 != rate {
+		t.Errorf("snapshot.Rate(): 0.6 != %v\n", rate)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("snapshot.Update() didn't panic")
+		}
+	}()
+	snapshot.Update(1)
+}
+
+func TestEWMAWithIntervalConverges(t *testing.T) {
+	for _, tc := range []struct {
+		window, interval time.Duration
+		n                int64
+	}{
+		{time.Minute, time.Second, 10},
+		{5 * time.Minute, 500 * time.Millisecond, 5},
+		{15 * time.Minute, 10 * time.Second, 100},
+	} {
+		// n events every interval is ten events per second.
+		a := NewEWMAWithInterval(tc.window, tc.interval)
+		for i := 0; i < int(10*tc.window/tc.interval); i++ {
+			a.Update(tc.n)
+			a.Tick()
+		}
+		if rate := a.Rate(); math.Abs(10-rate) > 0.01 {
+			t.Errorf("%v/%v a.Rate(): 10 != %v\n", tc.window, tc.interval, rate)
+		}
+	}
+}
+
+func TestEWMAWithIntervalDecays(t *testing.T) {
+	a := NewEWMAWithInterval(time.Minute, time.Second)
+	a.Update(3)
+	a.Tick()
+	if rate := a.Rate(); 3 != rate {
+		t.Errorf("initial a.Rate(): 3 != %v\n", rate)
+	}
+	for i := 0; i < 60; i++ {
+		a.Tick()
+	}
+	if rate, want := a.Rate(), 3*math.Exp(-1); math.Abs(want-rate) > 1e-9 {
+		t.Errorf("1 minute a.Rate(): %v != %v\n", want, rate)
+	}
+}
+
 func elapseMinute(a EWMA) {
 	for i := 0; i < 12; i++ {
 		a.Tick()
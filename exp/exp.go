@@ -6,6 +6,8 @@ import (
 	"expvar"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/rcrowley/go-metrics"
@@ -93,17 +95,16 @@ func (exp *exp) publishGaugeFloat64(name string, metric metrics.GaugeFloat64) {
 
 func (exp *exp) publishHistogram(name string, metric metrics.Histogram) {
 	h := metric.Snapshot()
-	ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+	ps := metrics.ReportedPercentiles(h)
+	scores := h.Percentiles(ps)
 	exp.getInt(name + ".count").Set(h.Count())
 	exp.getFloat(name + ".min").Set(float64(h.Min()))
 	exp.getFloat(name + ".max").Set(float64(h.Max()))
 	exp.getFloat(name + ".mean").Set(float64(h.Mean()))
 	exp.getFloat(name + ".std-dev").Set(float64(h.StdDev()))
-	exp.getFloat(name + ".50-percentile").Set(float64(ps[0]))
-	exp.getFloat(name + ".75-percentile").Set(float64(ps[1]))
-	exp.getFloat(name + ".95-percentile").Set(float64(ps[2]))
-	exp.getFloat(name + ".99-percentile").Set(float64(ps[3]))
-	exp.getFloat(name + ".999-percentile").Set(float64(ps[4]))
+	for i, p := range ps {
+		exp.getFloat(name + "." + percentileKey(p)).Set(scores[i])
+	}
 }
 
 func (exp *exp) publishMeter(name string, metric metrics.Meter) {
@@ -117,17 +118,16 @@ func (exp *exp) publishMeter(name string, metric metrics.Meter) {
 
 func (exp *exp) publishTimer(name string, metric metrics.Timer) {
 	t := metric.Snapshot()
-	ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+	ps := metrics.ReportedPercentiles(t)
+	scores := t.Percentiles(ps)
 	exp.getInt(name + ".count").Set(t.Count())
 	exp.getFloat(name + ".min").Set(float64(t.Min()))
 	exp.getFloat(name + ".max").Set(float64(t.Max()))
 	exp.getFloat(name + ".mean").Set(float64(t.Mean()))
 	exp.getFloat(name + ".std-dev").Set(float64(t.StdDev()))
-	exp.getFloat(name + ".50-percentile").Set(float64(ps[0]))
-	exp.getFloat(name + ".75-percentile").Set(float64(ps[1]))
-	exp.getFloat(name + ".95-percentile").Set(float64(ps[2]))
-	exp.getFloat(name + ".99-percentile").Set(float64(ps[3]))
-	exp.getFloat(name + ".999-percentile").Set(float64(ps[4]))
+	for i, p := range ps {
+		exp.getFloat(name + "." + percentileKey(p)).Set(scores[i])
+	}
 	exp.getFloat(name + ".one-minute").Set(float64(t.Rate1()))
 	exp.getFloat(name + ".five-minute").Set(float64(t.Rate5()))
 	exp.getFloat(name + ".fifteen-minute").Set(float64((t.Rate15())))
@@ -154,3 +154,9 @@ func (exp *exp) syncToExpvar() {
 		}
 	})
 }
+
+// percentileKey returns the suffix of the name of a percentile, say
+// "999-percentile" for 0.999.
+func percentileKey(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p*100.0, 'f', -1, 64), ".", "", 1) + "-percentile"
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// FloatCounters hold a float64 value that can be incremented, say by
+// fractional quantities which an int64 Counter would round away.
+type FloatCounter interface {
+	Clear()
+	Count() float64
+	Inc(float64)
+	Snapshot() FloatCounter
+}
+
+// GetFloatCounter returns the FloatCounter registered under the given name or
+// nil if there is none or it is another kind of metric.
+func GetFloatCounter(name string, r Registry) FloatCounter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(FloatCounter)
+	return m
+}
+
+// GetOrRegisterFloatCounter returns an existing FloatCounter or constructs and
+// registers a new StandardFloatCounter.
+func GetOrRegisterFloatCounter(name string, r Registry) FloatCounter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewFloatCounter).(FloatCounter)
+}
+
+// NewFloatCounter constructs a new StandardFloatCounter.
+func NewFloatCounter() FloatCounter {
+	if UseNilMetrics || UseNilCounters {
+		return NilFloatCounter{}
+	}
+	return &StandardFloatCounter{}
+}
+
+// NewRegisteredFloatCounter constructs and registers a new
+// StandardFloatCounter.
+func NewRegisteredFloatCounter(name string, r Registry) FloatCounter {
+	c := NewFloatCounter()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// FloatCounterSnapshot is a read-only copy of another FloatCounter.
+type FloatCounterSnapshot float64
+
+// Clear panics.
+func (FloatCounterSnapshot) Clear() {
+	panic("Clear called on a FloatCounterSnapshot")
+}
+
+// Count returns the count at the time the snapshot was taken.
+func (c FloatCounterSnapshot) Count() float64 { return float64(c) }
+
+// Inc panics.
+func (FloatCounterSnapshot) Inc(float64) {
+	panic("Inc called on a FloatCounterSnapshot")
+}
+
+// Snapshot returns the snapshot.
+func (c FloatCounterSnapshot) Snapshot() FloatCounter { return c }
+
+// NilFloatCounter is a no-op FloatCounter.
+type NilFloatCounter struct{}
+
+// Clear is a no-op.
+func (NilFloatCounter) Clear() {}
+
+// Count is a no-op.
+func (NilFloatCounter) Count() float64 { return 0.0 }
+
+// Inc is a no-op.
+func (NilFloatCounter) Inc(i float64) {}
+
+// Snapshot is a no-op.
+func (NilFloatCounter) Snapshot() FloatCounter { return NilFloatCounter{} }
+
+// StandardFloatCounter is the standard implementation of a FloatCounter and
+// uses the sync/atomic package to manage a single float64 value stored as its
+// IEEE 754 bits.
+type StandardFloatCounter struct {
+	count      uint64
+	lastUpdate int64 // nanoseconds since the Unix epoch
+}
+
+// Clear sets the counter to zero.
+func (c *StandardFloatCounter) Clear() {
+	atomic.StoreUint64(&c.count, 0)
+	touchLastUpdate(&c.lastUpdate)
+}
+
+// Count returns the current count.
+func (c *StandardFloatCounter) Count() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.count))
+}
+
+// Inc increments the counter by the given amount.
+func (c *StandardFloatCounter) Inc(i float64) {
+	for {
+		old := atomic.LoadUint64(&c.count)
+		if atomic.CompareAndSwapUint64(&c.count, old, math.Float64bits(math.Float64frombits(old)+i)) {
+			touchLastUpdate(&c.lastUpdate)
+			return
+		}
+	}
+}
+
+// LastUpdate returns when the counter was last changed, or the zero Time if
+// it never has been.
+func (c *StandardFloatCounter) LastUpdate() time.Time {
+	return loadLastUpdate(&c.lastUpdate)
+}
+
+// Snapshot returns a read-only copy of the counter.
+func (c *StandardFloatCounter) Snapshot() FloatCounter {
+	return FloatCounterSnapshot(c.Count())
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func BenchmarkFloatCounter(b *testing.B) {
+	c := NewFloatCounter()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Inc(0.5)
+	}
+}
+
+func TestFloatCounterClear(t *testing.T) {
+	c := NewFloatCounter()
+	c.Inc(1.5)
+	c.Clear()
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestFloatCounterInc(t *testing.T) {
+	c := NewFloatCounter()
+	c.Inc(0.25)
+	c.Inc(1.5)
+	if count := c.Count(); 1.75 != count {
+		t.Errorf("c.Count(): 1.75 != %v\n", count)
+	}
+}
+
+func TestFloatCounterIncConcurrent(t *testing.T) {
+	c := NewFloatCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc(0.1)
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Count(); 1e-6 < math.Abs(count-10000) {
+		t.Errorf("c.Count(): 10000 != %v\n", count)
+	}
+}
+
+func TestFloatCounterSnapshot(t *testing.T) {
+	c := NewFloatCounter()
+	c.Inc(0.5)
+	snapshot := c.Snapshot()
+	c.Inc(0.5)
+	if count := snapshot.Count(); 0.5 != count {
+		t.Errorf("snapshot.Count(): 0.5 != %v\n", count)
+	}
+}
+
+func TestGetOrRegisterFloatCounter(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredFloatCounter("foo", r).Inc(0.5)
+	if c := GetOrRegisterFloatCounter("foo", r); 0.5 != c.Count() {
+		t.Fatal(c)
+	}
+	if c := GetFloatCounter("foo", r); nil == c {
+		t.Fatal(c)
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+// flushDedup tracks the value last sent of each series an exporter flushes,
+// say "foo.count", so that it can skip those unchanged since the last flush
+// while still sending each at least every n flushes to keep it alive.  What
+// send decides during a flush is staged until commit, once the flush has been
+// written, so that after a failed flush every value is decided afresh.  A nil
+// flushDedup sends everything.
+type flushDedup struct {
+	n      int
+	sent   map[string]dedupedSeries
+	staged map[string]dedupedSeries
+}
+
+// dedupedSeries is the value last sent of a series and the number of flushes
+// it's been skipped since.
+type dedupedSeries struct {
+	skipped int
+	value   string
+}
+
+// newFlushDedup constructs a flushDedup which sends an unchanged value every
+// n flushes, or nil, which sends everything, if n is less than two.
+func newFlushDedup(n int) *flushDedup {
+	if n < 2 {
+		return nil
+	}
+	return &flushDedup{n: n, sent: make(map[string]dedupedSeries), staged: make(map[string]dedupedSeries)}
+}
+
+// commit ends a flush which was written, forgetting the series which weren't
+// seen in it, say those of unregistered metrics.
+func (d *flushDedup) commit() {
+	if nil == d {
+		return
+	}
+	d.sent, d.staged = d.staged, make(map[string]dedupedSeries, len(d.staged))
+}
+
+// discard ends a flush which failed, as if it had never been attempted.
+func (d *flushDedup) discard() {
+	if nil == d {
+		return
+	}
+	d.staged = make(map[string]dedupedSeries, len(d.sent))
+}
+
+// send returns whether the given value of a series should be sent, which it
+// should unless it's the value last sent and that was fewer than n flushes
+// ago.
+func (d *flushDedup) send(key, value string) bool {
+	if nil == d {
+		return true
+	}
+	s, ok := d.sent[key]
+	if ok && value == s.value && s.skipped < d.n-1 {
+		d.staged[key] = dedupedSeries{skipped: s.skipped + 1, value: value}
+		return false
+	}
+	d.staged[key] = dedupedSeries{value: value}
+	return true
+}
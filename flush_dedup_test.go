@@ -0,0 +1,57 @@
+package metrics
+
+import "testing"
+
+func TestFlushDedup(t *testing.T) {
+	d := newFlushDedup(2)
+	for i, test := range []struct {
+		key, value string
+		send       bool
+	}{
+		{"foo.count", "1", true},
+		{"foo.count", "1", false},
+		{"foo.count", "1", true},
+		{"foo.count", "2", true},
+		{"foo.count", "1", true},
+	} {
+		if send := d.send(test.key, test.value); test.send != send {
+			t.Errorf("%d: d.send(%q, %q): %v != %v\n", i, test.key, test.value, test.send, send)
+		}
+		d.commit()
+	}
+	d.commit()
+	if 0 != len(d.sent) {
+		t.Errorf("len(d.sent): 0 != %v\n", len(d.sent))
+	}
+}
+
+func TestFlushDedupDiscard(t *testing.T) {
+	d := newFlushDedup(3)
+	d.send("foo.count", "1")
+	d.commit()
+	if d.send("foo.count", "1") {
+		t.Error("d.send(): false != true")
+	}
+	d.discard()
+	if d.send("foo.count", "1") {
+		t.Error("d.send() after d.discard(): false != true")
+	}
+	d.commit()
+	if d.send("foo.count", "1") {
+		t.Error("d.send(): false != true")
+	}
+	d.commit()
+	if !d.send("foo.count", "1") {
+		t.Error("d.send() after skipping twice: true != false")
+	}
+}
+
+func TestFlushDedupNil(t *testing.T) {
+	d := newFlushDedup(1)
+	for i := 0; i < 3; i++ {
+		if !d.send("foo.count", "1") {
+			t.Errorf("%d: d.send(): true != false\n", i)
+		}
+		d.commit()
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import "time"
+
+// FlushTicker delivers the ticks on which an exporter flushes, every interval
+// either from when it's constructed or, if aligned, on multiples of the
+// interval since the Unix epoch, so that, say, every host flushing every
+// minute flushes on the minute and their points can be aggregated.
+//
+// Every periodic exporter flushes on a FlushTicker.  Those configured by a
+// struct, such as GraphiteConfig, InfluxDBConfig or CSVConfig, align it if
+// its AlignFlush is set; Log, Syslog, Write and WriteJSON, which take only an
+// interval, never align it.
+type FlushTicker struct {
+	C      <-chan time.Time // The channel on which the ticks are delivered.
+	stop   chan struct{}
+	ticker *time.Ticker // if not aligned
+}
+
+// NewFlushTicker constructs a new FlushTicker ticking every d, aligned to
+// multiples of d if align is true, in which case the first tick may come
+// sooner than d.  Like a time.Ticker it drops ticks for a slow receiver.
+// Be sure to call Stop() once the ticker is of no use to release it.
+func NewFlushTicker(d time.Duration, align bool) *FlushTicker {
+	if !align {
+		ticker := time.NewTicker(d)
+		return &FlushTicker{C: ticker.C, ticker: ticker}
+	}
+	return newAlignedFlushTicker(systemClock{}, time.After, d)
+}
+
+// newAlignedFlushTicker constructs an aligned FlushTicker which waits for the
+// first boundary after the clock's time with after.
+func newAlignedFlushTicker(clock Clock, after func(time.Duration) <-chan time.Time, d time.Duration) *FlushTicker {
+	c := make(chan time.Time, 1)
+	t := &FlushTicker{C: c, stop: make(chan struct{})}
+	go t.run(c, after(AlignDelay(clock.Now(), d)), d)
+	return t
+}
+
+// AlignDelay returns how long after now the next multiple of d since the Unix
+// epoch is, or zero if now is one.
+func AlignDelay(now time.Time, d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return (d - time.Duration(now.UnixNano()%int64(d))) % d
+}
+
+// Stop turns off the ticker.  Like a time.Ticker's Stop it doesn't close C.
+func (t *FlushTicker) Stop() {
+	if nil != t.ticker {
+		t.ticker.Stop()
+		return
+	}
+	close(t.stop)
+}
+
+// run delivers a tick at the first boundary and then every d after it.
+func (t *FlushTicker) run(c chan<- time.Time, first <-chan time.Time, d time.Duration) {
+	select {
+	case now := <-first:
+		sendTick(c, now)
+	case <-t.stop:
+		return
+	}
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			sendTick(c, now)
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// sendTick sends now on c unless its receiver is behind.
+func sendTick(c chan<- time.Time, now time.Time) {
+	select {
+	case c <- now:
+	default:
+	}
+}
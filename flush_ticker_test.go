@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlignDelay(t *testing.T) {
+	for _, test := range []struct {
+		now   time.Duration // since the Unix epoch
+		d     time.Duration
+		delay time.Duration
+	}{
+		{7300 * time.Millisecond, 10 * time.Second, 2700 * time.Millisecond},
+		{10 * time.Second, 10 * time.Second, 0},
+		{61 * time.Second, time.Minute, 59 * time.Second},
+		{61 * time.Second, 0, 0},
+	} {
+		if delay := AlignDelay(time.Unix(0, int64(test.now)), test.d); test.delay != delay {
+			t.Errorf("AlignDelay(%v, %v): %v != %v\n", test.now, test.d, test.delay, delay)
+		}
+	}
+}
+
+func TestFlushTickerAligned(t *testing.T) {
+	clock := newManualClock()
+	clock.Add(time.Hour + 7300*time.Millisecond)
+	first := make(chan time.Time, 1)
+	var delay time.Duration
+	after := func(d time.Duration) <-chan time.Time {
+		delay = d
+		return first
+	}
+	ticker := newAlignedFlushTicker(clock, after, 10*time.Second)
+	defer ticker.Stop()
+	boundary := clock.Now().Add(delay)
+	if !boundary.Equal(boundary.Truncate(10 * time.Second)) {
+		t.Errorf("first flush at %v, not on a boundary\n", boundary)
+	}
+	if 2700*time.Millisecond != delay {
+		t.Errorf("delay: 2.7s != %v\n", delay)
+	}
+	first <- boundary
+	select {
+	case now := <-ticker.C:
+		if !boundary.Equal(now) {
+			t.Errorf("tick: %v != %v\n", boundary, now)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tick")
+	}
+}
+
+func TestNewFlushTicker(t *testing.T) {
+	ticker := NewFlushTicker(time.Millisecond, false)
+	defer ticker.Stop()
+	<-ticker.C
+	aligned := NewFlushTicker(time.Millisecond, true)
+	defer aligned.Stop()
+	<-aligned.C
+	<-aligned.C
+}
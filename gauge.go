@@ -1,6 +1,9 @@
 package metrics
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Gauges hold an int64 value that can be set arbitrarily.
 type Gauge interface {
@@ -9,6 +12,25 @@ type Gauge interface {
 	Value() int64
 }
 
+// WatermarkGauges can raise or lower their value atomically, say to track a
+// high-water mark.  It's not part of Gauge so that implementations predating
+// it still are Gauges.
+type WatermarkGauge interface {
+	Gauge
+	UpdateMax(int64)
+	UpdateMin(int64)
+}
+
+// GetGauge returns the Gauge registered under the given name or nil if there is
+// none or it is another kind of metric.
+func GetGauge(name string, r Registry) Gauge {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(Gauge)
+	return m
+}
+
 // GetOrRegisterGauge returns an existing Gauge or constructs and registers a
 // new StandardGauge.
 func GetOrRegisterGauge(name string, r Registry) Gauge {
@@ -20,10 +42,10 @@ func GetOrRegisterGauge(name string, r Registry) Gauge {
 
 // NewGauge constructs a new StandardGauge.
 func NewGauge() Gauge {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilGauges {
 		return NilGauge{}
 	}
-	return &StandardGauge{0}
+	return &StandardGauge{}
 }
 
 // NewRegisteredGauge constructs and registers a new StandardGauge.
@@ -38,13 +60,13 @@ func NewRegisteredGauge(name string, r Registry) Gauge {
 
 // NewFunctionalGauge constructs a new FunctionalGauge.
 func NewFunctionalGauge(f func() int64) Gauge {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilGauges {
 		return NilGauge{}
 	}
 	return &FunctionalGauge{value: f}
 }
 
-// NewRegisteredFunctionalGauge constructs and registers a new StandardGauge.
+// NewRegisteredFunctionalGauge constructs and registers a new FunctionalGauge.
 func NewRegisteredFunctionalGauge(name string, r Registry, f func() int64) Gauge {
 	c := NewFunctionalGauge(f)
 	if nil == r {
@@ -65,6 +87,16 @@ func (GaugeSnapshot) Update(int64) {
 	panic("Update called on a GaugeSnapshot")
 }
 
+// UpdateMax panics.
+func (GaugeSnapshot) UpdateMax(int64) {
+	panic("UpdateMax called on a GaugeSnapshot")
+}
+
+// UpdateMin panics.
+func (GaugeSnapshot) UpdateMin(int64) {
+	panic("UpdateMin called on a GaugeSnapshot")
+}
+
 // Value returns the value at the time the snapshot was taken.
 func (g GaugeSnapshot) Value() int64 { return int64(g) }
 
@@ -77,13 +109,26 @@ func (NilGauge) Snapshot() Gauge { return NilGauge{} }
 // Update is a no-op.
 func (NilGauge) Update(v int64) {}
 
+// UpdateMax is a no-op.
+func (NilGauge) UpdateMax(v int64) {}
+
+// UpdateMin is a no-op.
+func (NilGauge) UpdateMin(v int64) {}
+
 // Value is a no-op.
 func (NilGauge) Value() int64 { return 0 }
 
 // StandardGauge is the standard implementation of a Gauge and uses the
 // sync/atomic package to manage a single int64 value.
 type StandardGauge struct {
-	value int64
+	value      int64
+	lastUpdate int64 // nanoseconds since the Unix epoch
+}
+
+// LastUpdate returns when the gauge was last updated, or the zero Time if it
+// never has been.
+func (g *StandardGauge) LastUpdate() time.Time {
+	return loadLastUpdate(&g.lastUpdate)
 }
 
 // Snapshot returns a read-only copy of the gauge.
@@ -94,6 +139,32 @@ func (g *StandardGauge) Snapshot() Gauge {
 // Update updates the gauge's value.
 func (g *StandardGauge) Update(v int64) {
 	atomic.StoreInt64(&g.value, v)
+	touchLastUpdate(&g.lastUpdate)
+}
+
+// UpdateMax updates the gauge's value if v is greater, atomically so that
+// concurrent callers keep the maximum, say as a high-water mark.  A new
+// gauge's value is zero.
+func (g *StandardGauge) UpdateMax(v int64) {
+	touchLastUpdate(&g.lastUpdate)
+	for {
+		old := atomic.LoadInt64(&g.value)
+		if v <= old || atomic.CompareAndSwapInt64(&g.value, old, v) {
+			return
+		}
+	}
+}
+
+// UpdateMin updates the gauge's value if v is less, atomically so that
+// concurrent callers keep the minimum.  A new gauge's value is zero.
+func (g *StandardGauge) UpdateMin(v int64) {
+	touchLastUpdate(&g.lastUpdate)
+	for {
+		old := atomic.LoadInt64(&g.value)
+		if v >= old || atomic.CompareAndSwapInt64(&g.value, old, v) {
+			return
+		}
+	}
 }
 
 // Value returns the gauge's current value.
@@ -118,3 +189,13 @@ func (g FunctionalGauge) Snapshot() Gauge { return GaugeSnapshot(g.Value()) }
 func (FunctionalGauge) Update(int64) {
 	panic("Update called on a FunctionalGauge")
 }
+
+// UpdateMax panics.
+func (FunctionalGauge) UpdateMax(int64) {
+	panic("UpdateMax called on a FunctionalGauge")
+}
+
+// UpdateMin panics.
+func (FunctionalGauge) UpdateMin(int64) {
+	panic("UpdateMin called on a FunctionalGauge")
+}
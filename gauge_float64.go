@@ -1,6 +1,10 @@
 package metrics
 
-import "sync"
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
 
 // GaugeFloat64s hold a float64 value that can be set arbitrarily.
 type GaugeFloat64 interface {
@@ -9,23 +13,30 @@ type GaugeFloat64 interface {
 	Value() float64
 }
 
+// WatermarkGaugeFloat64s can raise or lower their value atomically, say to
+// track a high-water mark.  It's not part of GaugeFloat64 so that
+// implementations predating it still are GaugeFloat64s.
+type WatermarkGaugeFloat64 interface {
+	GaugeFloat64
+	UpdateMax(float64)
+	UpdateMin(float64)
+}
+
 // GetOrRegisterGaugeFloat64 returns an existing GaugeFloat64 or constructs and registers a
 // new StandardGaugeFloat64.
 func GetOrRegisterGaugeFloat64(name string, r Registry) GaugeFloat64 {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	return r.GetOrRegister(name, NewGaugeFloat64()).(GaugeFloat64)
+	return r.GetOrRegister(name, NewGaugeFloat64).(GaugeFloat64)
 }
 
 // NewGaugeFloat64 constructs a new StandardGaugeFloat64.
 func NewGaugeFloat64() GaugeFloat64 {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilGauges {
 		return NilGaugeFloat64{}
 	}
-	return &StandardGaugeFloat64{
-		value: 0.0,
-	}
+	return &StandardGaugeFloat64{}
 }
 
 // NewRegisteredGaugeFloat64 constructs and registers a new StandardGaugeFloat64.
@@ -38,15 +49,16 @@ func NewRegisteredGaugeFloat64(name string, r Registry) GaugeFloat64 {
 	return c
 }
 
-// NewFunctionalGauge constructs a new FunctionalGauge.
+// NewFunctionalGaugeFloat64 constructs a new FunctionalGaugeFloat64.
 func NewFunctionalGaugeFloat64(f func() float64) GaugeFloat64 {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilGauges {
 		return NilGaugeFloat64{}
 	}
 	return &FunctionalGaugeFloat64{value: f}
 }
 
-// NewRegisteredFunctionalGauge constructs and registers a new StandardGauge.
+// NewRegisteredFunctionalGaugeFloat64 constructs and registers a new
+// FunctionalGaugeFloat64.
 func NewRegisteredFunctionalGaugeFloat64(name string, r Registry, f func() float64) GaugeFloat64 {
 	c := NewFunctionalGaugeFloat64(f)
 	if nil == r {
@@ -67,10 +79,20 @@ func (GaugeFloat64Snapshot) Update(float64) {
 	panic("Update called on a GaugeFloat64Snapshot")
 }
 
+// UpdateMax panics.
+func (GaugeFloat64Snapshot) UpdateMax(float64) {
+	panic("UpdateMax called on a GaugeFloat64Snapshot")
+}
+
+// UpdateMin panics.
+func (GaugeFloat64Snapshot) UpdateMin(float64) {
+	panic("UpdateMin called on a GaugeFloat64Snapshot")
+}
+
 // Value returns the value at the time the snapshot was taken.
 func (g GaugeFloat64Snapshot) Value() float64 { return float64(g) }
 
-// NilGauge is a no-op Gauge.
+// NilGaugeFloat64 is a no-op GaugeFloat64.
 type NilGaugeFloat64 struct{}
 
 // Snapshot is a no-op.
@@ -79,14 +101,27 @@ func (NilGaugeFloat64) Snapshot() GaugeFloat64 { return NilGaugeFloat64{} }
 // Update is a no-op.
 func (NilGaugeFloat64) Update(v float64) {}
 
+// UpdateMax is a no-op.
+func (NilGaugeFloat64) UpdateMax(v float64) {}
+
+// UpdateMin is a no-op.
+func (NilGaugeFloat64) UpdateMin(v float64) {}
+
 // Value is a no-op.
 func (NilGaugeFloat64) Value() float64 { return 0.0 }
 
 // StandardGaugeFloat64 is the standard implementation of a GaugeFloat64 and uses
-// sync.Mutex to manage a single float64 value.
+// the sync/atomic package to manage a single float64 value stored as its
+// IEEE 754 bits.
 type StandardGaugeFloat64 struct {
-	mutex sync.Mutex
-	value float64
+	value      uint64
+	lastUpdate int64 // nanoseconds since the Unix epoch
+}
+
+// LastUpdate returns when the gauge was last updated, or the zero Time if it
+// never has been.
+func (g *StandardGaugeFloat64) LastUpdate() time.Time {
+	return loadLastUpdate(&g.lastUpdate)
 }
 
 // Snapshot returns a read-only copy of the gauge.
@@ -96,16 +131,38 @@ func (g *StandardGaugeFloat64) Snapshot() GaugeFloat64 {
 
 // Update updates the gauge's value.
 func (g *StandardGaugeFloat64) Update(v float64) {
-	g.mutex.Lock()
-	defer g.mutex.Unlock()
-	g.value = v
+	atomic.StoreUint64(&g.value, math.Float64bits(v))
+	touchLastUpdate(&g.lastUpdate)
+}
+
+// UpdateMax updates the gauge's value if v is greater, atomically so that
+// concurrent callers keep the maximum, say as a high-water mark.  A new
+// gauge's value is zero.
+func (g *StandardGaugeFloat64) UpdateMax(v float64) {
+	touchLastUpdate(&g.lastUpdate)
+	for {
+		old := atomic.LoadUint64(&g.value)
+		if !(v > math.Float64frombits(old)) || atomic.CompareAndSwapUint64(&g.value, old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// UpdateMin updates the gauge's value if v is less, atomically so that
+// concurrent callers keep the minimum.  A new gauge's value is zero.
+func (g *StandardGaugeFloat64) UpdateMin(v float64) {
+	touchLastUpdate(&g.lastUpdate)
+	for {
+		old := atomic.LoadUint64(&g.value)
+		if !(v < math.Float64frombits(old)) || atomic.CompareAndSwapUint64(&g.value, old, math.Float64bits(v)) {
+			return
+		}
+	}
 }
 
 // Value returns the gauge's current value.
 func (g *StandardGaugeFloat64) Value() float64 {
-	g.mutex.Lock()
-	defer g.mutex.Unlock()
-	return g.value
+	return math.Float64frombits(atomic.LoadUint64(&g.value))
 }
 
 // FunctionalGaugeFloat64 returns value from given function
@@ -125,3 +182,13 @@ func (g FunctionalGaugeFloat64) Snapshot() GaugeFloat64 { return GaugeFloat64Sna
 func (FunctionalGaugeFloat64) Update(float64) {
 	panic("Update called on a FunctionalGaugeFloat64")
 }
+
+// UpdateMax panics.
+func (FunctionalGaugeFloat64) UpdateMax(float64) {
+	panic("UpdateMax called on a FunctionalGaugeFloat64")
+}
+
+// UpdateMin panics.
+func (FunctionalGaugeFloat64) UpdateMin(float64) {
+	panic("UpdateMin called on a FunctionalGaugeFloat64")
+}
@@ -1,6 +1,9 @@
 package metrics
 
-import "testing"
+import (
+	"sync"
+	"testing"
+)
 
 func BenchmarkGuageFloat64(b *testing.B) {
 	g := NewGaugeFloat64()
@@ -28,6 +31,29 @@ func TestGaugeFloat64Snapshot(t *testing.T) {
 	}
 }
 
+func TestGaugeFloat64UpdateMaxMin(t *testing.T) {
+	g := NewGaugeFloat64().(*StandardGaugeFloat64)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				g.UpdateMax(float64(i*1000+j) / 2)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if v := g.Value(); 7999.5 != v {
+		t.Errorf("g.Value(): 7999.5 != %v\n", v)
+	}
+	g.UpdateMin(-0.5)
+	g.UpdateMin(3)
+	if v := g.Value(); -0.5 != v {
+		t.Errorf("g.Value(): -0.5 != %v\n", v)
+	}
+}
+
 func TestGetOrRegisterGaugeFloat64(t *testing.T) {
 	r := NewRegistry()
 	NewRegisteredGaugeFloat64("foo", r).Update(float64(47.0))
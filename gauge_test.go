@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 )
 
@@ -31,6 +32,44 @@ func TestGaugeSnapshot(t *testing.T) {
 	}
 }
 
+func TestGaugeUpdateMaxMin(t *testing.T) {
+	g := NewGauge().(*StandardGauge)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				g.UpdateMax(int64(i*1000 + j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if v := g.Value(); 15999 != v {
+		t.Errorf("g.Value(): 15999 != %v\n", v)
+	}
+	g.UpdateMin(-1)
+	g.UpdateMin(3)
+	if v := g.Value(); -1 != v {
+		t.Errorf("g.Value(): -1 != %v\n", v)
+	}
+}
+
+func TestGetGauge(t *testing.T) {
+	r := NewRegistry()
+	m := NewRegisteredGauge("foo", r)
+	NewRegisteredCounter("bar", r)
+	if got := GetGauge("foo", r); m != got {
+		t.Errorf("GetGauge(\"foo\", r): %v != %v\n", m, got)
+	}
+	if got := GetGauge("bar", r); nil != got {
+		t.Errorf("GetGauge(\"bar\", r): nil != %v\n", got)
+	}
+	if got := GetGauge("baz", r); nil != got {
+		t.Errorf("GetGauge(\"baz\", r): nil != %v\n", got)
+	}
+}
+
 func TestGetOrRegisterGauge(t *testing.T) {
 	r := NewRegistry()
 	NewRegisteredGauge("foo", r).Update(47)
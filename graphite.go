@@ -2,8 +2,8 @@ package metrics
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"log"
 	"net"
 	"strconv"
 	"strings"
@@ -18,7 +18,10 @@ type GraphiteConfig struct {
 	FlushInterval time.Duration // Flush interval
 	DurationUnit  time.Duration // Time conversion unit for durations
 	Prefix        string        // Prefix to be prepended to metric names
-	Percentiles   []float64     // Percentiles to export from timers and histograms
+	Percentiles   []float64     // Percentiles to export from timers and histograms, each one's DefaultPercentiles if nil
+	Logger        Logger        // Logger for errors, the standard library's if nil
+	AlignFlush    bool          // Whether to flush on multiples of FlushInterval, say on the minute, rather than every FlushInterval from starting
+	SkipUnchanged int           // Send a value unchanged since the last flush only every SkipUnchanged flushes, every flush if 0 or 1
 }
 
 // Graphite is a blocking exporter function which reports metrics in r
@@ -31,30 +34,51 @@ func Graphite(r Registry, d time.Duration, prefix string, addr *net.TCPAddr) {
 		FlushInterval: d,
 		DurationUnit:  time.Nanosecond,
 		Prefix:        prefix,
-		Percentiles:   []float64{0.5, 0.75, 0.95, 0.99, 0.999},
 	})
 }
 
 // GraphiteWithConfig is a blocking exporter function just like Graphite,
 // but it takes a GraphiteConfig instead.
 func GraphiteWithConfig(c GraphiteConfig) {
-	log.Printf("WARNING: This go-metrics client has been DEPRECATED! It has been moved to https://github.com/cyberdelia/go-metrics-graphite and will be removed from rcrowley/go-metrics on August 12th 2015")
-	for _ = range time.Tick(c.FlushInterval) {
-		if err := graphite(&c); nil != err {
-			log.Println(err)
+	GraphiteWithContext(context.Background(), c)
+}
+
+// GraphiteWithContext is a blocking exporter function just like
+// GraphiteWithConfig but it returns once ctx is done, after a final flush so
+// that the metrics of the last, partial interval aren't lost on shutdown.
+// If c.SkipUnchanged is more than one, each value, say of a counter's count,
+// which is the same as at the last flush is skipped unless it was last sent
+// SkipUnchanged flushes ago, to save sending identical datapoints while
+// keeping the series alive.  A flush which fails to be written isn't counted,
+// so its values are sent again by the next.
+func GraphiteWithContext(ctx context.Context, c GraphiteConfig) {
+	l := loggerOrDefault(c.Logger)
+	l.Printf("WARNING: This go-metrics client has been DEPRECATED! It has been moved to https://github.com/cyberdelia/go-metrics-graphite and will be removed from rcrowley/go-metrics on August 12th 2015")
+	d := newFlushDedup(c.SkipUnchanged)
+	ticker := NewFlushTicker(c.FlushInterval, c.AlignFlush)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			done = true
+		}
+		if err := graphite(&c, d); nil != err {
+			l.Printf("%v", err)
 		}
 	}
 }
 
 // GraphiteOnce performs a single submission to Graphite, returning a
 // non-nil error on failed connections. This can be used in a loop
-// similar to GraphiteWithConfig for custom error handling.
+// similar to GraphiteWithConfig for custom error handling.  Having no
+// previous flush, it sends every value regardless of SkipUnchanged.
 func GraphiteOnce(c GraphiteConfig) error {
-	log.Printf("WARNING: This go-metrics client has been DEPRECATED! It has been moved to https://github.com/cyberdelia/go-metrics-graphite and will be removed from rcrowley/go-metrics on August 12th 2015")
-	return graphite(&c)
+	loggerOrDefault(c.Logger).Printf("WARNING: This go-metrics client has been DEPRECATED! It has been moved to https://github.com/cyberdelia/go-metrics-graphite and will be removed from rcrowley/go-metrics on August 12th 2015")
+	return graphite(&c, nil)
 }
 
-func graphite(c *GraphiteConfig) error {
+func graphite(c *GraphiteConfig, d *flushDedup) error {
 	now := time.Now().Unix()
 	du := float64(c.DurationUnit)
 	conn, err := net.DialTCP("tcp", nil, c.Addr)
@@ -63,51 +87,73 @@ func graphite(c *GraphiteConfig) error {
 	}
 	defer conn.Close()
 	w := bufio.NewWriter(conn)
+	send := func(name, field, format string, value interface{}) {
+		v := fmt.Sprintf(format, value)
+		if d.send(name+"."+field, v) {
+			fmt.Fprintf(w, "%s.%s.%s %s %d\n", c.Prefix, name, field, v, now)
+		}
+	}
 	c.Registry.Each(func(name string, i interface{}) {
 		switch metric := i.(type) {
 		case Counter:
-			fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, metric.Count(), now)
+			send(name, "count", "%d", metric.Count())
+		case FloatCounter:
+			send(name, "count", "%f", metric.Count())
 		case Gauge:
-			fmt.Fprintf(w, "%s.%s.value %d %d\n", c.Prefix, name, metric.Value(), now)
+			send(name, "value", "%d", metric.Value())
 		case GaugeFloat64:
-			fmt.Fprintf(w, "%s.%s.value %f %d\n", c.Prefix, name, metric.Value(), now)
+			send(name, "value", "%f", metric.Value())
 		case Histogram:
 			h := metric.Snapshot()
-			ps := h.Percentiles(c.Percentiles)
-			fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, h.Count(), now)
-			fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, h.Min(), now)
-			fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, h.Max(), now)
-			fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, h.Mean(), now)
-			fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, h.StdDev(), now)
-			for psIdx, psKey := range c.Percentiles {
+			keys := c.Percentiles
+			if nil == keys {
+				keys = ReportedPercentiles(h)
+			}
+			ps := h.Percentiles(keys)
+			send(name, "count", "%d", h.Count())
+			send(name, "min", "%d", h.Min())
+			send(name, "max", "%d", h.Max())
+			send(name, "mean", "%.2f", h.Mean())
+			send(name, "std-dev", "%.2f", h.StdDev())
+			for psIdx, psKey := range keys {
 				key := strings.Replace(strconv.FormatFloat(psKey*100.0, 'f', -1, 64), ".", "", 1)
-				fmt.Fprintf(w, "%s.%s.%s-percentile %.2f %d\n", c.Prefix, name, key, ps[psIdx], now)
+				send(name, key+"-percentile", "%.2f", ps[psIdx])
 			}
 		case ThisMeter:
-			m := metric.Snapshot()
-			fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
-			fmt.Fprintf(w, "%s.%s.one-minute %.2f %d\n", c.Prefix, name, m.Rate1(), now)
-			fmt.Fprintf(w, "%s.%s.five-minute %.2f %d\n", c.Prefix, name, m.Rate5(), now)
-			fmt.Fprintf(w, "%s.%s.fifteen-minute %.2f %d\n", c.Prefix, name, m.Rate15(), now)
-			fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, m.RateMean(), now)
+			m, release := flushSnapshot(metric)
+			send(name, "count", "%d", m.Count())
+			send(name, "one-minute", "%.2f", m.Rate1())
+			send(name, "five-minute", "%.2f", m.Rate5())
+			send(name, "fifteen-minute", "%.2f", m.Rate15())
+			send(name, "mean", "%.2f", m.RateMean())
+			release()
 		case Timer:
 			t := metric.Snapshot()
-			ps := t.Percentiles(c.Percentiles)
-			fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, t.Count(), now)
-			fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, t.Min()/int64(du), now)
-			fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, t.Max()/int64(du), now)
-			fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, t.Mean()/du, now)
-			fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, t.StdDev()/du, now)
-			for psIdx, psKey := range c.Percentiles {
+			keys := c.Percentiles
+			if nil == keys {
+				keys = ReportedPercentiles(t)
+			}
+			ps := t.Percentiles(keys)
+			send(name, "count", "%d", t.Count())
+			send(name, "min", "%d", t.Min()/int64(du))
+			send(name, "max", "%d", t.Max()/int64(du))
+			send(name, "mean", "%.2f", t.Mean()/du)
+			send(name, "std-dev", "%.2f", t.StdDev()/du)
+			for psIdx, psKey := range keys {
 				key := strings.Replace(strconv.FormatFloat(psKey*100.0, 'f', -1, 64), ".", "", 1)
-				fmt.Fprintf(w, "%s.%s.%s-percentile %.2f %d\n", c.Prefix, name, key, ps[psIdx], now)
+				send(name, key+"-percentile", "%.2f", ps[psIdx]/du)
 			}
-			fmt.Fprintf(w, "%s.%s.one-minute %.2f %d\n", c.Prefix, name, t.Rate1(), now)
-			fmt.Fprintf(w, "%s.%s.five-minute %.2f %d\n", c.Prefix, name, t.Rate5(), now)
-			fmt.Fprintf(w, "%s.%s.fifteen-minute %.2f %d\n", c.Prefix, name, t.Rate15(), now)
-			fmt.Fprintf(w, "%s.%s.mean-rate %.2f %d\n", c.Prefix, name, t.RateMean(), now)
+			send(name, "one-minute", "%.2f", t.Rate1())
+			send(name, "five-minute", "%.2f", t.Rate5())
+			send(name, "fifteen-minute", "%.2f", t.Rate15())
+			send(name, "mean-rate", "%.2f", t.RateMean())
 		}
 		w.Flush()
 	})
+	if err := w.Flush(); nil != err {
+		d.discard()
+		return err
+	}
+	d.commit()
 	return nil
 }
@@ -1,7 +1,12 @@
 package metrics
 
 import (
+	"bufio"
 	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
 	"time"
 )
 
@@ -20,3 +25,131 @@ func ExampleGraphiteWithConfig() {
 		Percentiles:   []float64{0.5, 0.75, 0.99, 0.999},
 	})
 }
+
+func TestGraphiteOnceDurationUnit(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lines := make(chan []string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(lines)
+			return
+		}
+		defer conn.Close()
+		var ls []string
+		for s := bufio.NewScanner(conn); s.Scan(); {
+			ls = append(ls, s.Text())
+		}
+		lines <- ls
+	}()
+
+	r := NewRegistry()
+	tm := NewRegisteredTimer("foo", r)
+	defer tm.Stop()
+	tm.Update(250 * time.Millisecond)
+	if err := GraphiteOnce(GraphiteConfig{
+		Addr:         l.Addr().(*net.TCPAddr),
+		Registry:     r,
+		DurationUnit: time.Millisecond,
+		Prefix:       "prefix",
+		Percentiles:  []float64{0.5},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got := <-lines
+	for _, want := range []string{"prefix.foo.max 250 ", "prefix.foo.mean 250.00 ", "prefix.foo.50-percentile 250.00 "} {
+		found := false
+		for _, line := range got {
+			found = found || strings.HasPrefix(line, want)
+		}
+		if !found {
+			t.Errorf("missing %q in %v", want, got)
+		}
+	}
+}
+
+func TestGraphiteOnceFloatCounter(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lines := make(chan []string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(lines)
+			return
+		}
+		defer conn.Close()
+		var ls []string
+		for s := bufio.NewScanner(conn); s.Scan(); {
+			ls = append(ls, s.Text())
+		}
+		lines <- ls
+	}()
+
+	r := NewRegistry()
+	NewRegisteredFloatCounter("foo", r).Inc(2.5)
+	if err := GraphiteOnce(GraphiteConfig{
+		Addr:     l.Addr().(*net.TCPAddr),
+		Registry: r,
+		Prefix:   "prefix",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got := <-lines
+	if 1 != len(got) || !strings.HasPrefix(got[0], "prefix.foo.count 2.500000 ") {
+		t.Errorf("prefix.foo.count 2.500000: %v\n", got)
+	}
+}
+
+func TestGraphiteSkipUnchanged(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	flushes := make(chan []string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				close(flushes)
+				return
+			}
+			var ls []string
+			for s := bufio.NewScanner(conn); s.Scan(); {
+				ls = append(ls, strings.Fields(s.Text())[0])
+			}
+			conn.Close()
+			flushes <- ls
+		}
+	}()
+
+	r := NewRegistry()
+	unchanged, changed := NewRegisteredCounter("unchanged", r), NewRegisteredCounter("changed", r)
+	unchanged.Inc(47)
+	c := GraphiteConfig{Addr: l.Addr().(*net.TCPAddr), Registry: r, Prefix: "prefix"}
+	d := newFlushDedup(3)
+	for i, want := range [][]string{
+		{"prefix.changed.count", "prefix.unchanged.count"},
+		{"prefix.changed.count"},
+		{"prefix.changed.count"},
+		{"prefix.changed.count", "prefix.unchanged.count"},
+	} {
+		changed.Inc(1)
+		if err := graphite(&c, d); err != nil {
+			t.Fatal(err)
+		}
+		got := <-flushes
+		sort.Strings(got)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("flush %d: %v != %v\n", i+1, want, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Handler returns an http.Handler which serves the metrics in the given
+// registry as JSON, in the form returned by GetAll, as the text written by
+// WriteOnce if the request's Accept header asks for text/plain, or as the
+// OpenMetrics text written by WriteOpenMetrics if it asks for
+// application/openmetrics-text.  Each request is served from a single
+// Snapshot of the registry.
+func Handler(r Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		snapshot := SnapshotRegistry(r)
+		accept := req.Header.Get("Accept")
+		if accepts(accept, "application/openmetrics-text") {
+			w.Header().Set("Content-Type", OpenMetricsContentType)
+			writeOpenMetrics(w, r, snapshot)
+			return
+		}
+		if accepts(accept, "text/plain") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			writeSnapshot(w, snapshot)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(snapshotValues(snapshot))
+	})
+}
+
+// accepts reports whether an Accept header lists the given media type.
+func accepts(accept, mediaType string) bool {
+	for _, s := range strings.Split(accept, ",") {
+		if m, _, err := mime.ParseMediaType(s); nil == err && mediaType == m {
+			return true
+		}
+	}
+	return false
+}
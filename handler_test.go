@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerJSON(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	NewRegisteredGauge("bar", r).Update(48)
+	s := httptest.NewServer(Handler(r))
+	defer s.Close()
+	resp, err := http.Get(s.URL)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); "application/json; charset=utf-8" != contentType {
+		t.Errorf("Content-Type: application/json; charset=utf-8 != %v\n", contentType)
+	}
+	var data map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&data); nil != err {
+		t.Fatal(err)
+	}
+	if count := data["foo"]["count"]; 47 != count {
+		t.Errorf("foo count: 47 != %v\n", count)
+	}
+	if value := data["bar"]["value"]; 48 != value {
+		t.Errorf("bar value: 48 != %v\n", value)
+	}
+}
+
+func TestHandlerText(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	s := httptest.NewServer(Handler(r))
+	defer s.Close()
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/html;q=0.9, text/plain;q=0.8")
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); "text/plain; charset=utf-8" != contentType {
+		t.Errorf("Content-Type: text/plain; charset=utf-8 != %v\n", contentType)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "counter foo\n  count:              47\n"; !strings.Contains(string(body), want) {
+		t.Errorf("body: %q doesn't contain %q\n", body, want)
+	}
+}
+
+func TestHandlerOpenMetrics(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	s := httptest.NewServer(Handler(r))
+	defer s.Close()
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0, text/plain;q=0.5")
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); OpenMetricsContentType != contentType {
+		t.Errorf("Content-Type: %v != %v\n", OpenMetricsContentType, contentType)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "# TYPE foo counter\nfoo_total 47\n# EOF\n"; want != string(body) {
+		t.Errorf("body: %q != %q\n", want, body)
+	}
+}
@@ -0,0 +1,370 @@
+package metrics
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// NewHdrHistogram constructs a new StandardHistogram from an HdrSample, whose
+// percentiles are exact to sigfigs significant figures for values between min
+// and max rather than estimated from a reservoir.
+func NewHdrHistogram(min, max int64, sigfigs int) Histogram {
+	return NewHistogram(NewHdrSample(min, max, sigfigs))
+}
+
+// HdrSample counts every value in one of a fixed set of buckets, after the
+// fashion of Gil Tene's HdrHistogram, so its memory use is bounded by the
+// range of values and the precision rather than the number of values and
+// percentiles are read without sorting.  Each power of two up to max is
+// divided into enough linear buckets that a value's bucket is within one part
+// in 10^sigfigs of it, whatever min is.
+//
+// Values outside of min and max are clamped to them and counted by Clamped.
+// Count, Max, Min and Sum are exact, of the clamped values.  Mean is exact
+// while Percentile, StdDev and Variance are exact to the precision of the
+// buckets, percentiles never being outside of Min and Max.
+type HdrSample struct {
+	clamped        int64
+	count          int64
+	counts         []int64
+	halfMagnitude  uint
+	max, min       int64 // of the values recorded
+	highest        int64
+	lowest         int64
+	mutex          sync.Mutex
+	subBucketCount int64
+	subBucketMask  int64
+	sum            int64
+}
+
+// NewHdrSample constructs a new HdrSample for values between min and max,
+// exact to sigfigs significant figures.  min is at least one, max at least
+// twice min and sigfigs between one and five, any of them being raised or
+// lowered as needed.
+func NewHdrSample(min, max int64, sigfigs int) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	if min < 1 {
+		min = 1
+	}
+	if max < 2*min {
+		max = 2 * min
+	}
+	if sigfigs < 1 {
+		sigfigs = 1
+	} else if 5 < sigfigs {
+		sigfigs = 5
+	}
+	s := &HdrSample{highest: max, lowest: min}
+	largest := 2 * int64(math.Pow10(sigfigs)) // with single-unit resolution
+	countMagnitude := uint(bits.Len64(uint64(largest - 1)))
+	s.halfMagnitude = countMagnitude - 1
+	s.subBucketCount = 1 << countMagnitude
+	s.subBucketMask = s.subBucketCount - 1
+	buckets := 1
+	for untrackable := s.subBucketCount; untrackable <= max; untrackable <<= 1 {
+		buckets++
+		if untrackable > math.MaxInt64/2 {
+			break
+		}
+	}
+	s.counts = make([]int64, (buckets+1)<<s.halfMagnitude)
+	return s
+}
+
+// Clamped returns the number of values recorded which were outside of the
+// sample's min and max and so were clamped to them.
+func (s *HdrSample) Clamped() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.clamped
+}
+
+// Clear clears all samples.
+func (s *HdrSample) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clamped, s.count, s.max, s.min, s.sum = 0, 0, 0, 0, 0
+	for i := range s.counts {
+		s.counts[i] = 0
+	}
+}
+
+// Count returns the number of samples recorded.
+func (s *HdrSample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Max returns the maximum value recorded.
+func (s *HdrSample) Max() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.max
+}
+
+// Mean returns the mean of the values recorded.
+func (s *HdrSample) Mean() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count {
+		return 0.0
+	}
+	return float64(s.sum) / float64(s.count)
+}
+
+// Merge adds the values recorded by other to the sample without changing
+// other.  The buckets of another HdrSample for the same min, max and sigfigs
+// are added to the sample's, so the merge is exact, and the values of any
+// other sample are recorded with Update.
+func (s *HdrSample) Merge(other Sample) {
+	if snapshot, ok := other.(*HdrSampleSnapshot); ok {
+		other = snapshot.s
+	}
+	o, ok := other.(*HdrSample)
+	if !ok || o.lowest != s.lowest || o.highest != s.highest || o.subBucketCount != s.subBucketCount || len(o.counts) != len(s.counts) {
+		for _, v := range other.Values() {
+			s.Update(v)
+		}
+		return
+	}
+	o.mutex.Lock()
+	counts := make([]int64, len(o.counts))
+	copy(counts, o.counts)
+	clamped, count, max, min, sum := o.clamped, o.count, o.max, o.min, o.sum
+	o.mutex.Unlock()
+	if 0 == count {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count || min < s.min {
+		s.min = min
+	}
+	if 0 == s.count || max > s.max {
+		s.max = max
+	}
+	s.clamped += clamped
+	s.count += count
+	s.sum += sum
+	for i, n := range counts {
+		s.counts[i] += n
+	}
+}
+
+// Min returns the minimum value recorded.
+func (s *HdrSample) Min() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.min
+}
+
+// Percentile returns an arbitrary percentile of the values recorded.
+func (s *HdrSample) Percentile(p float64) float64 {
+	return s.Percentiles([]float64{p})[0]
+}
+
+// Percentiles returns a slice of arbitrary percentiles of the values
+// recorded, each the middle of the bucket holding the value of that rank
+// bounded by the minimum and maximum values recorded.
+func (s *HdrSample) Percentiles(ps []float64) []float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	scores := make([]float64, len(ps))
+	if 0 == s.count {
+		return scores
+	}
+	for i, p := range ps {
+		rank := int64(math.Ceil(p * float64(s.count)))
+		if rank < 1 {
+			rank = 1
+		}
+		var seen int64
+		for j, n := range s.counts {
+			if seen += n; seen >= rank {
+				scores[i] = math.Min(math.Max(s.middle(j), float64(s.min)), float64(s.max))
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// Size returns the number of values recorded, which Values returns.
+func (s *HdrSample) Size() int {
+	return int(s.Count())
+}
+
+// Snapshot returns a read-only copy of the sample.
+func (s *HdrSample) Snapshot() Sample {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := &HdrSample{
+		clamped:        s.clamped,
+		count:          s.count,
+		counts:         make([]int64, len(s.counts)),
+		halfMagnitude:  s.halfMagnitude,
+		max:            s.max,
+		min:            s.min,
+		highest:        s.highest,
+		lowest:         s.lowest,
+		subBucketCount: s.subBucketCount,
+		subBucketMask:  s.subBucketMask,
+		sum:            s.sum,
+	}
+	copy(c.counts, s.counts)
+	return &HdrSampleSnapshot{c}
+}
+
+// StdDev returns the standard deviation of the values recorded.
+func (s *HdrSample) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Sum returns the sum of the values recorded.
+func (s *HdrSample) Sum() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sum
+}
+
+// Update records a new value, clamped to the sample's min and max.
+func (s *HdrSample) Update(v int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if v < s.lowest {
+		v = s.lowest
+		s.clamped++
+	} else if v > s.highest {
+		v = s.highest
+		s.clamped++
+	}
+	if 0 == s.count || v < s.min {
+		s.min = v
+	}
+	if 0 == s.count || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+	s.counts[s.index(v)]++
+}
+
+// Values returns the middle of the bucket of each value recorded in
+// ascending order.  It allocates one int64 per value so is best kept to
+// debugging.
+func (s *HdrSample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	values := make([]int64, 0, s.count)
+	for i, n := range s.counts {
+		for ; n > 0; n-- {
+			values = append(values, int64(s.middle(i)))
+		}
+	}
+	return values
+}
+
+// Variance returns the variance of the values recorded.
+func (s *HdrSample) Variance() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count {
+		return 0.0
+	}
+	m := float64(s.sum) / float64(s.count)
+	var sum float64
+	for i, n := range s.counts {
+		if 0 != n {
+			d := s.middle(i) - m
+			sum += float64(n) * d * d
+		}
+	}
+	return sum / float64(s.count)
+}
+
+// index returns the index into counts of the bucket of v.
+func (s *HdrSample) index(v int64) int {
+	bucket := int(64-s.halfMagnitude-1) - bits.LeadingZeros64(uint64(v|s.subBucketMask))
+	subBucket := v >> uint(bucket)
+	return (bucket+1)<<s.halfMagnitude + int(subBucket-s.subBucketCount/2)
+}
+
+// middle returns the middle of the bucket at the given index into counts.
+func (s *HdrSample) middle(i int) float64 {
+	bucket := i>>s.halfMagnitude - 1
+	subBucket := int64(i)&(s.subBucketCount/2-1) + s.subBucketCount/2
+	if bucket < 0 {
+		subBucket -= s.subBucketCount / 2
+		bucket = 0
+	}
+	shift := uint(bucket)
+	lowest := subBucket << shift
+	return float64(lowest) + float64(int64(1)<<shift-1)/2
+}
+
+// HdrSampleSnapshot is a read-only copy of an HdrSample.
+type HdrSampleSnapshot struct {
+	s *HdrSample
+}
+
+// Clamped returns the number of values clamped at the time the snapshot was
+// taken.
+func (s *HdrSampleSnapshot) Clamped() int64 { return s.s.Clamped() }
+
+// Clear panics.
+func (*HdrSampleSnapshot) Clear() {
+	panic("Clear called on a HdrSampleSnapshot")
+}
+
+// Count returns the count of inputs at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Count() int64 { return s.s.Count() }
+
+// Max returns the maximal value at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Max() int64 { return s.s.Max() }
+
+// Mean returns the mean value at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Mean() float64 { return s.s.Mean() }
+
+// Min returns the minimal value at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Min() int64 { return s.s.Min() }
+
+// Percentile returns an arbitrary percentile of values at the time the
+// snapshot was taken.
+func (s *HdrSampleSnapshot) Percentile(p float64) float64 {
+	return s.s.Percentile(p)
+}
+
+// Percentiles returns a slice of arbitrary percentiles of values at the time
+// the snapshot was taken.
+func (s *HdrSampleSnapshot) Percentiles(ps []float64) []float64 {
+	return s.s.Percentiles(ps)
+}
+
+// Size returns the size of the sample at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Size() int { return s.s.Size() }
+
+// Snapshot returns the snapshot.
+func (s *HdrSampleSnapshot) Snapshot() Sample { return s }
+
+// StdDev returns the standard deviation of values at the time the snapshot was
+// taken.
+func (s *HdrSampleSnapshot) StdDev() float64 { return s.s.StdDev() }
+
+// Sum returns the sum of values at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Sum() int64 { return s.s.Sum() }
+
+// Update panics.
+func (*HdrSampleSnapshot) Update(int64) {
+	panic("Update called on a HdrSampleSnapshot")
+}
+
+// Values returns the middle of the bucket of each value at the time the
+// snapshot was taken in ascending order.
+func (s *HdrSampleSnapshot) Values() []int64 { return s.s.Values() }
+
+// Variance returns the variance of values at the time the snapshot was taken.
+func (s *HdrSampleSnapshot) Variance() float64 { return s.s.Variance() }
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func BenchmarkHdrHistogram(b *testing.B) {
+	h := NewHdrHistogram(1, 3600e9, 3)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Update(int64(i))
+	}
+}
+
+func TestHdrHistogramPercentiles(t *testing.T) {
+	h := NewHdrHistogram(1, 3600e9, 3)
+	for i := int64(1); i <= 100000; i++ {
+		h.Update(i * 1000)
+	}
+	if count := h.Count(); 100000 != count {
+		t.Errorf("h.Count(): 100000 != %v\n", count)
+	}
+	if min := h.Min(); 1000 != min {
+		t.Errorf("h.Min(): 1000 != %v\n", min)
+	}
+	if max := h.Max(); 100000000 != max {
+		t.Errorf("h.Max(): 100000000 != %v\n", max)
+	}
+	if mean := h.Mean(); 50000500 != mean {
+		t.Errorf("h.Mean(): 50000500 != %v\n", mean)
+	}
+	ps := []float64{0.01, 0.5, 0.75, 0.99, 0.999, 1}
+	want := []float64{1e6, 50e6, 75e6, 99e6, 99.9e6, 100e6}
+	for i, p := range h.Snapshot().Percentiles(ps) {
+		if 1e-3 < math.Abs(p-want[i])/want[i] {
+			t.Errorf("%v percentile: %v != %v\n", ps[i], want[i], p)
+		}
+	}
+	if stdDev := h.StdDev(); 1e-3 < math.Abs(stdDev-28867513)/28867513 {
+		t.Errorf("h.StdDev(): 28867513 != %v\n", stdDev)
+	}
+}
+
+func TestHdrHistogramMerge(t *testing.T) {
+	h := NewHdrHistogram(1, 3600e9, 3)
+	for i := int64(1); i <= 100000; i++ {
+		h.Update(i * 1000)
+	}
+	s1, s2 := NewHdrSample(1, 3600e9, 3), NewHdrSample(1, 3600e9, 3)
+	for i := int64(1); i <= 100000; i++ {
+		if 0 == i%3 {
+			s1.Update(i * 1000)
+		} else {
+			s2.Update(i * 1000)
+		}
+	}
+	s1.(MergeableSample).Merge(s2.Snapshot())
+	if count := s2.Count(); 66667 != count {
+		t.Errorf("s2.Count(): 66667 != %v\n", count)
+	}
+	if count, min, max := s1.Count(), s1.Min(), s1.Max(); 100000 != count || 1000 != min || 100000000 != max {
+		t.Errorf("s1: 100000 1000 100000000 != %v %v %v\n", count, min, max)
+	}
+	ps := []float64{0.01, 0.5, 0.75, 0.99, 0.999, 1}
+	want, got := h.Percentiles(ps), s1.Percentiles(ps)
+	for i := range ps {
+		if want[i] != got[i] {
+			t.Errorf("%v percentile: %v != %v\n", ps[i], want[i], got[i])
+		}
+	}
+}
+
+func TestHdrHistogramMergeOtherSample(t *testing.T) {
+	s, other := NewHdrSample(1, 1000, 3), NewHdrSample(1, 1e6, 3)
+	for i := int64(1); i <= 100; i++ {
+		other.Update(i)
+	}
+	s.(MergeableSample).Merge(other)
+	if count, sum := s.Count(), s.Sum(); 100 != count || 5050 != sum {
+		t.Errorf("s: 100 5050 != %v %v\n", count, sum)
+	}
+	if p := s.Percentile(0.5); 50 != p {
+		t.Errorf("s.Percentile(0.5): 50 != %v\n", p)
+	}
+}
+
+func TestHdrHistogramExactBelowPrecision(t *testing.T) {
+	h := NewHdrHistogram(1, 1000000, 3)
+	for i := int64(1); i <= 2000; i++ {
+		h.Update(i)
+	}
+	ps := h.Percentiles([]float64{0.0005, 0.5, 1})
+	if 1 != ps[0] || 1000 != ps[1] || 2000 != ps[2] {
+		t.Errorf("h.Percentiles(): [1 1000 2000] != %v\n", ps)
+	}
+}
+
+func TestHdrHistogramPrecisionAboveOne(t *testing.T) {
+	for _, test := range []struct {
+		min, max int64
+		sigfigs  int
+		v        int64
+	}{
+		{1e6, 1e9, 3, 1.5e6},
+		{1000, 1e9, 3, 5000},
+		{1000, 1e6, 2, 1000},
+		{1000, 1e6, 2, 123456},
+	} {
+		h := NewHdrHistogram(test.min, test.max, test.sigfigs)
+		h.Update(test.v)
+		for _, p := range h.Percentiles([]float64{0, 0.5, 1}) {
+			if p < float64(h.Min()) || float64(h.Max()) < p {
+				t.Errorf("%v: percentile %v outside of [%v, %v]\n", test, p, h.Min(), h.Max())
+			}
+			if math.Pow10(-test.sigfigs) < math.Abs(p-float64(test.v))/float64(test.v) {
+				t.Errorf("%v: percentile: %v != %v\n", test, test.v, p)
+			}
+		}
+	}
+	h := NewHdrHistogram(1000, 1e9, 3)
+	for i := int64(1); i <= 1000; i++ {
+		h.Update(1e6 + i*1000)
+	}
+	if p := h.Percentile(0.5); 1e-3 < math.Abs(p-1.5e6)/1.5e6 {
+		t.Errorf("h.Percentile(0.5): 1.5e6 != %v\n", p)
+	}
+}
+
+func TestHdrHistogramClamped(t *testing.T) {
+	h := NewHdrHistogram(10, 1000, 2)
+	h.Update(1)
+	h.Update(100)
+	h.Update(1000000)
+	s := h.Sample().(*HdrSample)
+	if clamped := s.Clamped(); 2 != clamped {
+		t.Errorf("s.Clamped(): 2 != %v\n", clamped)
+	}
+	if min := h.Min(); 10 != min {
+		t.Errorf("h.Min(): 10 != %v\n", min)
+	}
+	if max := h.Max(); 1000 != max {
+		t.Errorf("h.Max(): 1000 != %v\n", max)
+	}
+	if sum := h.Sum(); 1110 != sum {
+		t.Errorf("h.Sum(): 1110 != %v\n", sum)
+	}
+	if p := h.Percentile(1); 1e-2 < math.Abs(p-1000)/1000 {
+		t.Errorf("h.Percentile(1): 1000 != %v\n", p)
+	}
+	h.Clear()
+	if clamped := s.Clamped(); 0 != clamped {
+		t.Errorf("s.Clamped(): 0 != %v\n", clamped)
+	}
+	if count := h.Count(); 0 != count {
+		t.Errorf("h.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestHdrHistogramSnapshot(t *testing.T) {
+	h := NewHdrHistogram(1, 1000, 3)
+	h.Update(1)
+	h.Update(2)
+	s := h.Snapshot()
+	h.Update(3)
+	if count := s.Count(); 2 != count {
+		t.Errorf("s.Count(): 2 != %v\n", count)
+	}
+	if values := s.Sample().Values(); 2 != len(values) || 1 != values[0] || 2 != values[1] {
+		t.Errorf("s.Sample().Values(): [1 2] != %v\n", values)
+	}
+}
@@ -1,5 +1,7 @@
 package metrics
 
+import "sync"
+
 // Healthchecks hold an error value describing an arbitrary up/down status.
 type Healthcheck interface {
 	Check()
@@ -8,13 +10,56 @@ type Healthcheck interface {
 	Unhealthy(error)
 }
 
+// SnapshotableHealthchecks can take a read-only copy of their status.  It's
+// not part of Healthcheck so that implementations predating it still are
+// Healthchecks; SnapshotHealthcheck falls back to the healthcheck itself.
+type SnapshotableHealthcheck interface {
+	Healthcheck
+	Snapshot() Healthcheck
+}
+
+// SnapshotHealthcheck returns a read-only copy of h if it's a
+// SnapshotableHealthcheck and h itself otherwise.
+func SnapshotHealthcheck(h Healthcheck) Healthcheck {
+	if s, ok := h.(SnapshotableHealthcheck); ok {
+		return s.Snapshot()
+	}
+	return h
+}
+
 // NewHealthcheck constructs a new Healthcheck which will use the given
 // function to update its status.
 func NewHealthcheck(f func(Healthcheck)) Healthcheck {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilHealthchecks {
 		return NilHealthcheck{}
 	}
-	return &StandardHealthcheck{nil, f}
+	return &StandardHealthcheck{f: f}
+}
+
+// HealthcheckSnapshot is a read-only copy of another Healthcheck.
+type HealthcheckSnapshot struct {
+	err error
+}
+
+// Check panics.
+func (HealthcheckSnapshot) Check() {
+	panic("Check called on a HealthcheckSnapshot")
+}
+
+// Error returns the status at the time the snapshot was taken.
+func (h HealthcheckSnapshot) Error() error { return h.err }
+
+// Healthy panics.
+func (HealthcheckSnapshot) Healthy() {
+	panic("Healthy called on a HealthcheckSnapshot")
+}
+
+// Snapshot returns the snapshot.
+func (h HealthcheckSnapshot) Snapshot() Healthcheck { return h }
+
+// Unhealthy panics.
+func (HealthcheckSnapshot) Unhealthy(error) {
+	panic("Unhealthy called on a HealthcheckSnapshot")
 }
 
 // NilHealthcheck is a no-op.
@@ -29,14 +74,18 @@ func (NilHealthcheck) Error() error { return nil }
 // Healthy is a no-op.
 func (NilHealthcheck) Healthy() {}
 
+// Snapshot is a no-op.
+func (NilHealthcheck) Snapshot() Healthcheck { return NilHealthcheck{} }
+
 // Unhealthy is a no-op.
 func (NilHealthcheck) Unhealthy(error) {}
 
 // StandardHealthcheck is the standard implementation of a Healthcheck and
 // stores the status and a function to call to update the status.
 type StandardHealthcheck struct {
-	err error
-	f   func(Healthcheck)
+	mutex sync.Mutex
+	err   error
+	f     func(Healthcheck)
 }
 
 // Check runs the healthcheck function to update the healthcheck's status.
@@ -46,16 +95,27 @@ func (h *StandardHealthcheck) Check() {
 
 // Error returns the healthcheck's status, which will be nil if it is healthy.
 func (h *StandardHealthcheck) Error() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 	return h.err
 }
 
 // Healthy marks the healthcheck as healthy.
 func (h *StandardHealthcheck) Healthy() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 	h.err = nil
 }
 
+// Snapshot returns a read-only copy of the healthcheck's status.
+func (h *StandardHealthcheck) Snapshot() Healthcheck {
+	return HealthcheckSnapshot{h.Error()}
+}
+
 // Unhealthy marks the healthcheck as unhealthy.  The error is stored and
 // may be retrieved by the Error method.
 func (h *StandardHealthcheck) Unhealthy(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 	h.err = err
 }
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthcheck(t *testing.T) {
+	err := errors.New("down")
+	healthy := true
+	h := NewHealthcheck(func(h Healthcheck) {
+		if healthy {
+			h.Healthy()
+		} else {
+			h.Unhealthy(err)
+		}
+	})
+	h.Check()
+	if nil != h.Error() {
+		t.Errorf("h.Error(): nil != %v\n", h.Error())
+	}
+	healthy = false
+	h.Check()
+	if err != h.Error() {
+		t.Errorf("h.Error(): %v != %v\n", err, h.Error())
+	}
+}
+
+func TestHealthcheckSnapshot(t *testing.T) {
+	err := errors.New("down")
+	h := NewHealthcheck(func(Healthcheck) {})
+	h.Unhealthy(err)
+	snapshot := SnapshotHealthcheck(h)
+	h.Healthy()
+	if err != snapshot.Error() {
+		t.Errorf("snapshot.Error(): %v != %v\n", err, snapshot.Error())
+	}
+	defer func() {
+		if nil == recover() {
+			t.Error("snapshot.Check() didn't panic")
+		}
+	}()
+	snapshot.Check()
+}
+
+// legacyHealthcheck is a Healthcheck implemented outside of the package
+// without a Snapshot method.
+type legacyHealthcheck struct{ err error }
+
+func (h *legacyHealthcheck) Check()            { h.err = errors.New("down") }
+func (h *legacyHealthcheck) Error() error      { return h.err }
+func (h *legacyHealthcheck) Healthy()          { h.err = nil }
+func (h *legacyHealthcheck) Unhealthy(e error) { h.err = e }
+
+func TestRegistryLegacyHealthcheck(t *testing.T) {
+	r := NewRegistry()
+	h := &legacyHealthcheck{}
+	if err := r.Register("foo", h); nil != err {
+		t.Fatal(err)
+	}
+	if got := r.Get("foo"); h != got {
+		t.Fatalf("r.Get(\"foo\"): %v != %v\n", h, got)
+	}
+	r.RunHealthchecks()
+	if nil == h.Error() {
+		t.Error("h.Error(): nil")
+	}
+	if s := SnapshotHealthcheck(h); h != s {
+		t.Errorf("SnapshotHealthcheck(h): %v != %v\n", h, s)
+	}
+}
+
+func TestRegistryRunHealthchecks(t *testing.T) {
+	err := errors.New("down")
+	r := NewRegistry()
+	h := NewHealthcheck(func(h Healthcheck) { h.Unhealthy(err) })
+	r.Register("foo", h)
+	r.RunHealthchecks()
+	if err != h.Error() {
+		t.Errorf("h.Error(): %v != %v\n", err, h.Error())
+	}
+}
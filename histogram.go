@@ -1,6 +1,23 @@
 package metrics
 
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrSampleNotMergeable is returned by StandardHistogram.Merge when its sample
+// isn't a MergeableSample.
+var ErrSampleNotMergeable = errors.New("metrics: sample can't be merged")
+
+// DefaultPercentiles are the percentiles exporters report of histograms and
+// timers which weren't constructed with their own.
+var DefaultPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
 // Histograms calculate distribution statistics from a series of int64 values.
+// Durations, say latencies, are recorded in nanoseconds, as by the
+// UpdateDuration of a DurationHistogram, so their statistics are in
+// nanoseconds too.
 type Histogram interface {
 	Clear()
 	Count() int64
@@ -17,6 +34,42 @@ type Histogram interface {
 	Variance() float64
 }
 
+// DurationHistograms can record a time.Duration without the caller
+// converting it.  It's not part of Histogram so that implementations
+// predating it still are Histograms.
+type DurationHistogram interface {
+	Histogram
+	UpdateDuration(time.Duration)
+}
+
+// PercentileDefaulters are histograms and timers which choose the
+// percentiles exporters report of them.  It's not part of Histogram or Timer
+// so that implementations predating it still are Histograms and Timers;
+// ReportedPercentiles falls back to DefaultPercentiles.
+type PercentileDefaulter interface {
+	DefaultPercentiles() []float64
+}
+
+// ReportedPercentiles returns the percentiles exporters report of the given
+// histogram or timer: its own if it's a PercentileDefaulter and the package's
+// DefaultPercentiles otherwise.
+func ReportedPercentiles(m interface{}) []float64 {
+	if p, ok := m.(PercentileDefaulter); ok {
+		return p.DefaultPercentiles()
+	}
+	return DefaultPercentiles
+}
+
+// GetHistogram returns the Histogram registered under the given name or nil if there is
+// none or it is another kind of metric.
+func GetHistogram(name string, r Registry) Histogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(Histogram)
+	return m
+}
+
 // GetOrRegisterHistogram returns an existing Histogram or constructs and
 // registers a new StandardHistogram.
 func GetOrRegisterHistogram(name string, r Registry, s Sample) Histogram {
@@ -28,12 +81,21 @@ func GetOrRegisterHistogram(name string, r Registry, s Sample) Histogram {
 
 // NewHistogram constructs a new StandardHistogram from a Sample.
 func NewHistogram(s Sample) Histogram {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilHistograms {
 		return NilHistogram{}
 	}
 	return &StandardHistogram{sample: s}
 }
 
+// NewHistogramP constructs a new StandardHistogram from a Sample whose
+// DefaultPercentiles, which every exporter reports, are ps.
+func NewHistogramP(s Sample, ps []float64) Histogram {
+	if UseNilMetrics || UseNilHistograms {
+		return NilHistogram{}
+	}
+	return &StandardHistogram{percentiles: append([]float64(nil), ps...), sample: s}
+}
+
 // NewRegisteredHistogram constructs and registers a new StandardHistogram from
 // a Sample.
 func NewRegisteredHistogram(name string, r Registry, s Sample) Histogram {
@@ -47,7 +109,8 @@ func NewRegisteredHistogram(name string, r Registry, s Sample) Histogram {
 
 // HistogramSnapshot is a read-only copy of another Histogram.
 type HistogramSnapshot struct {
-	sample *SampleSnapshot
+	percentiles []float64
+	sample      Sample
 }
 
 // Clear panics.
@@ -59,6 +122,12 @@ func (*HistogramSnapshot) Clear() {
 // taken.
 func (h *HistogramSnapshot) Count() int64 { return h.sample.Count() }
 
+// DefaultPercentiles returns the percentiles exporters report of the
+// histogram.
+func (h *HistogramSnapshot) DefaultPercentiles() []float64 {
+	return percentilesOrDefault(h.percentiles)
+}
+
 // Max returns the maximum value in the sample at the time the snapshot was
 // taken.
 func (h *HistogramSnapshot) Max() int64 { return h.sample.Max() }
@@ -101,6 +170,11 @@ func (*HistogramSnapshot) Update(int64) {
 	panic("Update called on a HistogramSnapshot")
 }
 
+// UpdateDuration panics.
+func (*HistogramSnapshot) UpdateDuration(time.Duration) {
+	panic("UpdateDuration called on a HistogramSnapshot")
+}
+
 // Variance returns the variance of inputs at the time the snapshot was taken.
 func (h *HistogramSnapshot) Variance() float64 { return h.sample.Variance() }
 
@@ -113,6 +187,9 @@ func (NilHistogram) Clear() {}
 // Count is a no-op.
 func (NilHistogram) Count() int64 { return 0 }
 
+// DefaultPercentiles returns the package's DefaultPercentiles.
+func (NilHistogram) DefaultPercentiles() []float64 { return DefaultPercentiles }
+
 // Max is a no-op.
 func (NilHistogram) Max() int64 { return 0 }
 
@@ -145,13 +222,17 @@ func (NilHistogram) Sum() int64 { return 0 }
 // Update is a no-op.
 func (NilHistogram) Update(v int64) {}
 
+// UpdateDuration is a no-op.
+func (NilHistogram) UpdateDuration(d time.Duration) {}
+
 // Variance is a no-op.
 func (NilHistogram) Variance() float64 { return 0.0 }
 
 // StandardHistogram is the standard implementation of a Histogram and uses a
 // Sample to bound its memory use.
 type StandardHistogram struct {
-	sample Sample
+	percentiles []float64 // DefaultPercentiles if empty
+	sample      Sample
 }
 
 // Clear clears the histogram and its sample.
@@ -161,12 +242,32 @@ func (h *StandardHistogram) Clear() { h.sample.Clear() }
 // cleared.
 func (h *StandardHistogram) Count() int64 { return h.sample.Count() }
 
+// DefaultPercentiles returns the percentiles exporters report of the
+// histogram, those it was constructed with by NewHistogramP or else the
+// package's DefaultPercentiles.  The slice mustn't be modified.
+func (h *StandardHistogram) DefaultPercentiles() []float64 {
+	return percentilesOrDefault(h.percentiles)
+}
+
 // Max returns the maximum value in the sample.
 func (h *StandardHistogram) Max() int64 { return h.sample.Max() }
 
 // Mean returns the mean of the values in the sample.
 func (h *StandardHistogram) Mean() float64 { return h.sample.Mean() }
 
+// Merge adds the values recorded by other, say one of a set of
+// sub-histograms, to the histogram's sample without changing other.  It
+// returns ErrSampleNotMergeable if the histogram's sample isn't a
+// MergeableSample.
+func (h *StandardHistogram) Merge(other Histogram) error {
+	s, ok := h.sample.(MergeableSample)
+	if !ok {
+		return ErrSampleNotMergeable
+	}
+	s.Merge(other.Sample())
+	return nil
+}
+
 // Min returns the minimum value in the sample.
 func (h *StandardHistogram) Min() int64 { return h.sample.Min() }
 
@@ -186,7 +287,7 @@ func (h *StandardHistogram) Sample() Sample { return h.sample }
 
 // Snapshot returns a read-only copy of the histogram.
 func (h *StandardHistogram) Snapshot() Histogram {
-	return &HistogramSnapshot{sample: h.sample.Snapshot().(*SampleSnapshot)}
+	return &HistogramSnapshot{percentiles: h.percentiles, sample: h.sample.Snapshot()}
 }
 
 // StdDev returns the standard deviation of the values in the sample.
@@ -198,5 +299,26 @@ func (h *StandardHistogram) Sum() int64 { return h.sample.Sum() }
 // Update samples a new value.
 func (h *StandardHistogram) Update(v int64) { h.sample.Update(v) }
 
+// UpdateDuration samples a new duration in nanoseconds, as Update(int64(d))
+// does.
+func (h *StandardHistogram) UpdateDuration(d time.Duration) { h.Update(int64(d)) }
+
 // Variance returns the variance of the values in the sample.
 func (h *StandardHistogram) Variance() float64 { return h.sample.Variance() }
+
+// percentilesOrDefault returns ps or, if it's empty, DefaultPercentiles.
+func percentilesOrDefault(ps []float64) []float64 {
+	if 0 == len(ps) {
+		return DefaultPercentiles
+	}
+	return ps
+}
+
+// percentileLabel returns the label of a percentile in the JSON of a registry
+// and in the text written by Log, Syslog and Write, say "median" or "99.9%".
+func percentileLabel(p float64) string {
+	if 0.5 == p {
+		return "median"
+	}
+	return strconv.FormatFloat(p*100, 'f', -1, 64) + "%"
+}
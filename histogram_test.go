@@ -1,6 +1,10 @@
 package metrics
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+)
 
 func BenchmarkHistogram(b *testing.B) {
 	h := NewHistogram(NewUniformSample(100))
@@ -10,6 +14,21 @@ func BenchmarkHistogram(b *testing.B) {
 	}
 }
 
+func TestGetHistogram(t *testing.T) {
+	r := NewRegistry()
+	m := NewRegisteredHistogram("foo", r, NewUniformSample(100))
+	NewRegisteredCounter("bar", r)
+	if got := GetHistogram("foo", r); m != got {
+		t.Errorf("GetHistogram(\"foo\", r): %v != %v\n", m, got)
+	}
+	if got := GetHistogram("bar", r); nil != got {
+		t.Errorf("GetHistogram(\"bar\", r): nil != %v\n", got)
+	}
+	if got := GetHistogram("baz", r); nil != got {
+		t.Errorf("GetHistogram(\"baz\", r): nil != %v\n", got)
+	}
+}
+
 func TestGetOrRegisterHistogram(t *testing.T) {
 	r := NewRegistry()
 	s := NewUniformSample(100)
@@ -27,6 +46,66 @@ func TestHistogram10000(t *testing.T) {
 	testHistogram10000(t, h)
 }
 
+func TestHistogramClear(t *testing.T) {
+	for _, s := range []Sample{NewUniformSample(100), NewExpDecaySample(100, 0.015)} {
+		h := NewHistogram(s)
+		for i := 1; i <= 100; i++ {
+			h.Update(int64(i))
+		}
+		h.Clear()
+		if count := h.Count(); 0 != count {
+			t.Errorf("h.Count(): 0 != %v\n", count)
+		}
+		for i := 1001; i <= 1010; i++ {
+			h.Update(int64(i))
+		}
+		if count := h.Count(); 10 != count {
+			t.Errorf("h.Count(): 10 != %v\n", count)
+		}
+		if min := h.Min(); 1001 != min {
+			t.Errorf("h.Min(): 1001 != %v\n", min)
+		}
+		if p := h.Percentile(0.5); 1005.5 != p {
+			t.Errorf("median: 1005.5 != %v\n", p)
+		}
+	}
+}
+
+func TestHistogramCountSumAfterEviction(t *testing.T) {
+	for _, s := range []Sample{NewUniformSample(10), NewExpDecaySample(10, 0.015), NewEWMASample(10, 0.1)} {
+		h := NewHistogram(s)
+		for i := 1; i <= 1000; i++ {
+			h.Update(int64(i))
+		}
+		if size := s.Size(); 10 != size {
+			t.Errorf("s.Size(): 10 != %v\n", size)
+		}
+		if count := h.Count(); 1000 != count {
+			t.Errorf("h.Count(): 1000 != %v\n", count)
+		}
+		if sum := h.Sum(); 500500 != sum {
+			t.Errorf("h.Sum(): 500500 != %v\n", sum)
+		}
+		if sum := h.Snapshot().Sum(); 500500 != sum {
+			t.Errorf("h.Snapshot().Sum(): 500500 != %v\n", sum)
+		}
+		h.Clear()
+		if sum := h.Sum(); 0 != sum {
+			t.Errorf("h.Sum(): 0 != %v\n", sum)
+		}
+	}
+}
+
+func TestHistogramSnapshotClearPanics(t *testing.T) {
+	snapshot := NewHistogram(NewUniformSample(100)).Snapshot()
+	defer func() {
+		if nil == recover() {
+			t.Error("snapshot.Clear() didn't panic")
+		}
+	}()
+	snapshot.Clear()
+}
+
 func TestHistogramEmpty(t *testing.T) {
 	h := NewHistogram(NewUniformSample(100))
 	if count := h.Count(); 0 != count {
@@ -56,6 +135,63 @@ func TestHistogramEmpty(t *testing.T) {
 	}
 }
 
+func TestHistogramMerge(t *testing.T) {
+	h := NewHistogram(NewUniformSample(100000)).(*StandardHistogram)
+	for shard := 0; shard < 4; shard++ {
+		sub := NewHistogram(NewUniformSample(100000))
+		for i := 1 + 2500*shard; i <= 2500*(shard+1); i++ {
+			sub.Update(int64(i))
+		}
+		if err := h.Merge(sub); nil != err {
+			t.Fatal(err)
+		}
+	}
+	testHistogram10000(t, h)
+}
+
+func TestHistogramMergeNotMergeable(t *testing.T) {
+	h := NewHistogram(NewEWMASample(100, 0.5)).(*StandardHistogram)
+	if err := h.Merge(NewHistogram(NewUniformSample(100))); ErrSampleNotMergeable != err {
+		t.Errorf("h.Merge(): %v != %v\n", ErrSampleNotMergeable, err)
+	}
+}
+
+func TestHistogramUpdateDuration(t *testing.T) {
+	h := NewHistogram(NewUniformSample(100)).(*StandardHistogram)
+	h.UpdateDuration(250 * time.Millisecond)
+	h.UpdateDuration(time.Millisecond)
+	if max := h.Max(); 250000000 != max {
+		t.Errorf("h.Max(): 250000000 != %v\n", max)
+	}
+	if min := h.Min(); 1000000 != min {
+		t.Errorf("h.Min(): 1000000 != %v\n", min)
+	}
+}
+
+func TestHistogramDefaultPercentiles(t *testing.T) {
+	if ps := ReportedPercentiles(NewHistogram(NewUniformSample(100))); !reflect.DeepEqual(DefaultPercentiles, ps) {
+		t.Errorf("ReportedPercentiles(): %v != %v\n", DefaultPercentiles, ps)
+	}
+	want := []float64{0.5, 0.9}
+	h := NewHistogramP(NewUniformSample(100), want)
+	want[1] = 0.99
+	if ps := ReportedPercentiles(h.Snapshot()); !reflect.DeepEqual([]float64{0.5, 0.9}, ps) {
+		t.Errorf("ReportedPercentiles(h.Snapshot()): [0.5 0.9] != %v\n", ps)
+	}
+	tm := NewCustomTimer(h, NewThisMeter())
+	defer tm.Stop()
+	if ps := ReportedPercentiles(tm.Snapshot()); !reflect.DeepEqual([]float64{0.5, 0.9}, ps) {
+		t.Errorf("ReportedPercentiles(tm.Snapshot()): [0.5 0.9] != %v\n", ps)
+	}
+}
+
+func TestReportedPercentilesPlainHistogram(t *testing.T) {
+	h := struct{ Histogram }{NewHistogramP(NewUniformSample(100), []float64{0.5, 0.9})}
+	if ps := ReportedPercentiles(h); !reflect.DeepEqual(DefaultPercentiles, ps) {
+		t.Errorf("ReportedPercentiles(): %v != %v\n", DefaultPercentiles, ps)
+	}
+}
+
 func TestHistogramSnapshot(t *testing.T) {
 	h := NewHistogram(NewUniformSample(100000))
 	for i := 1; i <= 10000; i++ {
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// HTTPPushConfig provides a container with configuration parameters for
+// the HTTP push exporter
+type HTTPPushConfig struct {
+	URL           string            // URL to POST to
+	Registry      Registry          // Registry to be exported
+	FlushInterval time.Duration     // Flush interval
+	Headers       map[string]string // Headers to set on each request, say for an auth token
+	Timeout       time.Duration     // Timeout of each request, the flush interval if zero
+	Logger        Logger            // Logger for errors, the standard library's if nil
+	AlignFlush    bool              // Whether to flush on multiples of FlushInterval, say on the minute, rather than every FlushInterval from starting
+}
+
+// HTTPPush is a blocking exporter function which POSTs the metrics in r, in
+// the same JSON as the registry's MarshalJSON, to url every d duration with
+// the given headers.  A failed request or a response other than 2xx is logged
+// and the metrics are sent again at the next interval.
+func HTTPPush(r Registry, d time.Duration, url string, headers map[string]string) {
+	HTTPPushWithConfig(HTTPPushConfig{
+		URL:           url,
+		Registry:      r,
+		FlushInterval: d,
+		Headers:       headers,
+	})
+}
+
+// HTTPPushWithConfig is a blocking exporter function just like HTTPPush,
+// but it takes an HTTPPushConfig instead.
+func HTTPPushWithConfig(c HTTPPushConfig) {
+	HTTPPushWithContext(context.Background(), c)
+}
+
+// HTTPPushWithContext is a blocking exporter function just like
+// HTTPPushWithConfig but it returns once ctx is done, after a final flush so
+// that the metrics of the last, partial interval aren't lost on shutdown.
+// Every request is made with the same http.Client.
+func HTTPPushWithContext(ctx context.Context, c HTTPPushConfig) {
+	l := loggerOrDefault(c.Logger)
+	client := newHTTPPushClient(&c)
+	ticker := NewFlushTicker(c.FlushInterval, c.AlignFlush)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			done = true
+		}
+		if err := httpPush(client, &c); nil != err {
+			l.Printf("%v", err)
+		}
+	}
+}
+
+// HTTPPushOnce performs a single POST, returning a non-nil error if the
+// request fails or the response isn't 2xx.  This can be used in a loop
+// similar to HTTPPushWithConfig for custom error handling.
+func HTTPPushOnce(c HTTPPushConfig) error {
+	return httpPush(newHTTPPushClient(&c), &c)
+}
+
+func newHTTPPushClient(c *HTTPPushConfig) *http.Client {
+	timeout := c.Timeout
+	if 0 == timeout {
+		timeout = c.FlushInterval
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+func httpPush(client *http.Client, c *HTTPPushConfig) error {
+	body, err := json.Marshal(c.Registry.GetAll())
+	if nil != err {
+		return err
+	}
+	req, err := http.NewRequest("POST", c.URL, bytes.NewReader(body))
+	if nil != err {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body) // so the connection can be reused
+	if resp.StatusCode < 200 || 299 < resp.StatusCode {
+		return fmt.Errorf("metrics: POST %s: %s", c.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPPushOnce(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	NewRegisteredGauge("bar", r).Update(3)
+	var (
+		body          map[string]map[string]interface{}
+		auth, ctype   string
+		method, calls = "", 0
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		method, auth, ctype = req.Method, req.Header.Get("Authorization"), req.Header.Get("Content-Type")
+		if err := json.NewDecoder(req.Body).Decode(&body); nil != err {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+	err := HTTPPushOnce(HTTPPushConfig{
+		URL:           s.URL,
+		Registry:      r,
+		FlushInterval: time.Second,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 1 != calls || "POST" != method {
+		t.Errorf("%v %v calls, want 1 POST\n", calls, method)
+	}
+	if "Bearer token" != auth {
+		t.Errorf("Authorization: Bearer token != %v\n", auth)
+	}
+	if "application/json" != ctype {
+		t.Errorf("Content-Type: application/json != %v\n", ctype)
+	}
+	if count := body["foo"]["count"]; 47.0 != count {
+		t.Errorf("foo.count: 47 != %v\n", count)
+	}
+	if value := body["bar"]["value"]; 3.0 != value {
+		t.Errorf("bar.value: 3 != %v\n", value)
+	}
+}
+
+func TestHTTPPushOnceNon2xx(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+	if err := HTTPPushOnce(HTTPPushConfig{URL: s.URL, Registry: NewRegistry(), FlushInterval: time.Second}); nil == err {
+		t.Error("err is nil")
+	}
+}
+
+func TestHTTPPushWithContextRetries(t *testing.T) {
+	calls := make(chan int, 10)
+	n := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n++
+		if 1 == n {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		select {
+		case calls <- n:
+		default:
+		}
+	}))
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	l := make(chanLogger, 1)
+	done := make(chan struct{})
+	go func() {
+		HTTPPushWithContext(ctx, HTTPPushConfig{
+			URL:           s.URL,
+			Registry:      NewRegistry(),
+			FlushInterval: 10 * time.Millisecond,
+			Logger:        l,
+		})
+		close(done)
+	}()
+	<-calls
+	if line := <-l; "" == line {
+		t.Error("line: want != \"\"\n")
+	}
+	if n := <-calls; 2 != n {
+		t.Errorf("calls: 2 != %v\n", n)
+	}
+	cancel()
+	<-done
+}
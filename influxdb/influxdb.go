@@ -0,0 +1,307 @@
+// Metrics output to InfluxDB using the line protocol.
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// maxPending bounds the bytes of points kept for retry after failed writes so
+// that a long outage doesn't grow memory without limit.
+const maxPending = 1 << 20
+
+// Config provides a container with configuration parameters for the
+// InfluxDB exporter
+type Config struct {
+	URL           string            // Base URL of the InfluxDB server
+	Database      string            // Database to write to
+	Username      string            // Username for basic authentication
+	Password      string            // Password for basic authentication
+	Registry      metrics.Registry  // Registry to be exported
+	FlushInterval time.Duration     // Flush interval
+	Tags          map[string]string // Static tags added to every point
+	DurationUnit  time.Duration     // Time conversion unit for timers, nanoseconds if zero
+	Percentiles   []float64         // Percentiles to export from timers and histograms, each one's DefaultPercentiles if nil
+	Logger        metrics.Logger    // Logger for errors, the standard library's if nil
+	AlignFlush    bool              // Whether to flush on multiples of FlushInterval, say on the minute, rather than every FlushInterval from starting
+}
+
+// InfluxDB is a blocking exporter function which reports metrics in r to the
+// InfluxDB server at url, writing them to database every d duration.  Names
+// encoded by metrics.EncodeTaggedName are written as their base name with
+// their tags.
+func InfluxDB(r metrics.Registry, d time.Duration, url, database, username, password string) {
+	InfluxDBWithConfig(Config{
+		URL:           url,
+		Database:      database,
+		Username:      username,
+		Password:      password,
+		Registry:      r,
+		FlushInterval: d,
+		DurationUnit:  time.Nanosecond,
+	})
+}
+
+// InfluxDBWithConfig is a blocking exporter function just like InfluxDB,
+// but it takes a Config instead.  Points which fail to be written are logged
+// and, if the server couldn't be reached or failed with a 5xx, retried along
+// with the next flush.  Points it rejects, with a 4xx, are dropped.
+func InfluxDBWithConfig(c Config) {
+	InfluxDBWithContext(context.Background(), c)
+}
+
+// InfluxDBWithContext is a blocking exporter function just like
+// InfluxDBWithConfig but it returns once ctx is done, after a final write so
+// that the metrics of the last, partial interval aren't lost on shutdown.
+func InfluxDBWithContext(ctx context.Context, c Config) {
+	rep := newReporter(c)
+	var l metrics.Logger = stdLogger{}
+	if nil != c.Logger {
+		l = c.Logger
+	}
+	ticker := metrics.NewFlushTicker(c.FlushInterval, c.AlignFlush)
+	defer ticker.Stop()
+	for done := false; !done; {
+		var now time.Time
+		select {
+		case now = <-ticker.C:
+		case <-ctx.Done():
+			now, done = time.Now(), true
+		}
+		if err := rep.send(now); nil != err {
+			l.Printf("%v", err)
+		}
+	}
+}
+
+// stdLogger writes to the standard library's logger.
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// InfluxDBOnce performs a single write to InfluxDB, returning a non-nil error
+// on failed requests or non-2xx responses.
+func InfluxDBOnce(c Config) error {
+	return newReporter(c).send(time.Now())
+}
+
+type reporter struct {
+	Config
+	client  *http.Client
+	pending []byte
+}
+
+func newReporter(c Config) *reporter {
+	return &reporter{
+		Config: c,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (r *reporter) send(now time.Time) error {
+	var buf bytes.Buffer
+	buf.Write(r.pending)
+	r.pending = nil
+	r.writePoints(&buf, now)
+	if 0 == buf.Len() {
+		return nil
+	}
+	if err := r.post(buf.Bytes()); nil != err {
+		if se, ok := err.(*statusError); (!ok || 500 <= se.code) && buf.Len() <= maxPending {
+			r.pending = buf.Bytes()
+		}
+		return err
+	}
+	return nil
+}
+
+// statusError is the error of a write which InfluxDB responded to with a
+// status other than 2xx.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (err *statusError) Error() string {
+	return err.msg
+}
+
+func (r *reporter) post(body []byte) error {
+	q := url.Values{"db": {r.Database}, "precision": {"ns"}}
+	req, err := http.NewRequest("POST", strings.TrimRight(r.URL, "/")+"/write?"+q.Encode(), bytes.NewReader(body))
+	if nil != err {
+		return err
+	}
+	if "" != r.Username {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	resp, err := r.client.Do(req)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &statusError{resp.StatusCode, fmt.Sprintf("influxdb: %s: %s", resp.Status, bytes.TrimSpace(b))}
+	}
+	return nil
+}
+
+func (r *reporter) writePoints(w io.Writer, now time.Time) {
+	ts := now.UnixNano()
+	for name, i := range metrics.SnapshotRegistry(r.Registry) {
+		var fields []string
+		switch metric := i.(type) {
+		case metrics.Counter:
+			fields = []string{intField("count", metric.Count())}
+		case metrics.FloatCounter:
+			fields = []string{floatField("count", metric.Count())}
+		case metrics.Gauge:
+			fields = []string{intField("value", metric.Value())}
+		case metrics.GaugeFloat64:
+			fields = []string{floatField("value", metric.Value())}
+		case metrics.Histogram:
+			keys := r.percentiles(metric)
+			ps := metric.Percentiles(keys)
+			fields = []string{
+				intField("count", metric.Count()),
+				intField("min", metric.Min()),
+				intField("max", metric.Max()),
+				floatField("mean", metric.Mean()),
+				floatField("stddev", metric.StdDev()),
+			}
+			fields = append(fields, percentileFields(keys, ps)...)
+		case metrics.ThisMeter:
+			fields = []string{
+				intField("count", metric.Count()),
+				floatField("m1", metric.Rate1()),
+				floatField("m5", metric.Rate5()),
+				floatField("m15", metric.Rate15()),
+				floatField("meanrate", metric.RateMean()),
+			}
+		case metrics.Timer:
+			// NilTimers snapshot to themselves and have nothing to report.
+			t, ok := metric.Snapshot().(*metrics.TimerSnapshot)
+			if !ok {
+				continue
+			}
+			du := r.durationUnit()
+			fields = []string{
+				intField("count", t.Count()),
+				intField("min", int64(t.MinFor(du))),
+				intField("max", int64(t.MaxFor(du))),
+				floatField("mean", t.MeanFor(du)),
+				floatField("stddev", t.StdDevFor(du)),
+			}
+			keys := r.percentiles(t)
+			fields = append(fields, percentileFields(keys, t.PercentilesFor(keys, du))...)
+			fields = append(fields,
+				floatField("m1", t.Rate1()),
+				floatField("m5", t.Rate5()),
+				floatField("m15", t.Rate15()),
+				floatField("meanrate", t.RateMean()),
+			)
+		default:
+			continue
+		}
+		if fields = finiteFields(fields); 0 == len(fields) {
+			continue
+		}
+		measurement, tags := metrics.DecodeTaggedName(name)
+		fmt.Fprintf(w, "%s%s %s %d\n", measurementEscaper.Replace(measurement), r.tags(tags), strings.Join(fields, ","), ts)
+	}
+}
+
+// durationUnit returns the unit timers are reported in, nanoseconds unless
+// configured otherwise.
+func (r *reporter) durationUnit() time.Duration {
+	if 0 == r.DurationUnit {
+		return time.Nanosecond
+	}
+	return r.DurationUnit
+}
+
+// percentiles returns the percentiles reported of a histogram or timer, its
+// metrics.ReportedPercentiles unless configured otherwise.
+func (r *reporter) percentiles(m interface{}) []float64 {
+	if nil == r.Percentiles {
+		return metrics.ReportedPercentiles(m)
+	}
+	return r.Percentiles
+}
+
+// tags returns the static tags and a metric's own tags, which take
+// precedence, formatted for the line protocol and sorted by key as InfluxDB
+// recommends.
+func (r *reporter) tags(metricTags map[string]string) string {
+	tags := make(map[string]string, len(r.Tags)+len(metricTags))
+	for k, v := range r.Tags {
+		tags[k] = v
+	}
+	for k, v := range metricTags {
+		tags[k] = v
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, ",%s=%s", tagEscaper.Replace(k), tagEscaper.Replace(tags[k]))
+	}
+	return buf.String()
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func intField(key string, v int64) string {
+	return fmt.Sprintf("%s=%di", key, v)
+}
+
+// floatField formats a float field, or returns the empty string if v is NaN
+// or infinite since the line protocol can't represent them.
+func floatField(key string, v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ""
+	}
+	return fmt.Sprintf("%s=%g", key, v)
+}
+
+// finiteFields returns fields without the empty strings floatField returns
+// for NaN and infinite values.
+func finiteFields(fields []string) []string {
+	finite := fields[:0]
+	for _, field := range fields {
+		if "" != field {
+			finite = append(finite, field)
+		}
+	}
+	return finite
+}
+
+func percentileFields(keys, ps []float64) []string {
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		key := "p" + strings.Replace(fmt.Sprintf("%g", k*100), ".", "", 1)
+		fields[i] = floatField(key, ps[i])
+	}
+	return fields
+}
@@ -0,0 +1,182 @@
+package influxdb
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestReporterRetriesFailedWrites(t *testing.T) {
+	var bodies []string
+	status := http.StatusInternalServerError
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if "/write" != req.URL.Path || "foo" != req.URL.Query().Get("db") {
+			t.Errorf("unexpected request: %v", req.URL)
+		}
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("bar baz", r).Inc(47)
+	rep := newReporter(Config{
+		URL:      ts.URL,
+		Database: "foo",
+		Registry: r,
+		Tags:     map[string]string{"host": "a,b"},
+	})
+	if err := rep.send(time.Unix(0, 1)); nil == err {
+		t.Fatal("expected an error on a 500 response")
+	}
+	status = http.StatusNoContent
+	if err := rep.send(time.Unix(0, 2)); nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(bodies) {
+		t.Fatal(bodies)
+	}
+	want := `bar\ baz,host=a\,b count=47i 1` + "\n" + `bar\ baz,host=a\,b count=47i 2` + "\n"
+	if want != bodies[1] {
+		t.Errorf("%q != %q", want, bodies[1])
+	}
+	if nil != rep.pending {
+		t.Error("pending points weren't released after a successful write")
+	}
+}
+
+func TestReporterDropsRejectedWrites(t *testing.T) {
+	var bodies []string
+	status := http.StatusBadRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo", r).Inc(47)
+	rep := newReporter(Config{URL: ts.URL, Database: "foo", Registry: r})
+	if err := rep.send(time.Unix(0, 1)); nil == err {
+		t.Fatal("expected an error on a 400 response")
+	}
+	if nil != rep.pending {
+		t.Error("rejected points were kept for retry")
+	}
+	status = http.StatusNoContent
+	if err := rep.send(time.Unix(0, 2)); nil != err {
+		t.Fatal(err)
+	}
+	if want := "foo count=47i 2\n"; 2 != len(bodies) || want != bodies[1] {
+		t.Errorf("%q != %q", want, bodies)
+	}
+}
+
+func TestInfluxDBWithContextWritesOnCancel(t *testing.T) {
+	bodies := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies <- string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo", r).Inc(47)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	InfluxDBWithContext(ctx, Config{
+		URL:           ts.URL,
+		Database:      "foo",
+		Registry:      r,
+		FlushInterval: time.Hour,
+	})
+	select {
+	case body := <-bodies:
+		if !strings.HasPrefix(body, "foo count=47i ") {
+			t.Errorf("foo count=47i ... != %q", body)
+		}
+	default:
+		t.Fatal("no final write after the context was cancelled")
+	}
+}
+
+func TestPercentileFields(t *testing.T) {
+	fields := percentileFields([]float64{0.5, 0.999}, []float64{1, 2.5})
+	if s := strings.Join(fields, ","); "p50=1,p999=2.5" != s {
+		t.Error(s)
+	}
+}
+
+func TestWritePointsDurationUnit(t *testing.T) {
+	r := metrics.NewRegistry()
+	tm := metrics.NewRegisteredTimer("foo", r)
+	defer tm.Stop()
+	tm.Update(time.Millisecond)
+	tm.Update(3 * time.Millisecond)
+	rep := newReporter(Config{
+		Registry:     r,
+		DurationUnit: time.Millisecond,
+		Percentiles:  []float64{0.5},
+	})
+	var buf strings.Builder
+	rep.writePoints(&buf, time.Unix(0, 1))
+	for _, field := range []string{"min=1i", "max=3i", "mean=2", "stddev=1", "p50=2"} {
+		if !strings.Contains(buf.String(), ","+field+",") {
+			t.Errorf("%q doesn't contain %q", buf.String(), field)
+		}
+	}
+}
+
+func TestWritePointsNonFinite(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredGaugeFloat64("nan", r).Update(math.NaN())
+	metrics.NewRegisteredGaugeFloat64("inf", r).Update(math.Inf(1))
+	metrics.NewRegisteredGaugeFloat64("foo", r).Update(2.5)
+	rep := newReporter(Config{Registry: r})
+	var buf strings.Builder
+	rep.writePoints(&buf, time.Unix(0, 1))
+	if "foo value=2.5 1\n" != buf.String() {
+		t.Errorf("%q != %q", "foo value=2.5 1\n", buf.String())
+	}
+}
+
+func TestWritePointsFloatCounter(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredFloatCounter("foo", r).Inc(2.5)
+	rep := newReporter(Config{Registry: r})
+	var buf strings.Builder
+	rep.writePoints(&buf, time.Unix(0, 1))
+	if "foo count=2.5 1\n" != buf.String() {
+		t.Errorf("%q != %q", "foo count=2.5 1\n", buf.String())
+	}
+}
+
+func TestWritePointsTagged(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTagged("requests", map[string]string{"status": "200", "host": "b"}, metrics.NewCounter, r).(metrics.Counter).Inc(47)
+	metrics.GetOrRegisterTagged("requests", map[string]string{"status": "500"}, metrics.NewCounter, r).(metrics.Counter).Inc(1)
+	rep := newReporter(Config{
+		Registry: r,
+		Tags:     map[string]string{"host": "a"},
+	})
+	var buf strings.Builder
+	rep.writePoints(&buf, time.Unix(0, 1))
+	for _, line := range []string{
+		"requests,host=b,status=200 count=47i 1\n",
+		"requests,host=a,status=500 count=1i 1\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("%q doesn't contain %q", buf.String(), line)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+// Every implementation of each kind of metric, sample and registry is
+// asserted to satisfy its interface here, so that a method added to an
+// interface but not to, say, its Nil implementation breaks the build rather
+// than a type assertion at runtime.
+var (
+	_ Counter = CounterSnapshot(0)
+	_ Counter = NilCounter{}
+	_ Counter = &StandardCounter{}
+	_ Counter = &PooledCounterSnapshot{}
+	_ Counter = &ShardedCounter{}
+
+	_ EWMA = EWMASnapshot(0)
+	_ EWMA = NilEWMA{}
+	_ EWMA = &StandardEWMA{}
+
+	_ FloatCounter = FloatCounterSnapshot(0)
+	_ FloatCounter = NilFloatCounter{}
+	_ FloatCounter = &StandardFloatCounter{}
+
+	_ Gauge = GaugeSnapshot(0)
+	_ Gauge = NilGauge{}
+	_ Gauge = &StandardGauge{}
+	_ Gauge = FunctionalGauge{}
+
+	_ WatermarkGauge = GaugeSnapshot(0)
+	_ WatermarkGauge = NilGauge{}
+	_ WatermarkGauge = &StandardGauge{}
+	_ WatermarkGauge = FunctionalGauge{}
+
+	_ GaugeFloat64 = GaugeFloat64Snapshot(0)
+	_ GaugeFloat64 = NilGaugeFloat64{}
+	_ GaugeFloat64 = &StandardGaugeFloat64{}
+	_ GaugeFloat64 = FunctionalGaugeFloat64{}
+	_ GaugeFloat64 = &DerivativeGauge{}
+
+	_ WatermarkGaugeFloat64 = GaugeFloat64Snapshot(0)
+	_ WatermarkGaugeFloat64 = NilGaugeFloat64{}
+	_ WatermarkGaugeFloat64 = &StandardGaugeFloat64{}
+	_ WatermarkGaugeFloat64 = FunctionalGaugeFloat64{}
+	_ WatermarkGaugeFloat64 = &DerivativeGauge{}
+
+	_ Healthcheck = HealthcheckSnapshot{}
+	_ Healthcheck = NilHealthcheck{}
+	_ Healthcheck = &StandardHealthcheck{}
+
+	_ SnapshotableHealthcheck = HealthcheckSnapshot{}
+	_ SnapshotableHealthcheck = NilHealthcheck{}
+	_ SnapshotableHealthcheck = &StandardHealthcheck{}
+
+	_ Histogram = &HistogramSnapshot{}
+	_ Histogram = NilHistogram{}
+	_ Histogram = &StandardHistogram{}
+
+	_ DurationHistogram = &HistogramSnapshot{}
+	_ DurationHistogram = NilHistogram{}
+	_ DurationHistogram = &StandardHistogram{}
+
+	_ PercentileDefaulter = &HistogramSnapshot{}
+	_ PercentileDefaulter = NilHistogram{}
+	_ PercentileDefaulter = &StandardHistogram{}
+	_ PercentileDefaulter = NilTimer{}
+	_ PercentileDefaulter = &StandardTimer{}
+	_ PercentileDefaulter = &TimerSnapshot{}
+
+	_ Meter = &MeterSnapshot{}
+	_ Meter = &NilMeter{}
+	_ Meter = &StandardMeter{}
+	_ Meter = &StandardRateMeter{}
+	_ Meter = &RateMeterSnapshot{}
+
+	_ Registry = &StandardRegistry{}
+	_ Registry = &PrefixedRegistry{}
+	_ Registry = &mergedRegistry{}
+	_ Registry = &rpcRegistry{}
+
+	_ AliasingRegistry = &StandardRegistry{}
+	_ AliasingRegistry = &PrefixedRegistry{}
+	_ AliasingRegistry = &mergedRegistry{}
+	_ AliasingRegistry = &rpcRegistry{}
+
+	_ ConstructingRegistry = &StandardRegistry{}
+	_ ConstructingRegistry = &PrefixedRegistry{}
+	_ ConstructingRegistry = &mergedRegistry{}
+	_ ConstructingRegistry = &rpcRegistry{}
+
+	_ DescribableRegistry = &StandardRegistry{}
+	_ DescribableRegistry = &PrefixedRegistry{}
+	_ DescribableRegistry = &mergedRegistry{}
+	_ DescribableRegistry = &rpcRegistry{}
+
+	_ EnumerableRegistry = &StandardRegistry{}
+	_ EnumerableRegistry = &PrefixedRegistry{}
+	_ EnumerableRegistry = &mergedRegistry{}
+	_ EnumerableRegistry = &rpcRegistry{}
+
+	_ ObservableRegistry = &StandardRegistry{}
+	_ ObservableRegistry = &PrefixedRegistry{}
+	_ ObservableRegistry = &mergedRegistry{}
+	_ ObservableRegistry = &rpcRegistry{}
+
+	_ SnapshotableRegistry = &StandardRegistry{}
+	_ SnapshotableRegistry = &PrefixedRegistry{}
+	_ SnapshotableRegistry = &mergedRegistry{}
+	_ SnapshotableRegistry = &rpcRegistry{}
+
+	_ ResettingTimer = NilResettingTimer{}
+	_ ResettingTimer = &StandardResettingTimer{}
+	_ ResettingTimer = &ResettingTimerSnapshot{}
+
+	_ Sample = &EWMASample{}
+	_ Sample = &EWMASampleSnapshot{}
+	_ Sample = &ExpDecaySample{}
+	_ Sample = &HdrSample{}
+	_ Sample = &HdrSampleSnapshot{}
+	_ Sample = NilSample{}
+	_ Sample = &SampleSnapshot{}
+	_ Sample = &TDigestSample{}
+	_ Sample = &TDigestSampleSnapshot{}
+	_ Sample = &UniformSample{}
+	_ Sample = &rpcSample{}
+
+	_ MergeableSample = &ExpDecaySample{}
+	_ MergeableSample = &HdrSample{}
+	_ MergeableSample = &TDigestSample{}
+	_ MergeableSample = &UniformSample{}
+
+	_ ThisMeter = &ThisMeterSnapshot{}
+	_ ThisMeter = NilThisMeter{}
+	_ ThisMeter = &StandardThisMeter{}
+	_ ThisMeter = &MultiMeter{}
+
+	_ TimestampedMetric = &StandardCounter{}
+	_ TimestampedMetric = &StandardFloatCounter{}
+	_ TimestampedMetric = &StandardGauge{}
+	_ TimestampedMetric = &StandardGaugeFloat64{}
+	_ TimestampedMetric = &StandardThisMeter{}
+
+	_ Timer = NilTimer{}
+	_ Timer = &StandardTimer{}
+	_ Timer = &TimerSnapshot{}
+
+	_ ErrorTimer = NilTimer{}
+	_ ErrorTimer = &StandardTimer{}
+	_ ErrorTimer = &TimerSnapshot{}
+
+	_ WindowedCounter = NilWindowedCounter{}
+	_ WindowedCounter = &StandardWindowedCounter{}
+
+	_ Clock  = systemClock{}
+	_ Logger = stdLogger{}
+)
@@ -12,10 +12,54 @@ func (r *StandardRegistry) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.GetAll())
 }
 
+// MarshalJSON returns a JSON object of the count, as in the JSON of a
+// Registry.
+func (c CounterSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(c))
+}
+
+// MarshalJSON returns a JSON object of the count, as in the JSON of a
+// Registry.
+func (c FloatCounterSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(c))
+}
+
+// MarshalJSON returns a JSON object of the value, as in the JSON of a
+// Registry.
+func (g GaugeSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(g))
+}
+
+// MarshalJSON returns a JSON object of the value, as in the JSON of a
+// Registry.
+func (g GaugeFloat64Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(g))
+}
+
+// MarshalJSON returns a JSON object of the count, extremes, mean, standard
+// deviation and percentiles, as in the JSON of a Registry.
+func (h *HistogramSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(h))
+}
+
+// MarshalJSON returns a JSON object of the count and rates, as in the JSON of
+// a Registry.
+func (m *ThisMeterSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(m))
+}
+
+// MarshalJSON returns a JSON object of the count, extremes, mean, standard
+// deviation, percentiles and rates, as in the JSON of a Registry.
+func (t *TimerSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metricValues(t))
+}
+
 // WriteJSON writes metrics from the given registry  periodically to the
 // specified io.Writer as JSON.
 func WriteJSON(r Registry, d time.Duration, w io.Writer) {
-	for _ = range time.Tick(d) {
+	ticker := NewFlushTicker(d, false)
+	defer ticker.Stop()
+	for _ = range ticker.C {
 		WriteJSONOnce(r, w)
 	}
 }
@@ -3,6 +3,7 @@ package metrics
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -26,3 +27,114 @@ func TestRegistryWriteJSONOnce(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRegistryMarshallJSONSorted(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", NewCounter())
+	r.Register("bar", NewGauge())
+	r.Register("baz", NewCounter())
+	b, err := json.Marshal(r)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if s := string(b); `{"bar":{"value":0},"baz":{"count":0},"foo":{"count":0}}` != s {
+		t.Errorf("json.Marshal(r): %s\n", s)
+	}
+}
+
+func TestRegistryMarshalJSONPercentiles(t *testing.T) {
+	r := NewRegistry()
+	h := NewHistogramP(NewUniformSample(100), []float64{0.5, 0.9})
+	for i := int64(1); i <= 10; i++ {
+		h.Update(i)
+	}
+	r.Register("histogram", h)
+	tm := NewTimerP([]float64{0.99})
+	defer tm.Stop()
+	tm.Update(47)
+	r.Register("timer", tm)
+	b, err := json.Marshal(r)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var v map[string]map[string]interface{}
+	if err := json.Unmarshal(b, &v); nil != err {
+		t.Fatal(err)
+	}
+	if median, p90 := v["histogram"]["median"], v["histogram"]["90%"]; 5.5 != median || 9.9 != p90 {
+		t.Errorf("histogram: 5.5 9.9 != %v %v\n", median, p90)
+	}
+	if p99 := v["timer"]["99%"]; 47.0 != p99 {
+		t.Errorf("timer: 47 != %v\n", p99)
+	}
+	for _, key := range []string{"75%", "95%", "99.9%"} {
+		if _, ok := v["histogram"][key]; ok {
+			t.Errorf("json.Marshal(r): unexpected %q in %s\n", key, b)
+		}
+	}
+	if _, ok := v["timer"]["median"]; ok {
+		t.Errorf("json.Marshal(r): unexpected \"median\" in %s\n", b)
+	}
+}
+
+func TestSnapshotMarshalJSON(t *testing.T) {
+	c := NewCounter()
+	c.Inc(47)
+	g := NewGauge()
+	g.Update(47)
+	gf := NewGaugeFloat64()
+	gf.Update(47.5)
+	h := NewHistogram(NewUniformSample(100))
+	h.Update(47)
+	m := NewThisMeter()
+	defer m.Stop()
+	m.Mark(47)
+	tm := NewTimer()
+	defer tm.Stop()
+	tm.Update(47)
+	for _, test := range []struct {
+		snapshot interface{}
+		want     string
+	}{
+		{c.Snapshot(), `{"count":47}`},
+		{g.Snapshot(), `{"value":47}`},
+		{gf.Snapshot(), `{"value":47.5}`},
+		{h.Snapshot(), `{"75%":47,"95%":47,"99%":47,"99.9%":47,"count":1,"max":47,"mean":47,"median":47,"min":47,"stddev":0}`},
+		{tm.Snapshot(), `{"15m.rate":0,"1m.rate":0,"5m.rate":0,"75%":47,"95%":47,"99%":47,"99.9%":47,"count":1,"max":47,"mean":47,"mean.rate":`},
+	} {
+		b, err := json.Marshal(test.snapshot)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if s := string(b); !strings.HasPrefix(s, test.want) {
+			t.Errorf("json.Marshal(%T): %s != %s\n", test.snapshot, test.want, s)
+		}
+	}
+	var v map[string]interface{}
+	b, err := json.Marshal(m.Snapshot())
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &v); nil != err {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"count", "1m.rate", "5m.rate", "15m.rate", "mean.rate"} {
+		if _, ok := v[key]; !ok {
+			t.Errorf("json.Marshal(m.Snapshot()): missing %q in %s\n", key, b)
+		}
+	}
+}
+
+func TestSnapshotMarshalJSONIsStable(t *testing.T) {
+	c := NewCounter()
+	c.Inc(47)
+	s := c.Snapshot()
+	c.Inc(1)
+	b, err := json.Marshal(s)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if `{"count":47}` != string(b) {
+		t.Errorf("json.Marshal(s): {\"count\":47} != %s\n", b)
+	}
+}
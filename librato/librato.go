@@ -29,11 +29,12 @@ type Reporter struct {
 	Registry        metrics.Registry
 	Percentiles     []float64              // percentiles to report on histogram metrics
 	TimerAttributes map[string]interface{} // units in which timers will be displayed
+	AlignFlush      bool                   // whether to report on multiples of Interval, say on the minute, rather than every Interval from starting
 	intervalSec     int64
 }
 
 func NewReporter(r metrics.Registry, d time.Duration, e string, t string, s string, p []float64, u time.Duration) *Reporter {
-	return &Reporter{e, t, "", s, d, r, p, translateTimerAttributes(u), int64(d / time.Second)}
+	return &Reporter{e, t, "", s, d, r, p, translateTimerAttributes(u), false, int64(d / time.Second)}
 }
 
 func Librato(r metrics.Registry, d time.Duration, e string, t string, s string, p []float64, u time.Duration) {
@@ -42,7 +43,7 @@ func Librato(r metrics.Registry, d time.Duration, e string, t string, s string,
 
 func (self *Reporter) Run() {
 	log.Printf("WARNING: This client has been DEPRECATED! It has been moved to https://github.com/mihasya/go-metrics-librato and will be removed from rcrowley/go-metrics on August 5th 2015")
-	ticker := time.Tick(self.Interval)
+	ticker := metrics.NewFlushTicker(self.Interval, self.AlignFlush).C
 	metricsApi := &LibratoClient{self.Email, self.Token}
 	for now := range ticker {
 		var metrics Batch
@@ -125,10 +126,11 @@ func (self *Reporter) BuildRequest(now time.Time, r metrics.Registry) (snapshot
 				measurement[Sum] = float64(s.Sum())
 				measurement[SumSquares] = sumSquares(s)
 				gauges[0] = measurement
+				ps := s.Percentiles(self.Percentiles)
 				for i, p := range self.Percentiles {
 					gauges[i+1] = Measurement{
 						Name:   fmt.Sprintf("%s.%.2f", measurement[Name], p),
-						Value:  s.Percentile(p),
+						Value:  ps[i],
 						Period: measurement[Period],
 					}
 				}
@@ -187,10 +189,11 @@ func (self *Reporter) BuildRequest(now time.Time, r metrics.Registry) (snapshot
 					Period:     int64(self.Interval.Seconds()),
 					Attributes: self.TimerAttributes,
 				}
+				ps := m.Percentiles(self.Percentiles)
 				for i, p := range self.Percentiles {
 					gauges[i+1] = Measurement{
 						Name:       fmt.Sprintf("%s.timer.%2.0f", name, p*100),
-						Value:      m.Percentile(p),
+						Value:      ps[i],
 						Period:     int64(self.Interval.Seconds()),
 						Attributes: self.TimerAttributes,
 					}
@@ -1,13 +1,38 @@
 package metrics
 
 import (
+	"log"
 	"time"
 )
 
+// Loggers receive the output of Log and the errors exporters report.  A
+// *log.Logger is a Logger.
 type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+// stdLogger is the Logger exporters report errors to when none is configured.
+// It writes to the standard library's logger.
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// ErrorLogger receives the errors the package reports outside of any
+// exporter, such as a registry reaching its maximum number of metrics or a
+// meter panicking when it's ticked.  The standard library's logger is used if
+// it's nil.  It isn't safe to set while metrics are in use.
+var ErrorLogger Logger
+
+// loggerOrDefault returns l or, if it's nil, the standard library's logger.
+func loggerOrDefault(l Logger) Logger {
+	if nil == l {
+		return stdLogger{}
+	}
+	return l
+}
+
 func Log(r Registry, freq time.Duration, l Logger) {
 	LogScaled(r, freq, time.Nanosecond, l)
 }
@@ -18,7 +43,9 @@ func LogScaled(r Registry, freq time.Duration, scale time.Duration, l Logger) {
 	du := float64(scale)
 	duSuffix := scale.String()[1:]
 
-	for _ = range time.Tick(freq) {
+	ticker := NewFlushTicker(freq, false)
+	defer ticker.Stop()
+	for _ = range ticker.C {
 		r.Each(func(name string, i interface{}) {
 			switch metric := i.(type) {
 			case Counter:
@@ -36,40 +63,39 @@ func LogScaled(r Registry, freq time.Duration, scale time.Duration, l Logger) {
 				l.Printf("  error:       %v\n", metric.Error())
 			case Histogram:
 				h := metric.Snapshot()
-				ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+				ps := ReportedPercentiles(h)
+				scores := h.Percentiles(ps)
 				l.Printf("histogram %s\n", name)
 				l.Printf("  count:       %9d\n", h.Count())
 				l.Printf("  min:         %9d\n", h.Min())
 				l.Printf("  max:         %9d\n", h.Max())
 				l.Printf("  mean:        %12.2f\n", h.Mean())
 				l.Printf("  stddev:      %12.2f\n", h.StdDev())
-				l.Printf("  median:      %12.2f\n", ps[0])
-				l.Printf("  75%%:         %12.2f\n", ps[1])
-				l.Printf("  95%%:         %12.2f\n", ps[2])
-				l.Printf("  99%%:         %12.2f\n", ps[3])
-				l.Printf("  99.9%%:       %12.2f\n", ps[4])
+				for i, p := range ps {
+					l.Printf("  %-13s%12.2f\n", percentileLabel(p)+":", scores[i])
+				}
 			case ThisMeter:
-				m := metric.Snapshot()
+				m, release := flushSnapshot(metric)
 				l.Printf("meter %s\n", name)
 				l.Printf("  count:       %9d\n", m.Count())
 				l.Printf("  1-min rate:  %12.2f\n", m.Rate1())
 				l.Printf("  5-min rate:  %12.2f\n", m.Rate5())
 				l.Printf("  15-min rate: %12.2f\n", m.Rate15())
 				l.Printf("  mean rate:   %12.2f\n", m.RateMean())
+				release()
 			case Timer:
 				t := metric.Snapshot()
-				ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+				ps := ReportedPercentiles(t)
+				scores := t.Percentiles(ps)
 				l.Printf("timer %s\n", name)
 				l.Printf("  count:       %9d\n", t.Count())
 				l.Printf("  min:         %12.2f%s\n", float64(t.Min())/du, duSuffix)
 				l.Printf("  max:         %12.2f%s\n", float64(t.Max())/du, duSuffix)
 				l.Printf("  mean:        %12.2f%s\n", t.Mean()/du, duSuffix)
 				l.Printf("  stddev:      %12.2f%s\n", t.StdDev()/du, duSuffix)
-				l.Printf("  median:      %12.2f%s\n", ps[0]/du, duSuffix)
-				l.Printf("  75%%:         %12.2f%s\n", ps[1]/du, duSuffix)
-				l.Printf("  95%%:         %12.2f%s\n", ps[2]/du, duSuffix)
-				l.Printf("  99%%:         %12.2f%s\n", ps[3]/du, duSuffix)
-				l.Printf("  99.9%%:       %12.2f%s\n", ps[4]/du, duSuffix)
+				for i, p := range ps {
+					l.Printf("  %-13s%12.2f%s\n", percentileLabel(p)+":", scores[i]/du, duSuffix)
+				}
 				l.Printf("  1-min rate:  %12.2f\n", t.Rate1())
 				l.Printf("  5-min rate:  %12.2f\n", t.Rate5())
 				l.Printf("  15-min rate: %12.2f\n", t.Rate15())
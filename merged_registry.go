@@ -0,0 +1,260 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrReadOnlyRegistry is the error returned by the methods of a merged or
+// remote registry which would register a metric.
+var ErrReadOnlyRegistry = errors.New("metrics: registry is read-only")
+
+// MergePolicy decides what a merged registry does when the same name is
+// registered in more than one of its registries.
+type MergePolicy int
+
+const (
+	// MergePreferFirst uses the metric from the first registry, in the
+	// order they were given, which has the name.
+	MergePreferFirst MergePolicy = iota
+
+	// MergePanic panics with a DuplicateMetric error when a duplicate name
+	// is read.
+	MergePanic
+)
+
+// MergedRegistry returns a read-only Registry spanning the given registries,
+// so they can be exported together without copying their metrics into one.
+// Names registered in more than one registry resolve to the first registry's
+// metric.
+func MergedRegistry(regs ...Registry) Registry {
+	return MergedRegistryWithPolicy(MergePreferFirst, regs...)
+}
+
+// MergedRegistryWithPolicy is just like MergedRegistry but resolves duplicate
+// names according to the given MergePolicy.
+func MergedRegistryWithPolicy(policy MergePolicy, regs ...Registry) Registry {
+	return &mergedRegistry{regs: regs, policy: policy}
+}
+
+// mergedRegistry is a read-only view of several registries.  It keeps no
+// metrics of its own, so metrics registered in or unregistered from the
+// underlying registries are reflected immediately.  Methods which would
+// register a metric return ErrReadOnlyRegistry and those which would
+// unregister one are no-ops.
+type mergedRegistry struct {
+	regs   []Registry
+	policy MergePolicy
+}
+
+// Alias returns ErrReadOnlyRegistry.
+func (r *mergedRegistry) Alias(string, string) error {
+	return ErrReadOnlyRegistry
+}
+
+// Describe is a no-op.
+func (r *mergedRegistry) Describe(string, string, string) {}
+
+// Description returns the description of name in the first of the merged
+// registries which has one.
+func (r *mergedRegistry) Description(name string) (help, unit string, ok bool) {
+	for _, reg := range r.regs {
+		if help, unit, ok = RegistryDescription(reg, name); ok {
+			return
+		}
+	}
+	return
+}
+
+// Each calls the given function for each metric in the merged registries.
+func (r *mergedRegistry) Each(f func(string, interface{})) {
+	for name, i := range r.merge(func(reg Registry) map[string]interface{} {
+		metrics := make(map[string]interface{})
+		reg.Each(func(name string, i interface{}) { metrics[name] = i })
+		return metrics
+	}) {
+		f(name, i)
+	}
+}
+
+// EachFiltered calls fn for each metric in the merged registries for which
+// pred returns true.
+func (r *mergedRegistry) EachFiltered(pred func(string, interface{}) bool, fn func(string, interface{})) {
+	r.Each(func(name string, i interface{}) {
+		if pred(name, i) {
+			fn(name, i)
+		}
+	})
+}
+
+// Get the metric by the given name or nil if none is registered.
+func (r *mergedRegistry) Get(name string) interface{} {
+	var metric interface{}
+	for _, reg := range r.regs {
+		i := reg.Get(name)
+		if nil == i {
+			continue
+		}
+		if nil == metric {
+			metric = i
+			if MergePreferFirst == r.policy {
+				break
+			}
+			continue
+		}
+		panic(DuplicateMetric(name))
+	}
+	return metric
+}
+
+// GetAll metrics in the merged registries.
+func (r *mergedRegistry) GetAll() map[string]map[string]interface{} {
+	return snapshotValues(r.Snapshot())
+}
+
+// GetOrRegister returns the existing metric or, since nothing can be
+// registered, a no-op metric of the kind of i, constructing and stopping it
+// if it's a function, so that GetOrRegisterCounter and the like work.
+func (r *mergedRegistry) GetOrRegister(name string, i interface{}) interface{} {
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	return readOnlyMetric(i)
+}
+
+// GetOrRegisterE returns the existing metric or a no-op metric of the kind
+// ctor constructs and ErrReadOnlyRegistry.
+func (r *mergedRegistry) GetOrRegisterE(name string, ctor func() interface{}) (interface{}, error) {
+	if i := r.Get(name); nil != i {
+		return i, nil
+	}
+	return readOnlyMetric(ctor), ErrReadOnlyRegistry
+}
+
+// GetOrRegisterNamed returns the existing metric or, since nothing can be
+// registered, a no-op metric of the kind ctor constructs.
+func (r *mergedRegistry) GetOrRegisterNamed(name string, ctor func(string) interface{}) interface{} {
+	if i := r.Get(name); nil != i {
+		return i
+	}
+	return readOnlyMetric(func() interface{} { return ctor(name) })
+}
+
+// GetOrRegisterValue returns the existing metric or, since nothing can be
+// registered, a no-op metric of the kind of i.
+func (r *mergedRegistry) GetOrRegisterValue(name string, i interface{}) interface{} {
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	return nilMetric(i)
+}
+
+// Len returns the number of metrics in the merged registries, counting names
+// registered in more than one once.
+func (r *mergedRegistry) Len() int {
+	n := 0
+	r.Each(func(string, interface{}) { n++ })
+	return n
+}
+
+// Names returns the names of the metrics in the merged registries in lexical
+// order, listing names registered in more than one once.
+func (r *mergedRegistry) Names() []string {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, reg := range r.regs {
+		for _, name := range registryNames(reg) {
+			if _, ok := seen[name]; ok {
+				if MergePanic == r.policy {
+					panic(DuplicateMetric(name))
+				}
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OnRegister calls f each time a metric is registered in any of the merged
+// registries which are ObservableRegistries.
+func (r *mergedRegistry) OnRegister(f func(string, interface{})) {
+	for _, reg := range r.regs {
+		registryOnRegister(reg, f)
+	}
+}
+
+// OnUnregister calls f each time a metric is unregistered from any of the
+// merged registries which are ObservableRegistries.
+func (r *mergedRegistry) OnUnregister(f func(string)) {
+	for _, reg := range r.regs {
+		registryOnUnregister(reg, f)
+	}
+}
+
+// Register returns ErrReadOnlyRegistry.
+func (r *mergedRegistry) Register(string, interface{}) error {
+	return ErrReadOnlyRegistry
+}
+
+// RunHealthchecks runs all the healthchecks in the merged registries.
+func (r *mergedRegistry) RunHealthchecks() {
+	r.Each(func(_ string, i interface{}) {
+		if h, ok := i.(Healthcheck); ok {
+			h.Check()
+		}
+	})
+}
+
+// SetMaxMetrics is a no-op.
+func (r *mergedRegistry) SetMaxMetrics(int) {}
+
+// Snapshot returns read-only copies of all the metrics in the merged
+// registries keyed by name, taking one Snapshot of each.
+func (r *mergedRegistry) Snapshot() map[string]interface{} {
+	return r.merge(SnapshotRegistry)
+}
+
+// SortedEach calls the given function for each metric in the merged
+// registries in lexical order by name.
+func (r *mergedRegistry) SortedEach(f func(string, interface{})) {
+	metrics := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) { metrics[name] = i })
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, metrics[name])
+	}
+}
+
+// Unregister is a no-op.
+func (r *mergedRegistry) Unregister(string) {}
+
+// UnregisterAll is a no-op.
+func (r *mergedRegistry) UnregisterAll() {}
+
+// UnregisterMatching is a no-op.
+func (r *mergedRegistry) UnregisterMatching(func(string, interface{}) bool) {}
+
+// merge combines the metrics read from each registry by the given function
+// according to the policy.
+func (r *mergedRegistry) merge(read func(Registry) map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, reg := range r.regs {
+		for name, i := range read(reg) {
+			if _, ok := merged[name]; ok {
+				if MergePanic == r.policy {
+					panic(DuplicateMetric(name))
+				}
+				continue
+			}
+			merged[name] = i
+		}
+	}
+	return merged
+}
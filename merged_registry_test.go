@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergedRegistryDisjoint(t *testing.T) {
+	r1, r2 := NewRegistry(), NewRegistry()
+	NewRegisteredCounter("foo", r1).Inc(47)
+	NewRegisteredGauge("bar", r2).Update(48)
+	r := MergedRegistry(r1, r2).(*mergedRegistry)
+	if c, ok := r.Get("foo").(Counter); !ok || 47 != c.Count() {
+		t.Errorf("r.Get(\"foo\"): %v\n", r.Get("foo"))
+	}
+	if g, ok := r.Get("bar").(Gauge); !ok || 48 != g.Value() {
+		t.Errorf("r.Get(\"bar\"): %v\n", r.Get("bar"))
+	}
+	var names []string
+	r.SortedEach(func(name string, _ interface{}) { names = append(names, name) })
+	if 2 != len(names) || "bar" != names[0] || "foo" != names[1] {
+		t.Errorf("names: [bar foo] != %v\n", names)
+	}
+	data := r.GetAll()
+	if int64(47) != data["foo"]["count"] || int64(48) != data["bar"]["value"] {
+		t.Errorf("r.GetAll(): %v\n", data)
+	}
+	NewRegisteredCounter("baz", r2)
+	if nil == r.Get("baz") {
+		t.Error("metric registered after merging isn't visible")
+	}
+}
+
+func TestMergedRegistryOverlapping(t *testing.T) {
+	r1, r2 := NewRegistry(), NewRegistry()
+	NewRegisteredCounter("foo", r1).Inc(47)
+	NewRegisteredCounter("foo", r2).Inc(48)
+	r := MergedRegistry(r1, r2).(*mergedRegistry)
+	if c := r.Get("foo").(Counter); 47 != c.Count() {
+		t.Errorf("r.Get(\"foo\").Count(): 47 != %v\n", c.Count())
+	}
+	if c := r.Snapshot()["foo"].(Counter); 47 != c.Count() {
+		t.Errorf("r.Snapshot()[\"foo\"].Count(): 47 != %v\n", c.Count())
+	}
+	i := 0
+	r.Each(func(string, interface{}) { i++ })
+	if 1 != i {
+		t.Errorf("r.Each: 1 != %v\n", i)
+	}
+	NewRegisteredCounter("bar", r2)
+	if names := r.Names(); 2 != len(names) || "bar" != names[0] || "foo" != names[1] {
+		t.Errorf("r.Names(): [bar foo] != %v\n", names)
+	}
+}
+
+func TestMergedRegistryPanic(t *testing.T) {
+	r1, r2 := NewRegistry(), NewRegistry()
+	NewRegisteredCounter("foo", r1)
+	NewRegisteredCounter("foo", r2)
+	NewRegisteredCounter("bar", r2)
+	r := MergedRegistryWithPolicy(MergePanic, r1, r2).(*mergedRegistry)
+	if nil == r.Get("bar") {
+		t.Error("r.Get(\"bar\"): nil")
+	}
+	for name, f := range map[string]func(){
+		"Get":      func() { r.Get("foo") },
+		"Each":     func() { r.Each(func(string, interface{}) {}) },
+		"Snapshot": func() { r.Snapshot() },
+	} {
+		func() {
+			defer func() {
+				if err, ok := recover().(DuplicateMetric); !ok || "foo" != string(err) {
+					t.Errorf("%s: recover(): foo != %v\n", name, err)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestMergedRegistryReadOnly(t *testing.T) {
+	r1 := NewRegistry()
+	NewRegisteredCounter("foo", r1)
+	r := MergedRegistry(r1).(*mergedRegistry)
+	if err := r.Register("bar", NewCounter()); ErrReadOnlyRegistry != err {
+		t.Errorf("r.Register: %v != %v\n", ErrReadOnlyRegistry, err)
+	}
+	if i, err := r.GetOrRegisterE("bar", func() interface{} { return NewCounter() }); ErrReadOnlyRegistry != err {
+		t.Errorf("r.GetOrRegisterE: %v != %v\n", ErrReadOnlyRegistry, err)
+	} else if _, ok := i.(NilCounter); !ok {
+		t.Errorf("r.GetOrRegisterE: NilCounter != %T\n", i)
+	}
+	if i := r.GetOrRegister("bar", NewCounter()); (NilCounter{}) != i {
+		t.Errorf("r.GetOrRegister: NilCounter != %v\n", i)
+	}
+	if _, ok := r.GetOrRegister("foo", NewCounter()).(*StandardCounter); !ok {
+		t.Errorf("r.GetOrRegister(\"foo\"): *StandardCounter != %T\n", r.GetOrRegister("foo", NewCounter()))
+	}
+	GetOrRegisterCounter("bar", r).Inc(1)
+	GetOrRegisterThisMeter("bar", r).Mark(1)
+	GetOrRegisterTimer("bar", r).Update(time.Second)
+	if g := GetOrRegisterGaugeFloat64("bar", r); (NilGaugeFloat64{}) != g {
+		t.Errorf("GetOrRegisterGaugeFloat64: NilGaugeFloat64 != %v\n", g)
+	}
+	if i := r.GetOrRegisterValue("bar", NewGauge()); (NilGauge{}) != i {
+		t.Errorf("r.GetOrRegisterValue: NilGauge != %v\n", i)
+	}
+	if i := r.GetOrRegisterNamed("bar", func(string) interface{} { return NewHistogram(NewUniformSample(10)) }); (NilHistogram{}) != i {
+		t.Errorf("r.GetOrRegisterNamed: NilHistogram != %v\n", i)
+	}
+	r.UnregisterAll()
+	if nil == r1.Get("foo") {
+		t.Error("UnregisterAll unregistered from an underlying registry")
+	}
+}
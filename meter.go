@@ -1,7 +1,10 @@
 package metrics
 
 import (
+	"context"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,6 +21,16 @@ type ThisMeter interface {
 	Stop()
 }
 
+// GetMeter returns the ThisMeter registered under the given name or nil if there is
+// none or it is another kind of metric.
+func GetMeter(name string, r Registry) ThisMeter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(ThisMeter)
+	return m
+}
+
 // GetOrRegisterThisMeter returns an existing Meter or constructs and registers a
 // new StandardThisMeter.
 // Be sure to unregister the meter from the registry once it is of no use to
@@ -32,16 +45,88 @@ func GetOrRegisterThisMeter(name string, r Registry) ThisMeter {
 // NewThisMeter constructs a new StandardThisMeter and launches a goroutine.
 // Be sure to call Stop() once the meter is of no use to allow for garbage collection.
 func NewThisMeter() ThisMeter {
-	if UseNilMetrics {
+	return NewThisMeterWithInterval(defaultTickInterval)
+}
+
+// NewThisMeterWithInterval constructs a new StandardThisMeter whose moving
+// averages are ticked every d rather than every five seconds, or every five
+// seconds if d isn't positive.  Meters sharing an interval are ticked by the
+// same goroutine.
+// Be sure to call Stop() once the meter is of no use to allow for garbage collection.
+func NewThisMeterWithInterval(d time.Duration) ThisMeter {
+	if UseNilMetrics || UseNilMeters {
+		return NilThisMeter{}
+	}
+	return startThisMeter(d)
+}
+
+// NewThisMeterWithWarmup constructs a new StandardThisMeter whose mean rate
+// is reported as zero until d has elapsed since it was constructed or cleared,
+// rather than spiking while the elapsed time is tiny.  It's
+// NewThisMeterWithConfig with only Warmup set; use that to also hold back the
+// moving averages.
+// Be sure to call Stop() once the meter is of no use to allow for garbage collection.
+func NewThisMeterWithWarmup(d time.Duration) ThisMeter {
+	return NewThisMeterWithConfig(ThisMeterConfig{Warmup: d})
+}
+
+// NewThisMeterWithMeanWindow constructs a new StandardThisMeter whose mean
+// rate is rescaled every d so that months-old events don't swamp recent ones.
+// The tradeoff is that RateMean is then the mean rate over the last one to two
+// windows rather than since the meter was constructed; Count and the moving
+// averages are unaffected.  It's NewThisMeterWithConfig with only MeanWindow
+// set.
+// Be sure to call Stop() once the meter is of no use to allow for garbage collection.
+func NewThisMeterWithMeanWindow(d time.Duration) ThisMeter {
+	return NewThisMeterWithConfig(ThisMeterConfig{MeanWindow: d})
+}
+
+// ThisMeterConfig provides a container with configuration parameters for
+// NewThisMeterWithConfig.
+type ThisMeterConfig struct {
+	TickInterval time.Duration // Interval the moving averages are ticked at, five seconds if not positive
+	Warmup       time.Duration // Time since construction or Clear until which the mean rate is reported as zero, rather than spiking while the elapsed time is tiny
+	WarmupRates  bool          // Whether to also report each moving average as zero until its full window has elapsed since construction or Clear
+	MeanWindow   time.Duration // Window the mean rate is rescaled every so that months-old events don't swamp recent ones, never if zero
+}
+
+// NewThisMeterWithConfig constructs a new StandardThisMeter configured by c
+// and launches a goroutine.  With a MeanWindow, RateMean is the mean rate
+// over the last one to two windows rather than since the meter was
+// constructed; Count and the moving averages are unaffected.
+// Be sure to call Stop() once the meter is of no use to allow for garbage collection.
+func NewThisMeterWithConfig(c ThisMeterConfig) ThisMeter {
+	if UseNilMetrics || UseNilMeters {
 		return NilThisMeter{}
 	}
-	m := newStandardThisMeter()
-	arbiter.Lock()
-	defer arbiter.Unlock()
-	arbiter.meters[m] = struct{}{}
-	if !arbiter.started {
-		arbiter.started = true
-		go arbiter.tick()
+	m := startThisMeter(c.TickInterval)
+	m.lock.Lock()
+	m.warmup, m.warmupRates, m.meanWindow = c.Warmup, c.WarmupRates, c.MeanWindow
+	m.lock.Unlock()
+	return m
+}
+
+// startThisMeter constructs a new StandardThisMeter and adds it to the arbiter
+// for d, or for the default interval if d isn't positive, regardless of
+// UseNilMeters.
+func startThisMeter(d time.Duration) *StandardThisMeter {
+	if d <= 0 {
+		d = defaultTickInterval
+	}
+	m := newStandardThisMeterWithInterval(d)
+	// Hold arbiters' lock until the meter's been added so that the arbiter
+	// can't be torn down in between.
+	arbiters.Lock()
+	ma := arbiterFor(d)
+	ma.Lock()
+	arbiters.Unlock()
+	defer ma.Unlock()
+	m.arbiter = ma
+	ma.add(m)
+	if !ma.started {
+		ma.started = true
+		ma.ticker = time.NewTicker(ma.interval)
+		go ma.tick()
 	}
 	return m
 }
@@ -59,10 +144,29 @@ func NewRegisteredThisMeter(name string, r Registry) ThisMeter {
 	return c
 }
 
+// NewRegisteredThisMeterWithInterval constructs and registers a new
+// StandardThisMeter ticked every d and launches a goroutine.
+// Be sure to unregister the meter from the registry once it is of no use to
+// allow for garbage collection.
+func NewRegisteredThisMeterWithInterval(name string, r Registry, d time.Duration) ThisMeter {
+	c := NewThisMeterWithInterval(d)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
 // ThisMeterSnapshot is a read-only copy of another Meter.
 type ThisMeterSnapshot struct {
 	count                          int64
 	rate1, rate5, rate15, rateMean float64
+	pooled                         bool
+	released                       uint32
+}
+
+var thisMeterSnapshotPool = sync.Pool{
+	New: func() interface{} { return &ThisMeterSnapshot{} },
 }
 
 // Count returns the count of events at the time the snapshot was taken.
@@ -89,6 +193,19 @@ func (m *ThisMeterSnapshot) Rate15() float64 { return m.rate15 }
 // snapshot was taken.
 func (m *ThisMeterSnapshot) RateMean() float64 { return m.rateMean }
 
+// Release returns a snapshot taken by SnapshotPooled to the pool for reuse.
+// It's a no-op on any other snapshot and panics if the snapshot has already
+// been released, which would let two holders share it.
+func (m *ThisMeterSnapshot) Release() {
+	if !m.pooled {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&m.released, 0, 1) {
+		panic("Release called twice on a ThisMeterSnapshot")
+	}
+	thisMeterSnapshotPool.Put(m)
+}
+
 // Snapshot returns the snapshot.
 func (m *ThisMeterSnapshot) Snapshot() ThisMeter { return m }
 
@@ -122,107 +239,317 @@ func (NilThisMeter) Snapshot() ThisMeter { return NilThisMeter{} }
 // Stop is a no-op.
 func (NilThisMeter) Stop() {}
 
-// StandardThisMeter is the standard implementation of a Meter.
+// StandardThisMeter is the standard implementation of a Meter.  Mark only
+// adds to the count and the moving averages' uncounted events; the rates are
+// recomputed when the meter is ticked and, if the count has changed since,
+// when they're read.
 type StandardThisMeter struct {
-	lock        sync.RWMutex
-	snapshot    *ThisMeterSnapshot
-	a1, a5, a15 EWMA
-	startTime   time.Time
-	stopped     bool
+	count        int64 // accessed atomically, first for 64-bit alignment
+	lastUpdate   int64 // nanoseconds since the Unix epoch
+	stopped      uint32
+	lock         sync.RWMutex
+	snapshot     *ThisMeterSnapshot
+	a1, a5, a15  EWMA
+	startTime    time.Time
+	lastRead     time.Time
+	lastCount    int64
+	tickTime     time.Time
+	tickCount    int64
+	clock        Clock
+	warmup       time.Duration
+	warmupRates  bool
+	meanWindow   time.Duration
+	startCount   int64 // count at startTime once the mean has been rescaled
+	rescaleTime  time.Time
+	rescaleCount int64
+	arbiter      *meterArbiter
+	shard        int // of the arbiter's shards, the one ticking the meter
 }
 
 func newStandardThisMeter() *StandardThisMeter {
 	return &StandardThisMeter{
 		snapshot:  &ThisMeterSnapshot{},
-		a1:        NewEWMA1(),
-		a5:        NewEWMA5(),
-		a15:       NewEWMA15(),
+		a1:        NewEWMAWithInterval(time.Minute, defaultTickInterval),
+		a5:        NewEWMAWithInterval(5*time.Minute, defaultTickInterval),
+		a15:       NewEWMAWithInterval(15*time.Minute, defaultTickInterval),
 		startTime: time.Now(),
+		clock:     systemClock{},
+		arbiter:   &arbiter,
+	}
+}
+
+func newStandardThisMeterWithInterval(d time.Duration) *StandardThisMeter {
+	if d == defaultTickInterval {
+		return newStandardThisMeter()
+	}
+	return &StandardThisMeter{
+		snapshot:  &ThisMeterSnapshot{},
+		a1:        NewEWMAWithInterval(time.Minute, d),
+		a5:        NewEWMAWithInterval(5*time.Minute, d),
+		a15:       NewEWMAWithInterval(15*time.Minute, d),
+		startTime: time.Now(),
+		clock:     systemClock{},
 	}
 }
 
 // Stop stops the meter, Mark() will be a no-op if you use it after being stopped.
 func (m *StandardThisMeter) Stop() {
+	if atomic.CompareAndSwapUint32(&m.stopped, 0, 1) && m.arbiter != nil {
+		m.arbiter.remove(m)
+	}
+}
+
+// Clear resets the count to zero and restarts the mean rate.  If resetRates
+// is true the moving averages are reset to zero too, as if the meter had just
+// been constructed.  If it's false they carry on decaying from where they
+// were, so that, say, a count reset at midnight doesn't make Rate1, Rate5 and
+// Rate15 drop.  It's atomic with respect to the arbiter's ticks; marks
+// concurrent with it may or may not be counted.
+func (m *StandardThisMeter) Clear(resetRates bool) {
 	m.lock.Lock()
-	stopped := m.stopped
-	m.stopped = true
-	m.lock.Unlock()
-	if !stopped {
-		arbiter.Lock()
-		delete(arbiter.meters, m)
-		arbiter.Unlock()
+	defer m.lock.Unlock()
+	atomic.StoreInt64(&m.count, 0)
+	m.startTime, m.startCount = m.clock.Now(), 0
+	m.rescaleTime, m.rescaleCount = time.Time{}, 0
+	m.lastRead, m.lastCount = time.Time{}, 0
+	m.tickTime, m.tickCount = time.Time{}, 0
+	touchLastUpdate(&m.lastUpdate)
+	if resetRates {
+		for _, a := range []EWMA{m.a1, m.a5, m.a15} {
+			if a, ok := a.(*StandardEWMA); ok {
+				a.clear()
+			}
+		}
 	}
+	m.updateSnapshot()
 }
 
 // Count returns the number of events recorded.
 func (m *StandardThisMeter) Count() int64 {
-	m.lock.RLock()
-	count := m.snapshot.count
-	m.lock.RUnlock()
-	return count
+	return atomic.LoadInt64(&m.count)
 }
 
-// Mark records the occurance of n events.
+// LastUpdate returns when the meter was last marked or cleared, or the zero
+// Time if it never has been.
+func (m *StandardThisMeter) LastUpdate() time.Time {
+	return loadLastUpdate(&m.lastUpdate)
+}
+
+// Mark records the occurance of n events.  A negative n is not an error: it
+// decrements the count and is fed to the moving averages like any other mark,
+// so the rates may go negative.  Callers processing events in batches should
+// mark each batch once rather than each event.
 func (m *StandardThisMeter) Mark(n int64) {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	if m.stopped {
+	if 1 == atomic.LoadUint32(&m.stopped) {
 		return
 	}
-	m.snapshot.count += n
+	atomic.AddInt64(&m.count, n)
+	touchLastUpdate(&m.lastUpdate)
 	m.a1.Update(n)
 	m.a5.Update(n)
 	m.a15.Update(n)
-	m.updateSnapshot()
+}
+
+// MarkBatch records the occurance of the sum of counts events with a single
+// update of the count and of each moving average, as if Mark had been called
+// once with the sum.
+func (m *StandardThisMeter) MarkBatch(counts []int64) {
+	var n int64
+	for _, c := range counts {
+		n += c
+	}
+	m.Mark(n)
+}
+
+// MarkContext records the occurance of n events, as Mark does, and, if a
+// Tracer has been set by SetTracer, adds an event for them to the span
+// active in ctx.  Without a Tracer it's exactly Mark and doesn't allocate.
+func (m *StandardThisMeter) MarkContext(ctx context.Context, n int64) {
+	if 1 == atomic.LoadUint32(&m.stopped) {
+		return
+	}
+	m.Mark(n)
+	if t := currentTracer(); nil != t {
+		t.MarkEvent(ctx, m, n)
+	}
 }
 
 // Rate1 returns the one-minute moving average rate of events per second.
 func (m *StandardThisMeter) Rate1() float64 {
 	m.lock.RLock()
-	rate1 := m.snapshot.rate1
+	snapshot := *m.snapshot
+	m.warmingUp(&snapshot)
 	m.lock.RUnlock()
-	return rate1
+	return snapshot.rate1
 }
 
 // Rate5 returns the five-minute moving average rate of events per second.
 func (m *StandardThisMeter) Rate5() float64 {
 	m.lock.RLock()
-	rate5 := m.snapshot.rate5
+	snapshot := *m.snapshot
+	m.warmingUp(&snapshot)
 	m.lock.RUnlock()
-	return rate5
+	return snapshot.rate5
 }
 
 // Rate15 returns the fifteen-minute moving average rate of events per second.
 func (m *StandardThisMeter) Rate15() float64 {
 	m.lock.RLock()
-	rate15 := m.snapshot.rate15
+	snapshot := *m.snapshot
+	m.warmingUp(&snapshot)
 	m.lock.RUnlock()
-	return rate15
+	return snapshot.rate15
 }
 
 // RateMean returns the meter's mean rate of events per second.
 func (m *StandardThisMeter) RateMean() float64 {
+	var snapshot ThisMeterSnapshot
+	m.currentInto(&snapshot)
+	return snapshot.rateMean
+}
+
+// RateMeanSinceLastRead returns the mean rate of events per second since the
+// previous call, or since the meter was constructed or cleared on the first
+// call, so that exporters can report the rate over each flush interval.  Each
+// call starts a new interval so a meter should have only one such reader.
+// Should no time have passed, it returns 0 and the interval goes on.
+func (m *StandardThisMeter) RateMeanSinceLastRead() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now, count := m.clock.Now(), atomic.LoadInt64(&m.count)
+	lastRead := m.lastRead
+	if lastRead.IsZero() {
+		lastRead = m.startTime
+	}
+	elapsed := now.Sub(lastRead).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	rate := float64(count-m.lastCount) / elapsed
+	m.lastRead, m.lastCount = now, count
+	return rate
+}
+
+// RateInstant returns the rate of events per second since the meter was last
+// ticked, or since it was constructed or cleared before the first tick, which
+// follows a burst of events before the moving averages catch up with it.
+func (m *StandardThisMeter) RateInstant() float64 {
 	m.lock.RLock()
-	rateMean := m.snapshot.rateMean
-	m.lock.RUnlock()
-	return rateMean
+	defer m.lock.RUnlock()
+	now, count := m.clock.Now(), atomic.LoadInt64(&m.count)
+	tickTime := m.tickTime
+	if tickTime.IsZero() {
+		tickTime = m.startTime
+	}
+	elapsed := now.Sub(tickTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count-m.tickCount) / elapsed
 }
 
 // Snapshot returns a read-only copy of the meter.
 func (m *StandardThisMeter) Snapshot() ThisMeter {
+	return m.current()
+}
+
+// SnapshotPooled returns a read-only copy of the meter like Snapshot but
+// reuses snapshots previously returned by Release to spare exporters that
+// snapshot every meter on every flush an allocation per meter.  Call Release
+// once done with it and don't use it afterwards.
+func (m *StandardThisMeter) SnapshotPooled() *ThisMeterSnapshot {
+	snapshot := thisMeterSnapshotPool.Get().(*ThisMeterSnapshot)
+	m.currentInto(snapshot)
+	snapshot.pooled, snapshot.released = true, 0
+	return snapshot
+}
+
+// flushSnapshot returns a snapshot of m for an exporter to read during a
+// flush, taken by SnapshotPooled if m is a StandardThisMeter, and the function
+// to release it with once the flush is done with it.
+func flushSnapshot(m ThisMeter) (ThisMeter, func()) {
+	if sm, ok := m.(*StandardThisMeter); ok {
+		snapshot := sm.SnapshotPooled()
+		return snapshot, snapshot.Release
+	}
+	return m.Snapshot(), func() {}
+}
+
+// current returns a copy of the snapshot, first bringing it up to date if
+// events have been marked since it was last updated.
+func (m *StandardThisMeter) current() *ThisMeterSnapshot {
+	snapshot := &ThisMeterSnapshot{}
+	m.currentInto(snapshot)
+	return snapshot
+}
+
+// currentInto is current but copies the snapshot into the given one.
+func (m *StandardThisMeter) currentInto(snapshot *ThisMeterSnapshot) {
 	m.lock.RLock()
-	snapshot := *m.snapshot
+	*snapshot = *m.snapshot
+	m.warmingUp(snapshot)
 	m.lock.RUnlock()
-	return &snapshot
+	if atomic.LoadInt64(&m.count) == snapshot.count {
+		return
+	}
+	m.lock.Lock()
+	m.updateSnapshot()
+	*snapshot = *m.snapshot
+	m.warmingUp(snapshot)
+	m.lock.Unlock()
+}
+
+// warmingUp zeroes the rates in a copy of the snapshot that aren't yet
+// available because the meter's still warming up.
+func (m *StandardThisMeter) warmingUp(snapshot *ThisMeterSnapshot) {
+	// should run with read or write lock held on m.lock
+	if 0 == m.warmup && !m.warmupRates {
+		return
+	}
+	elapsed := m.clock.Now().Sub(m.startTime)
+	if elapsed < m.warmup {
+		snapshot.rateMean = 0
+	}
+	if !m.warmupRates {
+		return
+	}
+	if elapsed < time.Minute {
+		snapshot.rate1 = 0
+	}
+	if elapsed < 5*time.Minute {
+		snapshot.rate5 = 0
+	}
+	if elapsed < 15*time.Minute {
+		snapshot.rate15 = 0
+	}
 }
 
 func (m *StandardThisMeter) updateSnapshot() {
 	// should run with write lock held on m.lock
 	snapshot := m.snapshot
+	snapshot.count = atomic.LoadInt64(&m.count)
 	snapshot.rate1 = m.a1.Rate()
 	snapshot.rate5 = m.a5.Rate()
 	snapshot.rate15 = m.a15.Rate()
-	snapshot.rateMean = float64(snapshot.count) / time.Since(m.startTime).Seconds()
+	now := m.clock.Now()
+	if 0 < m.meanWindow {
+		m.rescale(now, snapshot.count)
+	}
+	snapshot.rateMean = float64(snapshot.count-m.startCount) / now.Sub(m.startTime).Seconds()
+}
+
+// rescale moves the start of the mean rate up to the previous rescale once a
+// window has passed since it, so the mean always covers at least one window.
+func (m *StandardThisMeter) rescale(now time.Time, count int64) {
+	// should run with write lock held on m.lock
+	if m.rescaleTime.IsZero() {
+		m.rescaleTime, m.rescaleCount = m.startTime, m.startCount
+	}
+	if now.Sub(m.rescaleTime) < m.meanWindow {
+		return
+	}
+	m.startTime, m.startCount = m.rescaleTime, m.rescaleCount
+	m.rescaleTime, m.rescaleCount = now, count
 }
 
 func (m *StandardThisMeter) tick() {
@@ -232,33 +559,251 @@ func (m *StandardThisMeter) tick() {
 	m.a5.Tick()
 	m.a15.Tick()
 	m.updateSnapshot()
+	m.tickTime, m.tickCount = m.clock.Now(), m.snapshot.count
 }
 
-// meterArbiter ticks meters every 5s from a single goroutine.
-// meters are references in a set for future stopping.
+// defaultTickInterval is the interval at which the default arbiter ticks
+// meters and which the standard EWMA constructors assume.
+const defaultTickInterval = 5 * time.Second
+
+// parallelTickMeters is the number of meters from which an arbiter ticks its
+// shards from a goroutine each rather than one after another.
+const parallelTickMeters = 4096
+
+// meterArbiter ticks meters every interval from a single goroutine.  The
+// meters are spread across shards, one per GOMAXPROCS, each a set of
+// references for future stopping, and once there are enough of them each
+// shard is ticked from its own goroutine so that a pass over tens of
+// thousands of meters doesn't overrun the interval.  The goroutine exits once
+// every meter has been stopped and is restarted by the next new meter.
 type meterArbiter struct {
 	sync.RWMutex
-	started bool
-	meters  map[*StandardThisMeter]struct{}
-	ticker  *time.Ticker
+	started  bool
+	paused   bool
+	next     int // shard of the next meter added
+	shards   []*meterShard
+	ticker   *time.Ticker
+	interval time.Duration
+}
+
+var arbiter = meterArbiter{
+	shards:   newMeterShards(runtime.GOMAXPROCS(0)),
+	interval: defaultTickInterval,
+}
+
+// newMeterArbiter constructs a new meterArbiter ticking at the given interval
+// with the given number of shards.
+func newMeterArbiter(d time.Duration, shards int) *meterArbiter {
+	return &meterArbiter{shards: newMeterShards(shards), interval: d}
+}
+
+// meterShard is one of an arbiter's sets of meters.  Its lock is held while
+// it's ticked, so a meter being stopped only waits for its own shard.
+type meterShard struct {
+	sync.Mutex
+	meters map[*StandardThisMeter]struct{}
+}
+
+func newMeterShards(n int) []*meterShard {
+	shards := make([]*meterShard, n)
+	for i := range shards {
+		shards[i] = &meterShard{meters: make(map[*StandardThisMeter]struct{})}
+	}
+	return shards
+}
+
+// arbiters holds one arbiter per tick interval so meters constructed with
+// the same interval share a single goroutine.  Arbiters other than the
+// default are deleted once their last meter has been stopped.  Its lock is
+// taken before that of any arbiter.
+var arbiters = struct {
+	sync.Mutex
+	m            map[time.Duration]*meterArbiter
+	paused       bool
+	tickDuration Timer // set by RegisterArbiterMetrics
+}{m: map[time.Duration]*meterArbiter{defaultTickInterval: &arbiter}}
+
+// arbiterFor returns the arbiter ticking at the given interval, creating it
+// if necessary.
+func arbiterFor(d time.Duration) *meterArbiter {
+	// should run with arbiters' lock held
+	if ma, ok := arbiters.m[d]; ok {
+		return ma
+	}
+	ma := newMeterArbiter(d, runtime.GOMAXPROCS(0))
+	ma.paused = arbiters.paused
+	arbiters.m[d] = ma
+	return ma
+}
+
+// RegisterArbiterMetrics registers metrics about the arbiters which tick
+// meters in r, or DefaultRegistry if r is nil, so that operators can see
+// ticking falling behind its interval: a gauge go-metrics.arbiter.meters of
+// the number of live meters and a timer go-metrics.arbiter.tick_duration of
+// how long each pass over them takes.  Nothing is measured until it's called.
+func RegisterArbiterMetrics(r Registry) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register("go-metrics.arbiter.meters", NewFunctionalGauge(liveMeters))
+	t := GetOrRegisterTimer("go-metrics.arbiter.tick_duration", r)
+	arbiters.Lock()
+	defer arbiters.Unlock()
+	arbiters.tickDuration = t
+}
+
+// liveMeters returns the number of meters every arbiter is ticking.
+func liveMeters() int64 {
+	arbiters.Lock()
+	defer arbiters.Unlock()
+	var n int64
+	for _, ma := range arbiters.m {
+		n += int64(ma.len())
+	}
+	return n
+}
+
+// SetArbiterPaused pauses or resumes the ticking of every meter's moving
+// averages without unregistering any meters, so tests can tick them by hand.
+// While paused, Rate1, Rate5 and Rate15 freeze but Count still advances on
+// Mark.  Once SetArbiterPaused(true) returns no further ticks happen until
+// SetArbiterPaused(false) is called.
+func SetArbiterPaused(paused bool) {
+	arbiters.Lock()
+	defer arbiters.Unlock()
+	arbiters.paused = paused
+	for _, ma := range arbiters.m {
+		ma.Lock()
+		ma.paused = paused
+		ma.Unlock()
+	}
+}
+
+// add adds the meter to the next of the arbiter's shards in turn, which it's
+// removed from when stopped.  The arbiter must be locked.
+func (ma *meterArbiter) add(m *StandardThisMeter) {
+	m.shard = ma.next
+	ma.next = (ma.next + 1) % len(ma.shards)
+	s := ma.shards[m.shard]
+	s.Lock()
+	defer s.Unlock()
+	s.meters[m] = struct{}{}
+}
+
+// has returns whether the arbiter is ticking the meter.
+func (ma *meterArbiter) has(m *StandardThisMeter) bool {
+	s := ma.shards[m.shard]
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.meters[m]
+	return ok
+}
+
+// len returns the number of meters the arbiter is ticking.
+func (ma *meterArbiter) len() int {
+	var n int
+	for _, s := range ma.shards {
+		s.Lock()
+		n += len(s.meters)
+		s.Unlock()
+	}
+	return n
 }
 
-var arbiter = meterArbiter{ticker: time.NewTicker(5e9), meters: make(map[*StandardThisMeter]struct{})}
+// remove removes the meter from its shard.
+func (ma *meterArbiter) remove(m *StandardThisMeter) {
+	s := ma.shards[m.shard]
+	s.Lock()
+	defer s.Unlock()
+	delete(s.meters, m)
+}
 
-// Ticks meters on the scheduled interval
+// Ticks meters on the scheduled interval until there are none left
 func (ma *meterArbiter) tick() {
 	for {
 		select {
 		case <-ma.ticker.C:
-			ma.tickMeters()
+			if !ma.tickMeters() {
+				return
+			}
 		}
 	}
 }
 
-func (ma *meterArbiter) tickMeters() {
+// tickMeter ticks the meter, recovering from and logging any panic so that a
+// bad meter, say one with a broken custom EWMA, doesn't stop the arbiter
+// ticking every other meter.
+func tickMeter(m *StandardThisMeter) {
+	defer func() {
+		if r := recover(); nil != r {
+			loggerOrDefault(ErrorLogger).Printf("metrics: recovered from panic ticking meter %p: %v", m, r)
+		}
+	}()
+	m.tick()
+}
+
+// tickMeters ticks every meter unless the arbiter is paused.  If there are
+// none it stops the ticker, marks the arbiter as not started, deletes it from
+// arbiters unless it's the default and returns false.
+func (ma *meterArbiter) tickMeters() bool {
+	arbiters.Lock()
+	t := arbiters.tickDuration
+	arbiters.Unlock()
+	start := time.Now()
 	ma.RLock()
-	defer ma.RUnlock()
-	for meter := range ma.meters {
-		meter.tick()
+	n, ticked := ma.len(), !ma.paused
+	if ticked && 0 != n {
+		ma.tickShards(n)
+	}
+	ma.RUnlock()
+	if nil != t && ticked && 0 != n {
+		t.UpdateSince(start)
+	}
+	if 0 != n {
+		return true
+	}
+	arbiters.Lock()
+	defer arbiters.Unlock()
+	ma.Lock()
+	defer ma.Unlock()
+	if 0 != ma.len() {
+		return true
+	}
+	ma.ticker.Stop()
+	ma.started = false
+	if ma != &arbiter && ma == arbiters.m[ma.interval] {
+		delete(arbiters.m, ma.interval)
+	}
+	return false
+}
+
+// tickShards ticks the n meters of every shard, from a goroutine per shard
+// if there are at least parallelTickMeters of them and one after another
+// otherwise, since then ticking is quicker than starting the goroutines.
+func (ma *meterArbiter) tickShards(n int) {
+	if n < parallelTickMeters || 1 == len(ma.shards) {
+		for _, s := range ma.shards {
+			s.tick()
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	for _, s := range ma.shards[1:] {
+		wg.Add(1)
+		go func(s *meterShard) {
+			defer wg.Done()
+			s.tick()
+		}(s)
+	}
+	ma.shards[0].tick()
+	wg.Wait()
+}
+
+// tick ticks every meter in the shard.
+func (s *meterShard) tick() {
+	s.Lock()
+	defer s.Unlock()
+	for meter := range s.meters {
+		tickMeter(meter)
 	}
 }
@@ -1,6 +1,11 @@
 package metrics
 
 import (
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,6 +18,145 @@ func BenchmarkMeter(b *testing.B) {
 	}
 }
 
+func BenchmarkMeterParallel(b *testing.B) {
+	m := NewThisMeter()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Mark(1)
+		}
+	})
+}
+
+func BenchmarkMeterSnapshot(b *testing.B) {
+	meters := make([]*StandardThisMeter, 100)
+	for i := range meters {
+		meters[i] = NewThisMeter().(*StandardThisMeter)
+		defer meters[i].Stop()
+	}
+	snapshots := make([]ThisMeter, len(meters))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, m := range meters {
+			m.Mark(1)
+			snapshots[j] = m.Snapshot()
+		}
+	}
+}
+
+func BenchmarkMeterSnapshotPooled(b *testing.B) {
+	meters := make([]*StandardThisMeter, 100)
+	for i := range meters {
+		meters[i] = NewThisMeter().(*StandardThisMeter)
+		defer meters[i].Stop()
+	}
+	snapshots := make([]*ThisMeterSnapshot, len(meters))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, m := range meters {
+			m.Mark(1)
+			snapshots[j] = m.SnapshotPooled()
+		}
+		for _, snapshot := range snapshots {
+			snapshot.Release()
+		}
+	}
+}
+
+func BenchmarkMeterMark100(b *testing.B) {
+	m := NewThisMeter()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			m.Mark(1)
+		}
+	}
+}
+
+func BenchmarkMeterMarkBatch100(b *testing.B) {
+	m := NewThisMeter().(*StandardThisMeter)
+	counts := make([]int64, 100)
+	for i := range counts {
+		counts[i] = 1
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MarkBatch(counts)
+	}
+}
+
+// BenchmarkMeterArbiterTick measures a pass over 100k meters, which should
+// take less time the more shards they're ticked from.
+func BenchmarkMeterArbiterTick(b *testing.B) {
+	for _, shards := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			ma := newMeterArbiter(defaultTickInterval, shards)
+			for i := 0; i < 100000; i++ {
+				m := newStandardThisMeter()
+				m.Mark(1)
+				ma.add(m)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ma.tickMeters()
+			}
+		})
+	}
+}
+
+func TestGetMeter(t *testing.T) {
+	r := NewRegistry()
+	m := NewRegisteredThisMeter("foo", r)
+	NewRegisteredMeter("bar", r)
+	if got := GetMeter("foo", r); m != got {
+		t.Errorf("GetMeter(\"foo\", r): %v != %v\n", m, got)
+	}
+	if got := GetMeter("bar", r); nil != got {
+		t.Errorf("GetMeter(\"bar\", r): nil != %v\n", got)
+	}
+	if got := GetMeter("baz", r); nil != got {
+		t.Errorf("GetMeter(\"baz\", r): nil != %v\n", got)
+	}
+}
+
+func TestGetOrRegisterValueThisMeter(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	defer r.UnregisterAll()
+	l := arbiter.len()
+	m := NewThisMeter()
+	for i := 0; i < 10; i++ {
+		if got := r.GetOrRegisterValue("foo", m); m != got {
+			t.Errorf("r.GetOrRegisterValue(): %v != %v\n", m, got)
+		}
+	}
+	if arbiter.len() != l+1 {
+		t.Errorf("arbiter.meters: %d != %d\n", l+1, arbiter.len())
+	}
+}
+
+func TestGetOrRegisterThisMeterStopsConstructed(t *testing.T) {
+	r := NewRegistry()
+	defer r.UnregisterAll()
+	l := arbiter.len()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			GetOrRegisterThisMeter("foo", r)
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 100; i++ {
+		GetOrRegisterThisMeter("foo", r)
+	}
+	if arbiter.len() != l+1 {
+		t.Errorf("arbiter.meters: %d != %d\n", l+1, arbiter.len())
+	}
+}
+
 func TestGetOrRegisterThisMeter(t *testing.T) {
 	r := NewRegistry()
 	NewRegisteredThisMeter("foo", r).Mark(47)
@@ -22,18 +166,122 @@ func TestGetOrRegisterThisMeter(t *testing.T) {
 }
 
 func TestMeterDecay(t *testing.T) {
-	ma := meterArbiter{
-		ticker: time.NewTicker(time.Millisecond),
-		meters: make(map[*StandardThisMeter]struct{}),
-	}
+	ma := newMeterArbiter(defaultTickInterval, 1)
+	clock := newManualClock()
 	m := newStandardThisMeter()
-	ma.meters[m] = struct{}{}
-	go ma.tick()
+	m.clock, m.startTime = clock, clock.Now()
+	ma.add(m)
+	clock.Add(time.Second)
 	m.Mark(1)
-	rateMean := m.RateMean()
-	time.Sleep(100 * time.Millisecond)
-	if m.RateMean() >= rateMean {
-		t.Error("m.RateMean() didn't decrease")
+	if rate := m.RateMean(); 1 != rate {
+		t.Errorf("m.RateMean(): 1 != %v\n", rate)
+	}
+	clock.Add(time.Second)
+	ma.tickMeters()
+	if rate := m.RateMean(); 0.5 != rate {
+		t.Errorf("m.RateMean(): 0.5 != %v\n", rate)
+	}
+}
+
+type panickingEWMA struct{ NilEWMA }
+
+func (panickingEWMA) Tick() { panic("tick") }
+
+func TestRegisterArbiterMetrics(t *testing.T) {
+	r := NewRegistry()
+	RegisterArbiterMetrics(r)
+	defer func() {
+		arbiters.Lock()
+		arbiters.tickDuration = nil
+		arbiters.Unlock()
+		r.UnregisterAll()
+	}()
+	g := r.Get("go-metrics.arbiter.meters").(Gauge)
+	n := g.Value()
+	var ms []ThisMeter
+	for i := 0; i < 3; i++ {
+		ms = append(ms, NewThisMeter())
+	}
+	ms = append(ms, NewThisMeterWithInterval(time.Second))
+	if v := g.Value(); n+4 != v {
+		t.Errorf("g.Value(): %v != %v\n", n+4, v)
+	}
+	for _, m := range ms {
+		m.Stop()
+	}
+	if v := g.Value(); n != v {
+		t.Errorf("g.Value(): %v != %v\n", n, v)
+	}
+	tm := r.Get("go-metrics.arbiter.tick_duration").(Timer)
+	count := tm.Count()
+	arbiter.tickMeters()
+	if c := tm.Count(); count+1 > c {
+		t.Errorf("tm.Count(): %v > %v\n", count+1, c)
+	}
+}
+
+func TestMeterArbiterRecovers(t *testing.T) {
+	l := make(chanLogger, 1)
+	ErrorLogger = l
+	defer func() { ErrorLogger = nil }()
+	ma := newMeterArbiter(defaultTickInterval, 1)
+	bad, good := newStandardThisMeter(), newStandardThisMeter()
+	bad.a1 = panickingEWMA{}
+	ma.add(bad)
+	ma.add(good)
+	good.Mark(5)
+	if !ma.tickMeters() {
+		t.Fatal("ma.tickMeters(): false")
+	}
+	if rate := good.Rate1(); 1 != rate {
+		t.Errorf("good.Rate1(): 1 != %v\n", rate)
+	}
+	select {
+	case line := <-l:
+		if !strings.Contains(line, "recovered from panic") {
+			t.Errorf("ErrorLogger: %q\n", line)
+		}
+	default:
+		t.Error("ErrorLogger: nothing logged")
+	}
+	bad.Mark(1)
+	if count := bad.Snapshot().Count(); 1 != count { // bad's lock was released.
+		t.Errorf("bad.Snapshot().Count(): 1 != %v\n", count)
+	}
+}
+
+func TestMeterWithInterval(t *testing.T) {
+	m := newStandardThisMeterWithInterval(time.Second)
+	m.Mark(3)
+	m.tick()
+	if rate := m.Rate1(); 3.0 != rate {
+		t.Errorf("m.Rate1(): 3.0 != %v\n", rate)
+	}
+	for i := 0; i < 60; i++ {
+		m.tick()
+	}
+	if rate := m.Rate1(); math.Abs(3.0*math.Exp(-1)-rate) > 1e-9 {
+		t.Errorf("m.Rate1(): %v != %v\n", 3.0*math.Exp(-1), rate)
+	}
+}
+
+func TestMeterWithIntervalSharesArbiter(t *testing.T) {
+	m1 := NewThisMeterWithInterval(time.Second).(*StandardThisMeter)
+	m2 := NewThisMeterWithInterval(time.Second).(*StandardThisMeter)
+	if m1.arbiter != m2.arbiter {
+		t.Fatal("meters with the same interval use different arbiters")
+	}
+	if m1.arbiter == &arbiter {
+		t.Fatal("meter with a custom interval uses the default arbiter")
+	}
+	if m := NewThisMeter().(*StandardThisMeter); m.arbiter != &arbiter {
+		t.Fatal("meter with the default interval doesn't use the default arbiter")
+	}
+	l := m1.arbiter.len()
+	m1.Stop()
+	m2.Stop()
+	if m1.arbiter.len() != l-2 {
+		t.Errorf("arbiter.meters: %d != %d\n", l-2, m1.arbiter.len())
 	}
 }
 
@@ -46,14 +294,450 @@ func TestMeterNonzero(t *testing.T) {
 }
 
 func TestMeterStop(t *testing.T) {
-	l := len(arbiter.meters)
+	l := arbiter.len()
 	m := NewThisMeter()
-	if len(arbiter.meters) != l+1 {
-		t.Errorf("arbiter.meters: %d != %d\n", l+1, len(arbiter.meters))
+	if arbiter.len() != l+1 {
+		t.Errorf("arbiter.meters: %d != %d\n", l+1, arbiter.len())
+	}
+	m.Stop()
+	if arbiter.len() != l {
+		t.Errorf("arbiter.meters: %d != %d\n", l, arbiter.len())
+	}
+}
+
+// arbiterGoroutines counts the goroutines ticking meter arbiters.  Counting
+// every goroutine instead is thrown off by those of other tests exiting.
+func arbiterGoroutines() int {
+	buf := make([]byte, 1<<20)
+	return strings.Count(string(buf[:runtime.Stack(buf, true)]), "(*meterArbiter).tick(")
+}
+
+// waitArbiterGoroutines waits up to a second for there to be n goroutines
+// ticking meter arbiters.
+func waitArbiterGoroutines(t *testing.T, n int) {
+	for i := 0; arbiterGoroutines() != n; i++ {
+		if 100 == i {
+			t.Fatalf("arbiterGoroutines(): %d != %d\n", n, arbiterGoroutines())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMeterArbiterShards(t *testing.T) {
+	ma := newMeterArbiter(defaultTickInterval, 4)
+	meters := make([]*StandardThisMeter, parallelTickMeters)
+	for i := range meters {
+		meters[i] = newStandardThisMeter()
+		meters[i].Mark(5)
+		ma.add(meters[i])
+	}
+	for i, s := range ma.shards {
+		if n := len(s.meters); parallelTickMeters/4 != n {
+			t.Errorf("len(ma.shards[%d].meters): %d != %d\n", i, parallelTickMeters/4, n)
+		}
+	}
+	ma.tickMeters()
+	for i, m := range meters {
+		if rate := m.Rate1(); 1 != rate {
+			t.Fatalf("meters[%d].Rate1(): 1 != %v\n", i, rate)
+		}
+	}
+	m := meters[len(meters)-1]
+	ma.remove(m)
+	if ma.has(m) {
+		t.Error("ma.has(m) after ma.remove(m)")
+	}
+	if n := ma.len(); len(meters)-1 != n {
+		t.Errorf("ma.len(): %d != %d\n", len(meters)-1, n)
+	}
+}
+
+func TestMeterArbiterStops(t *testing.T) {
+	const d = 3 * time.Millisecond
+	baseline := arbiterGoroutines()
+	ms := []ThisMeter{
+		NewThisMeterWithInterval(d),
+		NewThisMeterWithInterval(d),
+		NewThisMeterWithInterval(d),
+	}
+	waitArbiterGoroutines(t, baseline+1)
+	for _, m := range ms {
+		m.Stop()
+	}
+	waitArbiterGoroutines(t, baseline)
+	arbiters.Lock()
+	_, ok := arbiters.m[d]
+	arbiters.Unlock()
+	if ok {
+		t.Fatal("arbiter still in arbiters after its meters were stopped")
+	}
+
+	m := NewThisMeterWithInterval(d)
+	defer m.Stop()
+	m.Mark(1)
+	time.Sleep(50 * time.Millisecond)
+	if 0 == m.Rate1() {
+		t.Error("restarted arbiter didn't tick the meter")
+	}
+}
+
+func TestMeterNonPositiveInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		m := NewThisMeterWithInterval(d).(*StandardThisMeter)
+		if &arbiter != m.arbiter {
+			t.Errorf("NewThisMeterWithInterval(%v): not ticked by the default arbiter\n", d)
+		}
+		m.Stop()
+	}
+	m := NewThisMeterWithConfig(ThisMeterConfig{TickInterval: -time.Second}).(*StandardThisMeter)
+	defer m.Stop()
+	if &arbiter != m.arbiter {
+		t.Error("NewThisMeterWithConfig(): not ticked by the default arbiter")
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	m := NewRateMeter()
+	defer m.Stop()
+	m.Inc(3)
+	m.(*StandardRateMeter).tick()
+	if rate := m.Rate1(); 0.6 != rate {
+		t.Errorf("m.Rate1(): 0.6 != %v\n", rate)
+	}
+	if rate := m.Rate5(); 0 == rate {
+		t.Error("m.Rate5() is zero")
+	}
+	if rate := m.Rate15(); 0 == rate {
+		t.Error("m.Rate15() is zero")
+	}
+	if rate := m.RateMean(); 0 == rate {
+		t.Error("m.RateMean() is zero")
+	}
+	m.Dec(1)
+	if count := m.Count(); 2 != count {
+		t.Errorf("m.Count(): 2 != %v\n", count)
+	}
+	snapshot := m.Snapshot()
+	m.Clear()
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	if count := snapshot.Count(); 2 != count {
+		t.Errorf("snapshot.Count(): 2 != %v\n", count)
+	}
+	if rate := snapshot.Rate1(); 0.6 != rate {
+		t.Errorf("snapshot.Rate1(): 0.6 != %v\n", rate)
+	}
+}
+
+func TestGetOrRegisterRateMeter(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	defer r.UnregisterAll()
+	NewRegisteredRateMeter("foo", r).Mark(47)
+	m := GetOrRegisterRateMeter("foo", r)
+	if 47 != m.Count() {
+		t.Fatal(m)
+	}
+	if _, ok := r.Snapshot()["foo"].(*RateMeterSnapshot); !ok {
+		t.Fatal(r.Snapshot()["foo"])
+	}
+}
+
+func TestMeterArbiterPaused(t *testing.T) {
+	const d = 2 * time.Millisecond
+	m := NewThisMeterWithInterval(d)
+	defer m.Stop()
+	SetArbiterPaused(true)
+	defer SetArbiterPaused(false)
+	m.Mark(3)
+	time.Sleep(20 * time.Millisecond)
+	if rate := m.Rate1(); 0 != rate {
+		t.Errorf("paused m.Rate1(): 0 != %v\n", rate)
+	}
+	if count := m.Count(); 3 != count {
+		t.Errorf("paused m.Count(): 3 != %v\n", count)
+	}
+	m.(*StandardThisMeter).tick()
+	rate := m.Rate1()
+	if 0 == rate {
+		t.Error("m.Rate1() didn't change on a manual tick")
+	}
+
+	// Arbiters created while paused start out paused.
+	m2 := NewThisMeterWithInterval(7 * time.Millisecond)
+	defer m2.Stop()
+	m2.Mark(1)
+	time.Sleep(20 * time.Millisecond)
+	if r := m.Rate1(); rate != r {
+		t.Errorf("paused m.Rate1(): %v != %v\n", rate, r)
+	}
+	if r := m2.Rate1(); 0 != r {
+		t.Errorf("paused m2.Rate1(): 0 != %v\n", r)
+	}
+
+	SetArbiterPaused(false)
+	time.Sleep(20 * time.Millisecond)
+	if r := m.Rate1(); rate == r {
+		t.Error("m.Rate1() didn't change after resuming")
+	}
+}
+
+func TestMeterClear(t *testing.T) {
+	m := newStandardThisMeter()
+	m.Mark(3)
+	m.tick()
+	m.Mark(2)
+	m.Clear(true)
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	if rate := m.Rate1(); 0 != rate {
+		t.Errorf("m.Rate1(): 0 != %v\n", rate)
+	}
+	if rate := m.RateMean(); 0 != rate {
+		t.Errorf("m.RateMean(): 0 != %v\n", rate)
+	}
+	m.Mark(1)
+	m.tick()
+	if rate := m.Rate1(); 0.2 != rate {
+		t.Errorf("m.Rate1(): 0.2 != %v\n", rate)
+	}
+}
+
+func TestMeterClearKeepingRates(t *testing.T) {
+	m := newStandardThisMeter()
+	m.Mark(5)
+	m.tick()
+	m.Mark(2)
+	m.Clear(false)
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	if rate := m.Rate1(); 1 != rate {
+		t.Errorf("m.Rate1(): 1 != %v\n", rate)
+	}
+	if rate := m.RateMean(); 0 != rate {
+		t.Errorf("m.RateMean(): 0 != %v\n", rate)
+	}
+	m.tick()
+	if rate := m.Rate1(); 1 <= rate || 0 == rate {
+		t.Errorf("m.Rate1(): 0 < %v < 1\n", rate)
+	}
+}
+
+func TestMeterMarkBatch(t *testing.T) {
+	m := newStandardThisMeter()
+	m.MarkBatch([]int64{1, 2, 3})
+	if count := m.Count(); 6 != count {
+		t.Errorf("m.Count(): 6 != %v\n", count)
+	}
+	m.tick()
+	if rate := m.Rate1(); 1.2 != rate {
+		t.Errorf("m.Rate1(): 1.2 != %v\n", rate)
 	}
 	m.Stop()
-	if len(arbiter.meters) != l {
-		t.Errorf("arbiter.meters: %d != %d\n", l, len(arbiter.meters))
+	m.MarkBatch([]int64{4})
+	if count := m.Count(); 6 != count {
+		t.Errorf("m.Count(): 6 != %v\n", count)
+	}
+}
+
+func TestMeterNegativeMark(t *testing.T) {
+	m := newStandardThisMeter()
+	m.Mark(3)
+	m.Mark(-1)
+	if count := m.Count(); 2 != count {
+		t.Errorf("m.Count(): 2 != %v\n", count)
+	}
+	m.tick()
+	if rate := m.Rate1(); 0.4 != rate {
+		t.Errorf("m.Rate1(): 0.4 != %v\n", rate)
+	}
+	m.Mark(-5)
+	if count := m.Count(); -3 != count {
+		t.Errorf("m.Count(): -3 != %v\n", count)
+	}
+}
+
+func TestMeterRateMeanWithoutTick(t *testing.T) {
+	m := newStandardThisMeter()
+	m.Mark(1)
+	if rate := m.RateMean(); rate <= 0 {
+		t.Errorf("m.RateMean(): 0 >= %v\n", rate)
+	}
+	if count := m.Snapshot().Count(); 1 != count {
+		t.Errorf("m.Snapshot().Count(): 1 != %v\n", count)
+	}
+}
+
+func TestMeterMeanWindow(t *testing.T) {
+	clock := newManualClock()
+	m := NewThisMeterWithMeanWindow(time.Hour).(*StandardThisMeter)
+	defer m.Stop()
+	m.clock, m.startTime = clock, clock.Now()
+	for i := 0; i < 24*30; i++ { // a month of 100 events per second
+		m.Mark(360000)
+		clock.Add(time.Hour)
+		m.tick()
+	}
+	if rate := m.RateMean(); 100 != rate {
+		t.Errorf("m.RateMean(): 100 != %v\n", rate)
+	}
+	for i := 0; i < 2; i++ { // then two hours of 10 events per second
+		m.Mark(36000)
+		clock.Add(time.Hour)
+		m.tick()
+	}
+	if rate := m.RateMean(); 10 != rate {
+		t.Errorf("m.RateMean(): 10 != %v\n", rate)
+	}
+	if count := m.Count(); 24*30*360000+2*36000 != count {
+		t.Errorf("m.Count(): %v != %v\n", 24*30*360000+2*36000, count)
+	}
+	if rate := m.Rate1(); 0 == rate {
+		t.Errorf("m.Rate1(): 0 == %v\n", rate)
+	}
+}
+
+func TestMeterWarmup(t *testing.T) {
+	clock := newManualClock()
+	m := NewThisMeterWithWarmup(10 * time.Second).(*StandardThisMeter)
+	defer m.Stop()
+	m.clock, m.startTime = clock, clock.Now()
+	m.Mark(10)
+	clock.Add(10*time.Second - time.Nanosecond)
+	if rate := m.RateMean(); 0 != rate {
+		t.Errorf("m.RateMean(): 0 != %v\n", rate)
+	}
+	if rate := m.Snapshot().RateMean(); 0 != rate {
+		t.Errorf("m.Snapshot().RateMean(): 0 != %v\n", rate)
+	}
+	clock.Add(time.Nanosecond)
+	if rate := m.RateMean(); 0 == rate {
+		t.Errorf("m.RateMean(): 0 == %v\n", rate)
+	}
+	m.Clear(true)
+	m.Mark(10)
+	if rate := m.RateMean(); 0 != rate {
+		t.Errorf("m.RateMean(): 0 != %v\n", rate)
+	}
+}
+
+func TestMeterWarmupRates(t *testing.T) {
+	clock := newManualClock()
+	m := NewThisMeterWithConfig(ThisMeterConfig{WarmupRates: true}).(*StandardThisMeter)
+	defer m.Stop()
+	m.clock, m.startTime = clock, clock.Now()
+	m.Mark(60)
+	m.tick()
+	clock.Add(time.Minute - time.Nanosecond)
+	if rate := m.Rate1(); 0 != rate {
+		t.Errorf("m.Rate1(): 0 != %v\n", rate)
+	}
+	if rate := m.RateMean(); 0 == rate {
+		t.Errorf("m.RateMean(): 0 == %v\n", rate)
+	}
+	clock.Add(time.Nanosecond)
+	if rate := m.Rate1(); 0 == rate {
+		t.Errorf("m.Rate1(): 0 == %v\n", rate)
+	}
+	if rate := m.Rate5(); 0 != rate {
+		t.Errorf("m.Rate5(): 0 != %v\n", rate)
+	}
+	clock.Add(14 * time.Minute)
+	if snapshot := m.Snapshot(); 0 == snapshot.Rate5() || 0 == snapshot.Rate15() {
+		t.Errorf("m.Snapshot(): %v\n", snapshot)
+	}
+}
+
+func TestMeterConfigCombined(t *testing.T) {
+	clock := newManualClock()
+	m := NewThisMeterWithConfig(ThisMeterConfig{
+		TickInterval: time.Second,
+		Warmup:       10 * time.Second,
+		MeanWindow:   time.Hour,
+	}).(*StandardThisMeter)
+	defer m.Stop()
+	if time.Second != m.arbiter.interval {
+		t.Errorf("m.arbiter.interval: %v != %v\n", time.Second, m.arbiter.interval)
+	}
+	m.clock, m.startTime = clock, clock.Now()
+	m.Mark(10)
+	clock.Add(5 * time.Second)
+	if rate := m.RateMean(); 0 != rate {
+		t.Errorf("m.RateMean() warming up: 0 != %v\n", rate)
+	}
+	for i := 0; i < 24; i++ { // a day of 100 events per second
+		m.Mark(360000)
+		clock.Add(time.Hour)
+		m.tick()
+	}
+	m.Mark(36000) // then an hour of 10
+	clock.Add(time.Hour)
+	m.tick()
+	if rate := m.RateMean(); 10 != rate {
+		t.Errorf("m.RateMean(): 10 != %v\n", rate)
+	}
+}
+
+func TestMeterRateInstant(t *testing.T) {
+	clock := newManualClock()
+	m := newStandardThisMeter()
+	m.clock, m.startTime = clock, clock.Now()
+	m.Mark(10)
+	clock.Add(5 * time.Second)
+	if rate := m.RateInstant(); 2 != rate {
+		t.Errorf("m.RateInstant(): 2 != %v\n", rate)
+	}
+	m.tick()
+	if rate := m.RateInstant(); 0 != rate {
+		t.Errorf("m.RateInstant(): 0 != %v\n", rate)
+	}
+	m.Mark(100) // a burst between ticks
+	clock.Add(time.Second)
+	if rate := m.RateInstant(); 100 != rate {
+		t.Errorf("m.RateInstant(): 100 != %v\n", rate)
+	}
+	if rate := m.Rate1(); 2 != rate {
+		t.Errorf("m.Rate1(): 2 != %v\n", rate)
+	}
+	m.Clear(true)
+	clock.Add(time.Second)
+	if rate := m.RateInstant(); 0 != rate {
+		t.Errorf("m.RateInstant(): 0 != %v\n", rate)
+	}
+}
+
+func TestMeterRateMeanSinceLastRead(t *testing.T) {
+	clock := newManualClock()
+	m := newStandardThisMeter()
+	m.clock, m.startTime = clock, clock.Now()
+	m.Mark(10)
+	clock.Add(10 * time.Second)
+	if rate := m.RateMeanSinceLastRead(); 1 != rate {
+		t.Errorf("m.RateMeanSinceLastRead(): 1 != %v\n", rate)
+	}
+	m.Mark(30)
+	clock.Add(5 * time.Second)
+	if rate := m.RateMeanSinceLastRead(); 6 != rate {
+		t.Errorf("m.RateMeanSinceLastRead(): 6 != %v\n", rate)
+	}
+	if rate := m.RateMean(); 40.0/15 != rate {
+		t.Errorf("m.RateMean(): %v != %v\n", 40.0/15, rate)
+	}
+	m.Clear(true)
+	m.Mark(4)
+	clock.Add(2 * time.Second)
+	if rate := m.RateMeanSinceLastRead(); 2 != rate {
+		t.Errorf("m.RateMeanSinceLastRead(): 2 != %v\n", rate)
+	}
+	m.Mark(3)
+	if rate := m.RateMeanSinceLastRead(); 0 != rate {
+		t.Errorf("m.RateMeanSinceLastRead(): 0 != %v\n", rate)
+	}
+	clock.Add(time.Second)
+	if rate := m.RateMeanSinceLastRead(); 3 != rate {
+		t.Errorf("m.RateMeanSinceLastRead(): 3 != %v\n", rate)
 	}
 }
 
@@ -65,6 +749,74 @@ func TestMeterSnapshot(t *testing.T) {
 	}
 }
 
+func TestMeterSnapshotPooled(t *testing.T) {
+	m := NewThisMeter().(*StandardThisMeter)
+	m.Mark(1)
+	snapshot := m.SnapshotPooled()
+	m.Mark(1)
+	other := m.SnapshotPooled()
+	if count := snapshot.Count(); 1 != count {
+		t.Errorf("snapshot.Count(): 1 != %v\n", count)
+	}
+	if count := other.Count(); 2 != count {
+		t.Errorf("other.Count(): 2 != %v\n", count)
+	}
+	snapshot.Release()
+	other.Release()
+}
+
+func TestMeterSnapshotReleaseNotPooled(t *testing.T) {
+	m := NewThisMeter().(*StandardThisMeter)
+	m.Mark(1)
+	snapshot := m.Snapshot().(*ThisMeterSnapshot)
+	snapshot.Release()
+	m.Mark(1)
+	for i := 0; i < 10; i++ {
+		if pooled := m.SnapshotPooled(); pooled == snapshot {
+			t.Fatal("m.SnapshotPooled() reused a snapshot that wasn't pooled")
+		}
+	}
+	if count := snapshot.Count(); 1 != count {
+		t.Errorf("snapshot.Count(): 1 != %v\n", count)
+	}
+}
+
+func TestMeterSnapshotReleaseTwice(t *testing.T) {
+	m := NewThisMeter().(*StandardThisMeter)
+	snapshot := m.SnapshotPooled()
+	snapshot.Release()
+	func() {
+		defer func() {
+			if nil == recover() {
+				t.Error("snapshot.Release() twice didn't panic")
+			}
+		}()
+		snapshot.Release()
+	}()
+	if a, b := m.SnapshotPooled(), m.SnapshotPooled(); a == b {
+		t.Fatal("m.SnapshotPooled() returned the same snapshot twice")
+	}
+}
+
+func TestMeterFlushSnapshot(t *testing.T) {
+	m := NewThisMeter()
+	defer m.Stop()
+	m.Mark(3)
+	snapshot, release := flushSnapshot(m)
+	if _, ok := snapshot.(*ThisMeterSnapshot); !ok {
+		t.Fatalf("flushSnapshot(): %T\n", snapshot)
+	}
+	if count := snapshot.Count(); 3 != count {
+		t.Errorf("snapshot.Count(): 3 != %v\n", count)
+	}
+	release()
+	other, release := flushSnapshot(NilThisMeter{})
+	if _, ok := other.(NilThisMeter); !ok {
+		t.Errorf("flushSnapshot(NilThisMeter{}): %T\n", other)
+	}
+	release()
+}
+
 func TestMeterZero(t *testing.T) {
 	m := NewThisMeter()
 	if count := m.Count(); 0 != count {
@@ -4,6 +4,9 @@ package metrics
 // Exposing meter functions/interfaces to replace with counter functionality
 ////////////////////////////////////////////////////////////////////////////
 
+// Meter is a Counter.  NewMeter, GetOrRegisterMeter and NewRegisteredMeter
+// return plain counters whose rate methods always return zero; use
+// NewRateMeter and friends for a Meter with moving-average rates.
 type Meter interface {
 	Counter
 }
@@ -31,3 +34,83 @@ type NilMeter struct {
 type StandardMeter struct {
 	StandardCounter
 }
+
+// NewRateMeter constructs a Meter backed by a StandardThisMeter, so unlike
+// NewMeter its Rate1, Rate5, Rate15 and RateMean report moving averages.
+// Be sure to call Stop() once the meter is of no use to allow for garbage collection.
+func NewRateMeter() Meter {
+	if UseNilMetrics || UseNilMeters {
+		return NilMeter{}
+	}
+	return &StandardRateMeter{NewThisMeter().(*StandardThisMeter)}
+}
+
+// GetOrRegisterRateMeter returns an existing Meter or constructs and
+// registers a new StandardRateMeter.
+// Be sure to unregister the meter from the registry once it is of no use to
+// allow for garbage collection.
+func GetOrRegisterRateMeter(name string, r Registry) Meter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewRateMeter).(Meter)
+}
+
+// NewRegisteredRateMeter constructs and registers a new StandardRateMeter.
+// Be sure to unregister the meter from the registry once it is of no use to
+// allow for garbage collection.
+func NewRegisteredRateMeter(name string, r Registry) Meter {
+	c := NewRateMeter()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardRateMeter adapts a StandardThisMeter to the Meter interface.
+// Registries and exporters see it as a Counter.
+type StandardRateMeter struct {
+	*StandardThisMeter
+}
+
+// Clear sets the count to zero and restarts the mean rate.  The moving
+// averages are left to decay, as by the StandardThisMeter's Clear(false).
+func (m *StandardRateMeter) Clear() { m.StandardThisMeter.Clear(false) }
+
+// Dec records the occurrence of -i events.
+func (m *StandardRateMeter) Dec(i int64) { m.Mark(-i) }
+
+// Inc records the occurrence of i events.
+func (m *StandardRateMeter) Inc(i int64) { m.Mark(i) }
+
+// Snapshot returns a read-only copy of the meter.
+func (m *StandardRateMeter) Snapshot() Counter {
+	s := m.StandardThisMeter.Snapshot().(*ThisMeterSnapshot)
+	return &RateMeterSnapshot{CounterSnapshot(s.count), s}
+}
+
+// RateMeterSnapshot is a read-only copy of a StandardRateMeter.
+type RateMeterSnapshot struct {
+	CounterSnapshot
+	meter *ThisMeterSnapshot
+}
+
+// Rate1 returns the one-minute moving average rate of events per second at the
+// time the snapshot was taken.
+func (m *RateMeterSnapshot) Rate1() float64 { return m.meter.Rate1() }
+
+// Rate5 returns the five-minute moving average rate of events per second at
+// the time the snapshot was taken.
+func (m *RateMeterSnapshot) Rate5() float64 { return m.meter.Rate5() }
+
+// Rate15 returns the fifteen-minute moving average rate of events per second
+// at the time the snapshot was taken.
+func (m *RateMeterSnapshot) Rate15() float64 { return m.meter.Rate15() }
+
+// RateMean returns the meter's mean rate of events per second at the time the
+// snapshot was taken.
+func (m *RateMeterSnapshot) RateMean() float64 { return m.meter.RateMean() }
+
+// Snapshot returns the snapshot.
+func (m *RateMeterSnapshot) Snapshot() Counter { return m }
@@ -11,3 +11,17 @@ package metrics
 // This global kill-switch helps quantify the observer effect and makes
 // for less cluttered pprof profiles.
 var UseNilMetrics bool = false
+
+// UseNilCounters, UseNilGauges, UseNilHealthchecks, UseNilHistograms,
+// UseNilMeters and UseNilTimers are checked along with UseNilMetrics by the
+// constructor functions for their kind of metric, so expensive kinds can be
+// stubbed out while cheap ones are kept.  Like UseNilMetrics, they only
+// affect metrics constructed after they're set.
+var (
+	UseNilCounters     bool = false
+	UseNilGauges       bool = false
+	UseNilHealthchecks bool = false
+	UseNilHistograms   bool = false
+	UseNilMeters       bool = false
+	UseNilTimers       bool = false
+)
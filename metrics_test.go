@@ -107,6 +107,37 @@ func BenchmarkMetrics(b *testing.B) {
 	wgW.Wait()
 }
 
+func TestUseNilHistograms(t *testing.T) {
+	UseNilHistograms = true
+	defer func() { UseNilHistograms = false }()
+	if _, ok := NewHistogram(NewUniformSample(100)).(NilHistogram); !ok {
+		t.Error("NewHistogram didn't return a NilHistogram")
+	}
+	if _, ok := NewCounter().(*StandardCounter); !ok {
+		t.Error("NewCounter didn't return a *StandardCounter")
+	}
+	tm := NewTimer()
+	defer tm.Stop()
+	tm.Update(47)
+	if count := tm.Count(); 1 != count {
+		t.Errorf("tm.Count(): 1 != %v\n", count)
+	}
+}
+
+func TestUseNilTimers(t *testing.T) {
+	UseNilTimers = true
+	defer func() { UseNilTimers = false }()
+	if _, ok := NewTimer().(NilTimer); !ok {
+		t.Error("NewTimer didn't return a NilTimer")
+	}
+	if _, ok := NewResettingTimer().(NilResettingTimer); !ok {
+		t.Error("NewResettingTimer didn't return a NilResettingTimer")
+	}
+	if _, ok := NewHistogram(NewUniformSample(100)).(*StandardHistogram); !ok {
+		t.Error("NewHistogram didn't return a *StandardHistogram")
+	}
+}
+
 func Example() {
 	c := NewCounter()
 	Register("money", c)
@@ -0,0 +1,59 @@
+package metrics
+
+// NewMultiMeter constructs a new MultiMeter marking each of the given meters,
+// so that one call site can record an event into, say, both a global and a
+// per-endpoint meter.
+func NewMultiMeter(meters ...ThisMeter) ThisMeter {
+	if UseNilMetrics || UseNilMeters {
+		return NilThisMeter{}
+	}
+	return &MultiMeter{meters: meters}
+}
+
+// MultiMeter is a ThisMeter that forwards Mark and Stop to all its meters and
+// reads counts and rates from the first.
+type MultiMeter struct {
+	meters []ThisMeter
+}
+
+// Count returns the number of events recorded by the first meter.
+func (m *MultiMeter) Count() int64 { return m.first().Count() }
+
+// Mark records the occurance of n events in every meter.
+func (m *MultiMeter) Mark(n int64) {
+	for _, meter := range m.meters {
+		meter.Mark(n)
+	}
+}
+
+// Rate1 returns the first meter's one-minute moving average rate of events per
+// second.
+func (m *MultiMeter) Rate1() float64 { return m.first().Rate1() }
+
+// Rate5 returns the first meter's five-minute moving average rate of events
+// per second.
+func (m *MultiMeter) Rate5() float64 { return m.first().Rate5() }
+
+// Rate15 returns the first meter's fifteen-minute moving average rate of
+// events per second.
+func (m *MultiMeter) Rate15() float64 { return m.first().Rate15() }
+
+// RateMean returns the first meter's mean rate of events per second.
+func (m *MultiMeter) RateMean() float64 { return m.first().RateMean() }
+
+// Snapshot returns a read-only copy of the first meter.
+func (m *MultiMeter) Snapshot() ThisMeter { return m.first().Snapshot() }
+
+// Stop stops every meter.
+func (m *MultiMeter) Stop() {
+	for _, meter := range m.meters {
+		meter.Stop()
+	}
+}
+
+func (m *MultiMeter) first() ThisMeter {
+	if 0 == len(m.meters) {
+		return NilThisMeter{}
+	}
+	return m.meters[0]
+}
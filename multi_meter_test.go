@@ -0,0 +1,38 @@
+package metrics
+
+import "testing"
+
+func TestMultiMeter(t *testing.T) {
+	global, child := NewThisMeter(), NewThisMeter()
+	m := NewMultiMeter(global, child)
+	m.Mark(3)
+	global.Mark(1)
+	if count := child.Count(); 3 != count {
+		t.Errorf("child.Count(): 3 != %v\n", count)
+	}
+	if count := global.Count(); 4 != count {
+		t.Errorf("global.Count(): 4 != %v\n", count)
+	}
+	if count := m.Count(); 4 != count {
+		t.Errorf("m.Count(): 4 != %v\n", count)
+	}
+	if count := m.Snapshot().Count(); 4 != count {
+		t.Errorf("m.Snapshot().Count(): 4 != %v\n", count)
+	}
+	m.Stop()
+	m.Mark(1)
+	if count := global.Count(); 4 != count {
+		t.Errorf("global.Count(): 4 != %v\n", count)
+	}
+	if count := child.Count(); 3 != count {
+		t.Errorf("child.Count(): 3 != %v\n", count)
+	}
+}
+
+func TestMultiMeterEmpty(t *testing.T) {
+	m := NewMultiMeter()
+	m.Mark(1)
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+}
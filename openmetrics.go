@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenMetricsContentType is the Content-Type of the text WriteOpenMetrics
+// writes, for serving it over HTTP.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteOpenMetrics writes the metrics in the given registry, read from a
+// single Snapshot of it, to the given io.Writer in the OpenMetrics 1.0.0 text
+// format, terminated by "# EOF".  Counters are written as counters with the
+// "_total" suffix, gauges as gauges, and histograms and timers as summaries.
+// Meters are written as counters and meters and timers additionally write
+// their rates as a "_rate" gauge labelled by window.  Timer summaries are in
+// seconds.  Healthchecks and resetting timers aren't written.
+//
+// Names encoded by EncodeTaggedName are written as their base name with their
+// tags as labels.  The help text and unit set by DescribableRegistry.Describe,
+// under the base name for tagged metrics, are written as the HELP and UNIT of
+// the family and the unit is a suffix of its name, except that timers are
+// always in seconds.  Metric and label names are sanitized to the OpenMetrics
+// charset by replacing every invalid character with an underscore.  Should
+// metrics of different types sanitize to the same name, only the first in
+// lexical order is written.
+func WriteOpenMetrics(r Registry, w io.Writer) {
+	writeOpenMetrics(w, r, SnapshotRegistry(r))
+}
+
+// writeOpenMetrics writes the metrics in a snapshot of r, described by r, to
+// the given io.Writer in the OpenMetrics text format.
+func writeOpenMetrics(w io.Writer, r Registry, snapshot map[string]interface{}) {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make(map[string]*openMetricsFamily)
+	add := func(name, typ, unit, help string) *openMetricsFamily {
+		f, ok := families[name]
+		if !ok {
+			f = &openMetricsFamily{name: name, typ: typ, unit: unit, help: help}
+			families[name] = f
+		} else if typ != f.typ {
+			return nil
+		}
+		return f
+	}
+
+	for _, fullName := range names {
+		name, tags := DecodeTaggedName(fullName)
+		help, unit, ok := RegistryDescription(r, name)
+		if !ok {
+			help, unit, _ = RegistryDescription(r, fullName)
+		}
+		fqName, labels := openMetricsName(name), openMetricsLabels(tags)
+		switch snapshot[fullName].(type) {
+		case Timer:
+			unit = "seconds"
+		case Counter, FloatCounter, ThisMeter:
+			fqName = strings.TrimSuffix(fqName, "_total")
+		}
+		if "" != unit {
+			unit = openMetricsName(unit)
+			if !strings.HasSuffix(fqName, "_"+unit) {
+				fqName += "_" + unit
+			}
+		}
+
+		switch metric := snapshot[fullName].(type) {
+		case Counter:
+			if f := add(fqName, "counter", unit, help); nil != f {
+				f.sample("_total", labels, "", "", strconv.FormatInt(metric.Count(), 10))
+			}
+		case FloatCounter:
+			if f := add(fqName, "counter", unit, help); nil != f {
+				f.sample("_total", labels, "", "", formatOpenMetricsFloat(metric.Count()))
+			}
+		case Gauge:
+			if f := add(fqName, "gauge", unit, help); nil != f {
+				f.sample("", labels, "", "", strconv.FormatInt(metric.Value(), 10))
+			}
+		case GaugeFloat64:
+			if f := add(fqName, "gauge", unit, help); nil != f {
+				f.sample("", labels, "", "", formatOpenMetricsFloat(metric.Value()))
+			}
+		case Histogram:
+			if f := add(fqName, "summary", unit, help); nil != f {
+				ps := ReportedPercentiles(metric)
+				f.summary(labels, metric.Count(), float64(metric.Sum()), ps, metric.Percentiles(ps), 1)
+			}
+		case ThisMeter:
+			if f := add(fqName, "counter", unit, help); nil != f {
+				f.sample("_total", labels, "", "", strconv.FormatInt(metric.Count(), 10))
+			}
+			if f := add(fqName+"_rate", "gauge", "", help); nil != f {
+				f.rates(labels, metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean())
+			}
+		case Timer:
+			if f := add(fqName, "summary", unit, help); nil != f {
+				ps := ReportedPercentiles(metric)
+				f.summary(labels, metric.Count(), float64(metric.Sum()), ps, metric.Percentiles(ps), float64(time.Second))
+			}
+			rateName := strings.TrimSuffix(fqName, "_"+unit) + "_rate"
+			if f := add(rateName, "gauge", "", help); nil != f {
+				f.rates(labels, metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean())
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(families))
+	for name := range families {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	bw := bufio.NewWriter(w)
+	for _, name := range sorted {
+		families[name].write(bw)
+	}
+	bw.WriteString("# EOF\n")
+	bw.Flush()
+}
+
+// openMetricsFamily is a metric family being written by WriteOpenMetrics,
+// whose samples are buffered so that each family is written contiguously.
+type openMetricsFamily struct {
+	name, typ, unit, help string
+	samples               []string
+}
+
+// rates adds the samples of a "_rate" gauge labelled by window.
+func (f *openMetricsFamily) rates(labels string, rate1, rate5, rate15, rateMean float64) {
+	f.sample("", labels, "window", "1m", formatOpenMetricsFloat(rate1))
+	f.sample("", labels, "window", "5m", formatOpenMetricsFloat(rate5))
+	f.sample("", labels, "window", "15m", formatOpenMetricsFloat(rate15))
+	f.sample("", labels, "window", "mean", formatOpenMetricsFloat(rateMean))
+}
+
+// sample adds a sample named by the family's name and the given suffix with
+// the given labels, already formatted, and an optional extra label.
+func (f *openMetricsFamily) sample(suffix, labels, key, value, v string) {
+	if "" != key {
+		if "" != labels {
+			labels += ","
+		}
+		labels += key + "=\"" + openMetricsEscaper.Replace(value) + "\""
+	}
+	line := f.name + suffix
+	if "" != labels {
+		line += "{" + labels + "}"
+	}
+	f.samples = append(f.samples, line+" "+v)
+}
+
+// summary adds the samples of a summary with the given quantiles, dividing
+// its values by scale.
+func (f *openMetricsFamily) summary(labels string, count int64, sum float64, quantiles, ps []float64, scale float64) {
+	for i, q := range quantiles {
+		f.sample("", labels, "quantile", strconv.FormatFloat(q, 'g', -1, 64), formatOpenMetricsFloat(ps[i]/scale))
+	}
+	f.sample("_sum", labels, "", "", formatOpenMetricsFloat(sum/scale))
+	f.sample("_count", labels, "", "", strconv.FormatInt(count, 10))
+}
+
+// write writes the family's metadata and samples.
+func (f *openMetricsFamily) write(w *bufio.Writer) {
+	w.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+	if "" != f.unit {
+		w.WriteString("# UNIT " + f.name + " " + f.unit + "\n")
+	}
+	if "" != f.help {
+		w.WriteString("# HELP " + f.name + " " + openMetricsEscaper.Replace(f.help) + "\n")
+	}
+	for _, s := range f.samples {
+		w.WriteString(s + "\n")
+	}
+}
+
+// openMetricsEscaper escapes backslashes, double quotes and newlines in HELP
+// text and label values.
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatOpenMetricsFloat formats a float as OpenMetrics expects, including
+// +Inf, -Inf and NaN.
+func formatOpenMetricsFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// openMetricsLabels formats tags as OpenMetrics labels sorted by name,
+// replacing every character which isn't valid in a label name with an
+// underscore.
+func openMetricsLabels(tags map[string]string) string {
+	if 0 == len(tags) {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = openMetricsLabelName(k) + "=\"" + openMetricsEscaper.Replace(tags[k]) + "\""
+	}
+	return strings.Join(labels, ",")
+}
+
+// openMetricsLabelName replaces every character which isn't valid in an
+// OpenMetrics label name with an underscore.
+func openMetricsLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '_' == c || 0 < i && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if 0 == len(b) {
+		return "_"
+	}
+	return string(b)
+}
+
+// openMetricsName replaces every character which isn't valid in an
+// OpenMetrics metric name with an underscore.
+func openMetricsName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '_' == c || ':' == c || 0 < i && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if 0 == len(b) {
+		return "_"
+	}
+	return string(b)
+}
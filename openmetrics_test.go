@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	openMetricsMetadata = regexp.MustCompile(`^# (TYPE|UNIT|HELP) ([a-zA-Z_:][a-zA-Z0-9_:]*) (.*)$`)
+	openMetricsSample   = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(.*)\})? ([+-]?(Inf|NaN|[0-9]*\.?[0-9]+([eE][+-]?[0-9]+)?|[0-9]+\.))$`)
+	openMetricsLabel    = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)="((?:[^"\\\n]|\\[\\"n])*)"(,|$)`)
+	openMetricsEscaped  = regexp.MustCompile(`^(?:[^"\\\n]|\\[\\"n])*$`)
+)
+
+// validateOpenMetrics checks text against the OpenMetrics text format: each
+// line is metadata, a sample or the final "# EOF", every family is written
+// contiguously with its metadata first, and the name of every sample is that
+// of its family with a suffix allowed by its type.
+func validateOpenMetrics(text string) error {
+	if !strings.HasSuffix(text, "# EOF\n") {
+		return errors.New("no trailing # EOF")
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "# EOF\n"), "\n")
+	lines = lines[:len(lines)-1] // after the last newline
+	seen := make(map[string]bool)
+	var family, typ string
+	var sampled bool
+	for _, line := range lines {
+		if m := openMetricsMetadata.FindStringSubmatch(line); nil != m {
+			if m[2] != family {
+				if seen[m[2]] {
+					return errors.New("family written twice: " + line)
+				}
+				seen[m[2]], family, typ, sampled = true, m[2], "", false
+			}
+			if sampled {
+				return errors.New("metadata after samples: " + line)
+			}
+			switch m[1] {
+			case "TYPE":
+				switch m[3] {
+				case "counter", "gauge", "summary", "histogram", "gaugehistogram", "stateset", "info", "unknown":
+				default:
+					return errors.New("unknown type: " + line)
+				}
+				typ = m[3]
+			case "UNIT":
+				if !strings.HasSuffix(m[2], "_"+m[3]) {
+					return errors.New("unit isn't a suffix of the name: " + line)
+				}
+			case "HELP":
+				if !openMetricsEscaped.MatchString(m[3]) {
+					return errors.New("unescaped help: " + line)
+				}
+			}
+			continue
+		}
+		m := openMetricsSample.FindStringSubmatch(line)
+		if nil == m {
+			return errors.New("invalid line: " + line)
+		}
+		var suffixes []string
+		switch typ {
+		case "counter":
+			suffixes = []string{"_total", "_created"}
+		case "summary":
+			suffixes = []string{"", "_sum", "_count", "_created"}
+		default:
+			suffixes = []string{""}
+		}
+		ok := false
+		for _, suffix := range suffixes {
+			ok = ok || family+suffix == m[1]
+		}
+		if !ok {
+			return errors.New("sample outside its family " + family + ": " + line)
+		}
+		names := make(map[string]bool)
+		for labels := m[3]; "" != labels; {
+			l := openMetricsLabel.FindStringSubmatch(labels)
+			if nil == l || names[l[1]] {
+				return errors.New("invalid labels: " + line)
+			}
+			names[l[1]] = true
+			labels = labels[len(l[0]):]
+		}
+		sampled = true
+	}
+	return nil
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	NewRegisteredCounter("requests", r).Inc(3)
+	r.Describe("requests", `Requests "served"`, "")
+	GetOrRegisterTagged("hits", map[string]string{"path": "/a"}, NewCounter(), r).(Counter).Inc(1)
+	GetOrRegisterTagged("hits", map[string]string{"path": "/b\n"}, NewCounter(), r).(Counter).Inc(2)
+	NewRegisteredGauge("queue.depth", r).Update(7)
+	r.Describe("queue.depth", "Jobs waiting", "items")
+	NewRegisteredGaugeFloat64("load", r).Update(0.25)
+	h := NewRegisteredHistogram("sizes", r, NewUniformSample(100))
+	for i := int64(1); i <= 100; i++ {
+		h.Update(i)
+	}
+	r.Describe("sizes", "", "bytes")
+	NewRegisteredThisMeter("events", r).Mark(4)
+	tm := NewRegisteredTimer("latency", r)
+	tm.Update(time.Second)
+	tm.Update(500 * time.Millisecond)
+	r.Register("check", NewHealthcheck(func(Healthcheck) {}))
+
+	var buf bytes.Buffer
+	WriteOpenMetrics(r, &buf)
+	text := buf.String()
+	if err := validateOpenMetrics(text); nil != err {
+		t.Fatalf("%v\n%s", err, text)
+	}
+	for _, want := range []string{
+		"# TYPE requests counter\n# HELP requests Requests \\\"served\\\"\nrequests_total 3\n",
+		"# TYPE hits counter\nhits_total{path=\"/a\"} 1\nhits_total{path=\"/b\\n\"} 2\n",
+		"# TYPE queue_depth_items gauge\n# UNIT queue_depth_items items\n# HELP queue_depth_items Jobs waiting\nqueue_depth_items 7\n",
+		"load 0.25\n",
+		"sizes_bytes{quantile=\"0.5\"} 50.5\n",
+		"sizes_bytes_sum 5050\nsizes_bytes_count 100\n",
+		"events_total 4\n",
+		"# TYPE events_rate gauge\n",
+		"# TYPE latency_seconds summary\n# UNIT latency_seconds seconds\n",
+		"latency_seconds_sum 1.5\nlatency_seconds_count 2\n",
+		"latency_rate{window=\"1m\"} ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q isn't in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "check") {
+		t.Errorf("healthcheck written:\n%s", text)
+	}
+}
+
+func TestWriteOpenMetricsEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	WriteOpenMetrics(NewRegistry(), &buf)
+	if "# EOF\n" != buf.String() {
+		t.Errorf("WriteOpenMetrics(): %q != %q\n", "# EOF\n", buf.String())
+	}
+}
+
+func TestValidateOpenMetrics(t *testing.T) {
+	for _, text := range []string{
+		"foo 1\n",
+		"# TYPE foo counter\nfoo 1\n# EOF\n",
+		"# TYPE foo gauge\nfoo 1\n# HELP foo late\n# EOF\n",
+		"# TYPE foo gauge\nfoo 1\n# TYPE bar gauge\nbar 1\n# TYPE foo gauge\n# EOF\n",
+		"# TYPE foo gauge\n# UNIT foo seconds\nfoo 1\n# EOF\n",
+		"# TYPE foo gauge\nfoo{a=\"1\",a=\"2\"} 1\n# EOF\n",
+		"# TYPE foo gauge\nfoo{a=\"\"\"} 1\n# EOF\n",
+	} {
+		if nil == validateOpenMetrics(text) {
+			t.Errorf("validateOpenMetrics(%q): nil\n", text)
+		}
+	}
+}
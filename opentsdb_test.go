@@ -1,7 +1,9 @@
 package metrics
 
 import (
+	"fmt"
 	"net"
+	"testing"
 	"time"
 )
 
@@ -19,3 +21,39 @@ func ExampleOpenTSDBWithConfig() {
 		DurationUnit:  time.Millisecond,
 	})
 }
+
+// chanLogger sends each line logged to it on a channel, dropping lines while
+// the channel is full.
+type chanLogger chan string
+
+func (l chanLogger) Printf(format string, v ...interface{}) {
+	select {
+	case l <- fmt.Sprintf(format, v...):
+	default:
+	}
+}
+
+func TestOpenTSDBWithConfigLogger(t *testing.T) {
+	ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close() // so that every flush fails to connect.
+	l := make(chanLogger, 1)
+	go OpenTSDBWithConfig(OpenTSDBConfig{
+		Addr:          addr,
+		Registry:      NewRegistry(),
+		FlushInterval: 10 * time.Millisecond,
+		DurationUnit:  time.Millisecond,
+		Logger:        l,
+	})
+	select {
+	case line := <-l:
+		if "" == line {
+			t.Error("line: want != \"\"\n")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing logged")
+	}
+}
@@ -0,0 +1,415 @@
+// Metrics output to OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	quantileOpts sync.Map // of quantiles to their metric.ObserveOption
+	windowOpts   = []metric.ObserveOption{
+		metric.WithAttributes(attribute.String("window", "1m")),
+		metric.WithAttributes(attribute.String("window", "5m")),
+		metric.WithAttributes(attribute.String("window", "15m")),
+		metric.WithAttributes(attribute.String("window", "mean")),
+	}
+)
+
+// quantileOpt returns the option labelling an observation of the given
+// quantile, constructing it the first time it's needed.
+func quantileOpt(q float64) metric.ObserveOption {
+	if opt, ok := quantileOpts.Load(q); ok {
+		return opt.(metric.ObserveOption)
+	}
+	opt, _ := quantileOpts.LoadOrStore(q, metric.WithAttributes(attribute.String("quantile", strconv.FormatFloat(q, 'f', -1, 64))))
+	return opt.(metric.ObserveOption)
+}
+
+// RegisterMeterProvider reads r every interval and reports its metrics
+// through asynchronous instruments created from mp, returning a function
+// which stops reading and unregisters the instruments' callbacks.
+//
+// Metrics are mapped as the Prometheus exporter maps them.  Counters and float
+// counters are observable counters and gauges are observable gauges.  Histograms and
+// timers, whose samples can't be replayed without double counting, report
+// their percentiles as an observable gauge with a "quantile" attribute plus
+// "_count" and "_sum" instruments.  Timers are reported in seconds under a
+// "_seconds" suffix.  Meters and timers additionally report their rates as a
+// "_rate" gauge with a "window" attribute.  ResettingTimers, which hold only
+// the durations recorded since they were last read, are recorded into a
+// "_seconds" histogram.
+//
+// Names encoded by metrics.EncodeTaggedName are reported as their base name
+// with their tags as attributes, so that every tagged metric of a base name
+// is a series of the same instrument.  Metric names are sanitized to the
+// OpenTelemetry instrument name charset by replacing every invalid character
+// with an underscore and prefixing names which don't start with a letter with
+// "metric_".  Each read unregisters the callbacks of metrics which have been
+// unregistered from r since the last.
+func RegisterMeterProvider(r metrics.Registry, mp metric.MeterProvider, interval time.Duration) (stop func()) {
+	return RegisterMeterProviderWithConfig(Config{
+		Registry:      r,
+		MeterProvider: mp,
+		Interval:      interval,
+	})
+}
+
+// Config provides a container with configuration parameters for
+// RegisterMeterProviderWithConfig.
+type Config struct {
+	Registry      metrics.Registry     // Registry to be reported
+	MeterProvider metric.MeterProvider // Provider of the meter the instruments are created from
+	Interval      time.Duration        // Interval between reads of the registry
+	Logger        metrics.Logger       // Logger for errors, the standard library's if nil
+	AlignFlush    bool                 // Whether to read on multiples of Interval, say on the minute, rather than every Interval from starting
+}
+
+// RegisterMeterProviderWithConfig reports a registry just like
+// RegisterMeterProvider, but it takes a Config instead.
+func RegisterMeterProviderWithConfig(c Config) (stop func()) {
+	b := newBridge(c)
+	b.read()
+	ticker := metrics.NewFlushTicker(c.Interval, c.AlignFlush)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				b.read()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			b.unregister()
+		})
+	}
+}
+
+func newBridge(c Config) *bridge {
+	l := c.Logger
+	if nil == l {
+		l = log.Default()
+	}
+	return &bridge{
+		registry:      c.Registry,
+		meter:         c.MeterProvider.Meter("github.com/rcrowley/go-metrics"),
+		logger:        l,
+		registrations: make(map[string]registration),
+		histograms:    make(map[string]metric.Float64Histogram),
+	}
+}
+
+// bridge holds the latest snapshot of the registry, which the instruments'
+// callbacks observe, and one callback registration per metric name.  The
+// callbacks run under the SDK's locks so they only take snapshotMutex, which
+// is never held while calling into the SDK.
+type bridge struct {
+	registry      metrics.Registry
+	meter         metric.Meter
+	logger        metrics.Logger
+	mutex         sync.Mutex
+	registrations map[string]registration
+	histograms    map[string]metric.Float64Histogram
+	stopped       bool
+
+	snapshotMutex sync.RWMutex
+	snapshot      map[string]interface{}
+}
+
+type registration struct {
+	kind string
+	metric.Registration
+}
+
+// read snapshots the registry, creates instruments for metrics not seen
+// before, unregisters the callbacks of metrics no longer registered and
+// records the values of ResettingTimers.
+func (b *bridge) read() {
+	snapshot := metrics.SnapshotRegistry(b.registry)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.stopped {
+		return
+	}
+	b.snapshotMutex.Lock()
+	b.snapshot = snapshot
+	b.snapshotMutex.Unlock()
+	for name, i := range snapshot {
+		if _, ok := i.(metrics.ResettingTimer); ok {
+			// The registry's snapshot doesn't clear ResettingTimers, so
+			// drain the timer itself lest its durations be recorded twice.
+			if t, ok := b.registry.Get(name).(metrics.ResettingTimer); ok {
+				b.record(name, t.Snapshot())
+			}
+			continue
+		}
+		k := kind(i)
+		if "" == k {
+			continue
+		}
+		if reg, ok := b.registrations[name]; ok {
+			if k == reg.kind {
+				continue
+			}
+			reg.Unregister()
+			delete(b.registrations, name)
+		}
+		reg, err := b.register(name, i)
+		if nil != err {
+			b.logger.Printf("%v", err)
+			continue
+		}
+		b.registrations[name] = registration{k, reg}
+	}
+	for name, reg := range b.registrations {
+		if _, ok := snapshot[name]; !ok {
+			reg.Unregister()
+			delete(b.registrations, name)
+		}
+	}
+	for name := range b.histograms {
+		if _, ok := snapshot[name]; !ok {
+			delete(b.histograms, name)
+		}
+	}
+}
+
+func (b *bridge) unregister() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.stopped = true
+	for name, reg := range b.registrations {
+		reg.Unregister()
+		delete(b.registrations, name)
+	}
+}
+
+// get returns the named metric from the latest snapshot.
+func (b *bridge) get(name string) interface{} {
+	b.snapshotMutex.RLock()
+	defer b.snapshotMutex.RUnlock()
+	return b.snapshot[name]
+}
+
+func kind(i interface{}) string {
+	switch i.(type) {
+	case metrics.Counter:
+		return "counter"
+	case metrics.FloatCounter:
+		return "floatcounter"
+	case metrics.Gauge:
+		return "gauge"
+	case metrics.GaugeFloat64:
+		return "gaugefloat64"
+	case metrics.Histogram:
+		return "histogram"
+	case metrics.ThisMeter:
+		return "meter"
+	case metrics.Timer:
+		return "timer"
+	}
+	return ""
+}
+
+// register creates the instruments for the named metric and a callback
+// which observes them from the latest snapshot.
+func (b *bridge) register(name string, i interface{}) (metric.Registration, error) {
+	base, tags := metrics.DecodeTaggedName(name)
+	n, attrs := sanitizeName(base), tagAttributes(tags)
+	desc := metric.WithDescription("go-metrics " + base)
+	switch i.(type) {
+	case metrics.Counter:
+		c, err := b.meter.Int64ObservableCounter(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.Counter); ok {
+				o.ObserveInt64(c, m.Count(), attrs)
+			}
+			return nil
+		}, c)
+	case metrics.FloatCounter:
+		c, err := b.meter.Float64ObservableCounter(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.FloatCounter); ok {
+				o.ObserveFloat64(c, m.Count(), attrs)
+			}
+			return nil
+		}, c)
+	case metrics.Gauge:
+		g, err := b.meter.Int64ObservableGauge(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.Gauge); ok {
+				o.ObserveInt64(g, m.Value(), attrs)
+			}
+			return nil
+		}, g)
+	case metrics.GaugeFloat64:
+		g, err := b.meter.Float64ObservableGauge(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.GaugeFloat64); ok {
+				o.ObserveFloat64(g, m.Value(), attrs)
+			}
+			return nil
+		}, g)
+	case metrics.Histogram:
+		s, err := b.newSummary(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.Histogram); ok {
+				qs := metrics.ReportedPercentiles(m)
+				s.observe(o, attrs, m.Count(), float64(m.Sum()), qs, m.Percentiles(qs), 1)
+			}
+			return nil
+		}, s.instruments()...)
+	case metrics.ThisMeter:
+		c, err := b.meter.Int64ObservableCounter(n, desc)
+		if nil != err {
+			return nil, err
+		}
+		rate, err := b.meter.Float64ObservableGauge(n+"_rate", metric.WithDescription("go-metrics "+base+" rate per second"))
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.ThisMeter); ok {
+				o.ObserveInt64(c, m.Count(), attrs)
+				observeRates(o, attrs, rate, m.Rate1(), m.Rate5(), m.Rate15(), m.RateMean())
+			}
+			return nil
+		}, c, rate)
+	case metrics.Timer:
+		s, err := b.newSummary(n+"_seconds", desc)
+		if nil != err {
+			return nil, err
+		}
+		rate, err := b.meter.Float64ObservableGauge(n+"_rate", metric.WithDescription("go-metrics "+base+" rate per second"))
+		if nil != err {
+			return nil, err
+		}
+		return b.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if m, ok := b.get(name).(metrics.Timer); ok {
+				qs := metrics.ReportedPercentiles(m)
+				s.observe(o, attrs, m.Count(), float64(m.Sum()), qs, m.Percentiles(qs), float64(time.Second))
+				observeRates(o, attrs, rate, m.Rate1(), m.Rate5(), m.Rate15(), m.RateMean())
+			}
+			return nil
+		}, append(s.instruments(), rate)...)
+	}
+	return nil, nil
+}
+
+// record records every duration held by a ResettingTimer snapshot into a
+// histogram, in seconds.
+func (b *bridge) record(name string, t metrics.ResettingTimer) {
+	base, tags := metrics.DecodeTaggedName(name)
+	h, ok := b.histograms[name]
+	if !ok {
+		var err error
+		h, err = b.meter.Float64Histogram(sanitizeName(base)+"_seconds", metric.WithDescription("go-metrics "+base))
+		if nil != err {
+			b.logger.Printf("%v", err)
+			return
+		}
+		b.histograms[name] = h
+	}
+	ctx, attrs := context.Background(), tagAttributes(tags)
+	for _, v := range t.Values() {
+		h.Record(ctx, float64(v)/float64(time.Second), attrs)
+	}
+}
+
+// summary holds the instruments reporting a histogram or timer.
+type summary struct {
+	quantiles metric.Float64ObservableGauge
+	count     metric.Int64ObservableCounter
+	sum       metric.Float64ObservableGauge
+}
+
+func (b *bridge) newSummary(n string, desc metric.InstrumentOption) (*summary, error) {
+	var (
+		s   summary
+		err error
+	)
+	if s.quantiles, err = b.meter.Float64ObservableGauge(n, desc); nil != err {
+		return nil, err
+	}
+	if s.count, err = b.meter.Int64ObservableCounter(n+"_count", desc); nil != err {
+		return nil, err
+	}
+	if s.sum, err = b.meter.Float64ObservableGauge(n+"_sum", desc); nil != err {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *summary) instruments() []metric.Observable {
+	return []metric.Observable{s.quantiles, s.count, s.sum}
+}
+
+func (s *summary) observe(o metric.Observer, attrs metric.MeasurementOption, count int64, sum float64, quantiles, ps []float64, scale float64) {
+	for i, q := range quantiles {
+		o.ObserveFloat64(s.quantiles, ps[i]/scale, attrs, quantileOpt(q))
+	}
+	o.ObserveInt64(s.count, count, attrs)
+	o.ObserveFloat64(s.sum, sum/scale, attrs)
+}
+
+func observeRates(o metric.Observer, attrs metric.MeasurementOption, g metric.Float64ObservableGauge, rate1, rate5, rate15, rateMean float64) {
+	o.ObserveFloat64(g, rate1, attrs, windowOpts[0])
+	o.ObserveFloat64(g, rate5, attrs, windowOpts[1])
+	o.ObserveFloat64(g, rate15, attrs, windowOpts[2])
+	o.ObserveFloat64(g, rateMean, attrs, windowOpts[3])
+}
+
+// tagAttributes returns the option attributing a measurement with the tags
+// decoded from a metric's name.
+func tagAttributes(tags map[string]string) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(kvs...))
+}
+
+// sanitizeName replaces every character which isn't valid in an
+// OpenTelemetry instrument name with an underscore.  Names must start with a
+// letter so any other name is prefixed with "metric_".
+func sanitizeName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || '_' == c || '.' == c || '-' == c || '/' == c) {
+			b[i] = '_'
+		}
+	}
+	if 0 == len(b) || !('a' <= b[0] && b[0] <= 'z' || 'A' <= b[0] && b[0] <= 'Z') {
+		return "metric_" + string(b)
+	}
+	return string(b)
+}
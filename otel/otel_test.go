@@ -0,0 +1,206 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterMeterProvider(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo.count", r).Inc(47)
+	metrics.NewRegisteredGauge("bar", r).Update(47)
+	h := metrics.NewRegisteredHistogram("baz", r, metrics.NewUniformSample(100))
+	h.Update(1)
+	h.Update(3)
+	rt := metrics.NewRegisteredResettingTimer("quux", r)
+	rt.Update(time.Second)
+	rt.Update(2 * time.Second)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	stop := RegisterMeterProvider(r, mp, time.Hour)
+	defer stop()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); nil != err {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	if sum, ok := got["foo.count"].(metricdata.Sum[int64]); !ok || !sum.IsMonotonic || 47 != sum.DataPoints[0].Value {
+		t.Errorf("foo.count: %#v\n", got["foo.count"])
+	}
+	if g, ok := got["bar"].(metricdata.Gauge[int64]); !ok || 47 != g.DataPoints[0].Value {
+		t.Errorf("bar: %#v\n", got["bar"])
+	}
+	if g, ok := got["baz"].(metricdata.Gauge[float64]); ok {
+		want := attribute.NewSet(attribute.String("quantile", "0.5"))
+		found := false
+		for _, dp := range g.DataPoints {
+			if dp.Attributes.Equals(&want) {
+				found = true
+				if 2 != dp.Value {
+					t.Errorf("baz median: 2 != %v\n", dp.Value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("baz: no median in %#v\n", g)
+		}
+	} else {
+		t.Errorf("baz: %#v\n", got["baz"])
+	}
+	if sum, ok := got["baz_count"].(metricdata.Sum[int64]); !ok || 2 != sum.DataPoints[0].Value {
+		t.Errorf("baz_count: %#v\n", got["baz_count"])
+	}
+	if hist, ok := got["quux_seconds"].(metricdata.Histogram[float64]); !ok || 2 != hist.DataPoints[0].Count || 3 != hist.DataPoints[0].Sum {
+		t.Errorf("quux_seconds: %#v\n", got["quux_seconds"])
+	}
+}
+
+func TestRegisterMeterProviderStop(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo", r).Inc(47)
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	stop := RegisterMeterProvider(r, mp, time.Millisecond)
+	stop()
+	stop()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); nil != err {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && 0 != len(sum.DataPoints) {
+				t.Errorf("%s observed after stop: %#v\n", m.Name, sum)
+			}
+		}
+	}
+}
+
+func TestRegisterMeterProviderFloatCounter(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredFloatCounter("foo", r).Inc(2.5)
+	reader := sdkmetric.NewManualReader()
+	stop := RegisterMeterProvider(r, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)), time.Hour)
+	defer stop()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); nil != err {
+		t.Fatal(err)
+	}
+	if 1 != len(rm.ScopeMetrics) || 1 != len(rm.ScopeMetrics[0].Metrics) {
+		t.Fatalf("%#v\n", rm.ScopeMetrics)
+	}
+	m := rm.ScopeMetrics[0].Metrics[0]
+	if sum, ok := m.Data.(metricdata.Sum[float64]); !ok || "foo" != m.Name || !sum.IsMonotonic || 2.5 != sum.DataPoints[0].Value {
+		t.Errorf("%s: %#v\n", m.Name, m.Data)
+	}
+}
+
+func TestRegisterMeterProviderTagged(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTagged("hits", map[string]string{"path": "/a"}, metrics.NewCounter(), r).(metrics.Counter).Inc(1)
+	metrics.GetOrRegisterTagged("hits", map[string]string{"path": "/b"}, metrics.NewCounter(), r).(metrics.Counter).Inc(2)
+	reader := sdkmetric.NewManualReader()
+	stop := RegisterMeterProvider(r, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)), time.Hour)
+	defer stop()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); nil != err {
+		t.Fatal(err)
+	}
+	got := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok || "hits" != m.Name {
+				t.Errorf("%s: %#v\n", m.Name, m.Data)
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				path, _ := dp.Attributes.Value("path")
+				got[path.AsString()] = dp.Value
+			}
+		}
+	}
+	if want := map[string]int64{"/a": 1, "/b": 2}; !reflect.DeepEqual(want, got) {
+		t.Errorf("hits: %v != %v\n", want, got)
+	}
+}
+
+func TestRegisterMeterProviderUnregistered(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo", r).Inc(47)
+	rt := metrics.NewRegisteredResettingTimer("bar", r)
+	rt.Update(time.Second)
+	b := newBridge(Config{
+		Registry:      r,
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader())),
+	})
+	b.read()
+	if 1 != len(b.registrations) || 1 != len(b.histograms) {
+		t.Fatalf("b.read(): %v, %v\n", b.registrations, b.histograms)
+	}
+	r.UnregisterAll()
+	b.read()
+	if 0 != len(b.registrations) || 0 != len(b.histograms) {
+		t.Errorf("b.read() after unregistering: %v, %v\n", b.registrations, b.histograms)
+	}
+}
+
+func TestRegisterMeterProviderWithConfigLogger(t *testing.T) {
+	r := metrics.NewRegistry()
+	// Instrument names are limited to 255 characters.
+	metrics.NewRegisteredCounter(strings.Repeat("x", 256), r).Inc(47)
+	l := make(chanLogger, 1)
+	stop := RegisterMeterProviderWithConfig(Config{
+		Registry:      r,
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader())),
+		Interval:      time.Hour,
+		Logger:        l,
+	})
+	defer stop()
+	select {
+	case <-l:
+	default:
+		t.Error("invalid instrument name wasn't logged")
+	}
+}
+
+// chanLogger sends each line logged to it on a channel, dropping lines while
+// the channel is full.
+type chanLogger chan string
+
+func (l chanLogger) Printf(format string, v ...interface{}) {
+	select {
+	case l <- fmt.Sprintf(format, v...):
+	default:
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	for in, out := range map[string]string{
+		"foo.bar-baz/quux": "foo.bar-baz/quux",
+		"foo bar:baz":      "foo_bar_baz",
+		"0foo":             "metric_0foo",
+	} {
+		if s := sanitizeName(in); out != s {
+			t.Errorf("sanitizeName(%q): %q != %q", in, out, s)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Metrics output to Prometheus.
+package prometheus
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rcrowley/go-metrics"
+)
+
+type collector struct {
+	registry metrics.Registry
+}
+
+// NewPrometheusCollector returns a prometheus.Collector which reports every
+// metric in r each time it is collected.  Counters and float counters are
+// exported as counters, gauges as gauges, and histograms and timers as
+// summaries.  Meters and timers
+// additionally export their rates as a "_rate" gauge labelled by window.
+// Timer summaries are reported in seconds.
+//
+// Names encoded by metrics.EncodeTaggedName are exported as their base name
+// with their tags as labels.  Every tag set of a base name should have the
+// same keys, as Prometheus expects of the metrics in a family.  Metric and
+// label names are sanitized to the Prometheus charset by replacing every
+// invalid character with an underscore.  Labels which clash with those the
+// collector adds itself, "quantile" on histograms and timers and "window" on
+// meters and timers, make the metric invalid, which Gather reports as an
+// error rather than panicking.
+//
+// The help text and unit set by metrics.DescribableRegistry.Describe, under
+// the base name for tagged metrics, are exported as the HELP text and a suffix
+// of the metric name, except that timers are always in seconds.
+func NewPrometheusCollector(r metrics.Registry) prometheus.Collector {
+	return &collector{registry: r}
+}
+
+// Describe sends no descriptors, which makes the collector unchecked since
+// the metrics in a Registry come and go at runtime.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect snapshots the registry and sends one or more Prometheus metrics for
+// each metric found.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for fullName, i := range metrics.SnapshotRegistry(c.registry) {
+		name, tags := metrics.DecodeTaggedName(fullName)
+		fqName, labels := sanitizeName(name), sanitizeLabels(tags)
+		help, unit, ok := metrics.RegistryDescription(c.registry, name)
+		if !ok {
+			help, unit, _ = metrics.RegistryDescription(c.registry, fullName)
+		}
+		if "" == help {
+			help = "go-metrics " + name
+		}
+		if _, ok := i.(metrics.Timer); !ok && "" != unit {
+			if suffix := "_" + sanitizeName(unit); !strings.HasSuffix(fqName, suffix) {
+				fqName += suffix
+			}
+		}
+		switch metric := i.(type) {
+		case metrics.Counter:
+			ch <- constMetric(fqName, help, labels, prometheus.CounterValue, float64(metric.Count()))
+		case metrics.FloatCounter:
+			ch <- constMetric(fqName, help, labels, prometheus.CounterValue, metric.Count())
+		case metrics.Gauge:
+			ch <- constMetric(fqName, help, labels, prometheus.GaugeValue, float64(metric.Value()))
+		case metrics.GaugeFloat64:
+			ch <- constMetric(fqName, help, labels, prometheus.GaugeValue, metric.Value())
+		case metrics.Histogram:
+			qs := metrics.ReportedPercentiles(metric)
+			ch <- summary(fqName, help, labels, metric.Count(), float64(metric.Sum()), qs, metric.Percentiles(qs), 1)
+		case metrics.ThisMeter:
+			ch <- constMetric(fqName, help, labels, prometheus.CounterValue, float64(metric.Count()))
+			rates(ch, fqName, help, labels, metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean())
+		case metrics.Timer:
+			qs := metrics.ReportedPercentiles(metric)
+			ch <- summary(fqName+"_seconds", help, labels, metric.Count(), float64(metric.Sum()), qs, metric.Percentiles(qs), float64(time.Second))
+			rates(ch, fqName, help, labels, metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean())
+		}
+	}
+}
+
+// constMetric returns a constant metric or, should its labels be invalid, an
+// invalid metric which fails the collection rather than panicking.
+func constMetric(fqName, help string, labels prometheus.Labels, t prometheus.ValueType, v float64) prometheus.Metric {
+	desc := prometheus.NewDesc(fqName, help, nil, labels)
+	return validMetric(desc)(prometheus.NewConstMetric(desc, t, v))
+}
+
+// rates sends the samples of a "_rate" gauge labelled by window.  A metric
+// tagged "window" itself is sent as an invalid metric.
+func rates(ch chan<- prometheus.Metric, fqName, help string, labels prometheus.Labels, rate1, rate5, rate15, rateMean float64) {
+	desc := prometheus.NewDesc(fqName+"_rate", help+" rate per second", []string{"window"}, labels)
+	valid := validMetric(desc)
+	ch <- valid(prometheus.NewConstMetric(desc, prometheus.GaugeValue, rate1, "1m"))
+	ch <- valid(prometheus.NewConstMetric(desc, prometheus.GaugeValue, rate5, "5m"))
+	ch <- valid(prometheus.NewConstMetric(desc, prometheus.GaugeValue, rate15, "15m"))
+	ch <- valid(prometheus.NewConstMetric(desc, prometheus.GaugeValue, rateMean, "mean"))
+}
+
+// summary returns a constant summary with the given quantiles, dividing its
+// values by scale.  A metric tagged "quantile" is returned as an invalid
+// metric.
+func summary(fqName, help string, labels prometheus.Labels, count int64, sum float64, quantiles, ps []float64, scale float64) prometheus.Metric {
+	desc := prometheus.NewDesc(fqName, help, nil, labels)
+	qs := make(map[float64]float64, len(quantiles))
+	for i, q := range quantiles {
+		qs[q] = ps[i] / scale
+	}
+	return validMetric(desc)(prometheus.NewConstSummary(desc, uint64(count), sum/scale, qs))
+}
+
+// validMetric returns a function which returns the given metric or, if
+// there's an error, an invalid metric of desc reporting it.
+func validMetric(desc *prometheus.Desc) func(prometheus.Metric, error) prometheus.Metric {
+	return func(m prometheus.Metric, err error) prometheus.Metric {
+		if nil != err {
+			return prometheus.NewInvalidMetric(desc, err)
+		}
+		return m
+	}
+}
+
+// sanitizeName replaces every character which isn't valid in a Prometheus
+// metric name with an underscore.
+func sanitizeName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '_' == c || ':' == c || 0 < i && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// sanitizeLabels returns tags as Prometheus labels, replacing every character
+// which isn't valid in a label name with an underscore.
+func sanitizeLabels(tags map[string]string) prometheus.Labels {
+	if 0 == len(tags) {
+		return nil
+	}
+	labels := make(prometheus.Labels, len(tags))
+	for k, v := range tags {
+		b := []byte(k)
+		for i, c := range b {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '_' == c || 0 < i && '0' <= c && c <= '9') {
+				b[i] = '_'
+			}
+		}
+		labels[string(b)] = v
+	}
+	return labels
+}
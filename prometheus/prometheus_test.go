@@ -0,0 +1,148 @@
+package prometheus
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestCollector(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("foo.count", r).Inc(47)
+	metrics.NewRegisteredGauge("bar", r).Update(47)
+	h := metrics.NewRegisteredHistogram("baz", r, metrics.NewUniformSample(100))
+	h.Update(1)
+	h.Update(3)
+	m := metrics.NewRegisteredThisMeter("quux", r)
+	defer m.Stop()
+	m.Mark(47)
+
+	pr := prometheus.NewPedanticRegistry()
+	pr.MustRegister(NewPrometheusCollector(r))
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(pr, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	body := string(b)
+
+	for _, line := range []string{
+		"# TYPE foo_count counter",
+		"foo_count 47",
+		"# TYPE bar gauge",
+		"bar 47",
+		"# TYPE baz summary",
+		`baz{quantile="0.5"} 2`,
+		"baz_sum 4",
+		"baz_count 2",
+		"quux 47",
+		`quux_rate{window="1m"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorFloatCounter(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredFloatCounter("foo", r).Inc(2.5)
+	pr := prometheus.NewPedanticRegistry()
+	pr.MustRegister(NewPrometheusCollector(r))
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(pr, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	if body := string(b); !strings.Contains(body, "# TYPE foo counter\nfoo 2.5\n") {
+		t.Errorf("missing foo 2.5 in:\n%s", body)
+	}
+}
+
+func TestCollectorDescribed(t *testing.T) {
+	r := metrics.NewRegistry().(*metrics.StandardRegistry)
+	r.Describe("foo", "Bytes read from the socket.", "bytes")
+	r.Describe("requests", "Requests served.", "")
+	metrics.NewRegisteredCounter("foo", r).Inc(47)
+	metrics.GetOrRegisterTagged("requests", map[string]string{"status": "200"}, metrics.NewCounter, r).(metrics.Counter).Inc(1)
+
+	pr := prometheus.NewPedanticRegistry()
+	pr.MustRegister(NewPrometheusCollector(r))
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(pr, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	body := string(b)
+
+	for _, line := range []string{
+		"# HELP foo_bytes Bytes read from the socket.",
+		"# TYPE foo_bytes counter",
+		"foo_bytes 47",
+		"# HELP requests Requests served.",
+		`requests{status="200"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorTagged(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTagged("requests", map[string]string{"status": "200", "http.method": "GET"}, metrics.NewCounter, r).(metrics.Counter).Inc(47)
+	metrics.GetOrRegisterTagged("requests", map[string]string{"status": "500", "http.method": "GET"}, metrics.NewCounter, r).(metrics.Counter).Inc(1)
+
+	pr := prometheus.NewPedanticRegistry()
+	pr.MustRegister(NewPrometheusCollector(r))
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(pr, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b, _ := ioutil.ReadAll(w.Body)
+	body := string(b)
+
+	for _, line := range []string{
+		"# TYPE requests counter",
+		`requests{http_method="GET",status="200"} 47`,
+		`requests{http_method="GET",status="500"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorReservedLabels(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := metrics.GetOrRegisterTagged("events", map[string]string{"window": "1m"}, metrics.NewThisMeter, r).(metrics.ThisMeter)
+	defer m.Stop()
+	m.Mark(1)
+	metrics.GetOrRegisterTagged("sizes", map[string]string{"quantile": "all"}, func() metrics.Histogram {
+		return metrics.NewHistogram(metrics.NewUniformSample(100))
+	}, r).(metrics.Histogram).Update(1)
+	metrics.NewRegisteredCounter("ok", r).Inc(47)
+
+	pr := prometheus.NewPedanticRegistry()
+	pr.MustRegister(NewPrometheusCollector(r))
+	mfs, err := pr.Gather()
+	if nil == err {
+		t.Fatal("pr.Gather(): nil error")
+	}
+	for _, name := range []string{"events_rate", "sizes"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("err: %s not in %v\n", name, err)
+		}
+	}
+	if 2 != len(mfs) || "events" != mfs[0].GetName() || "ok" != mfs[1].GetName() {
+		t.Errorf("mfs: events ok != %v\n", mfs)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	for in, out := range map[string]string{
+		"foo.bar-baz": "foo_bar_baz",
+		"0foo:bar_9":  "_foo:bar_9",
+	} {
+		if s := sanitizeName(in); out != s {
+			t.Errorf("sanitizeName(%q): %q != %q", in, out, s)
+		}
+	}
+}
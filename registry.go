@@ -1,8 +1,10 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -16,11 +18,27 @@ func (err DuplicateMetric) Error() string {
 	return fmt.Sprintf("duplicate metric: %s", string(err))
 }
 
+// ErrMaxMetrics is the error returned by Registry.Register when the registry
+// already holds the maximum number of metrics set by SetMaxMetrics.
+var ErrMaxMetrics = errors.New("metrics: registry holds its maximum number of metrics")
+
+// UnknownMetric is the error returned by AliasingRegistry.Alias when there's
+// no metric to alias.
+type UnknownMetric string
+
+func (err UnknownMetric) Error() string {
+	return fmt.Sprintf("unknown metric: %s", string(err))
+}
+
 // A Registry holds references to a set of metrics by name and can iterate
 // over them, calling callback functions provided by the user.
 //
 // This is an interface so as to encourage other structs to implement
-// the Registry API as appropriate.
+// the Registry API as appropriate.  Further behaviour is added through the
+// optional interfaces below, each of which a Registry may implement, rather
+// than to Registry itself, so that implementations outside this package keep
+// satisfying it.  The package-level functions taking a Registry fall back on
+// its Registry methods when it doesn't implement the one they need.
 type Registry interface {
 
 	// Call the given function for each registered metric.
@@ -50,16 +68,178 @@ type Registry interface {
 	UnregisterAll()
 }
 
+// AliasingRegistries can make a metric available under a second name.
+type AliasingRegistry interface {
+	Registry
+
+	// Alias makes the metric registered under the first name also
+	// available under the second.
+	Alias(string, string) error
+}
+
+// ConstructingRegistries offer variants of GetOrRegister which control how
+// the metric is constructed.
+type ConstructingRegistry interface {
+	Registry
+
+	// Gets an existing metric or registers the one returned by the given
+	// constructor, returning a DuplicateMetric error if the existing metric's
+	// type differs from the constructed one.
+	GetOrRegisterE(string, func() interface{}) (interface{}, error)
+
+	// Gets an existing metric or registers the one returned by the given
+	// constructor, which is passed the name, say to describe the metric.
+	GetOrRegisterNamed(string, func(string) interface{}) interface{}
+
+	// Gets an existing metric or registers the given one, never calling
+	// it even if it's a function.
+	GetOrRegisterValue(string, interface{}) interface{}
+}
+
+// DescribableRegistries keep the help text and unit of their metrics for
+// exporters.
+type DescribableRegistry interface {
+	Registry
+
+	// Describe sets the help text and unit of the metric registered under
+	// the given name for exporters.
+	Describe(name, help, unit string)
+
+	// Description returns the help text and unit of the metric registered
+	// under the given name and whether it was described.
+	Description(string) (help, unit string, ok bool)
+}
+
+// EnumerableRegistries can list and select their metrics more cheaply or
+// more consistently than by calling Each.
+type EnumerableRegistry interface {
+	Registry
+
+	// Call the second function for each registered metric for which the
+	// first returns true.
+	EachFiltered(func(string, interface{}) bool, func(string, interface{}))
+
+	// Len returns the number of registered metrics.
+	Len() int
+
+	// Names returns the names of the registered metrics in lexical order.
+	Names() []string
+
+	// Call the given function for each registered metric in lexical order
+	// by name.
+	SortedEach(func(string, interface{}))
+
+	// Unregister every metric for which the given function returns true.
+	UnregisterMatching(func(string, interface{}) bool)
+}
+
+// ObservableRegistries call back when metrics are registered and
+// unregistered.
+type ObservableRegistry interface {
+	Registry
+
+	// OnRegister calls the given function with the name and metric each
+	// time a metric is registered.
+	OnRegister(func(string, interface{}))
+
+	// OnUnregister calls the given function with the name each time a
+	// metric is unregistered.
+	OnUnregister(func(string))
+}
+
+// SnapshotableRegistries can take read-only copies of all their metrics at
+// once.
+type SnapshotableRegistry interface {
+	Registry
+
+	// Snapshot returns read-only copies of all the metrics in the
+	// Registry.
+	Snapshot() map[string]interface{}
+}
+
 // The standard implementation of a Registry is a mutex-protected map
 // of names to metrics.
 type StandardRegistry struct {
-	metrics map[string]interface{}
-	mutex   sync.Mutex
+	aliases      map[string]string // alias to name
+	descriptions map[string]description
+	events       []registryEvent // not yet passed to the callbacks
+	maxMetrics   int
+	metrics      map[string]interface{}
+	mutex        sync.Mutex
+	onRegister   []func(string, interface{})
+	onUnregister []func(string)
+	overflowed   bool
+}
+
+// description is the help text and unit set by Describe.
+type description struct {
+	help, unit string
+}
+
+// registryEvent is a registration or unregistration for the OnRegister and
+// OnUnregister callbacks.
+type registryEvent struct {
+	name         string
+	metric       interface{}
+	unregistered bool
 }
 
 // Create a new registry.
 func NewRegistry() Registry {
-	return &StandardRegistry{metrics: make(map[string]interface{})}
+	return &StandardRegistry{
+		aliases:      make(map[string]string),
+		descriptions: make(map[string]description),
+		metrics:      make(map[string]interface{}),
+	}
+}
+
+// Alias makes the metric registered under name also available under alias,
+// say for the deprecation window of a rename: Get returns it and Each and
+// Snapshot include it under both names.  Unregistering the alias leaves the
+// metric registered and running under its name but unregistering the name
+// stops the metric and unregisters its aliases too.  Returns an UnknownMetric
+// if no metric is registered under name and a DuplicateMetric if one already
+// is under alias.
+func (r *StandardRegistry) Alias(name, alias string) error {
+	r.mutex.Lock()
+	defer r.unlockAndNotify()
+	i, ok := r.metrics[name]
+	if !ok {
+		return UnknownMetric(name)
+	}
+	if _, ok := r.metrics[alias]; ok {
+		return DuplicateMetric(alias)
+	}
+	if r.full() {
+		return ErrMaxMetrics
+	}
+	if primary, ok := r.aliases[name]; ok {
+		name = primary
+	}
+	r.aliases[alias] = name
+	r.metrics[alias] = i
+	r.events = append(r.events, registryEvent{name: alias, metric: i})
+	return nil
+}
+
+// Describe sets the help text and unit, say "bytes" or "seconds", of the
+// metric registered under name for exporters such as Prometheus which report
+// them.  The description is kept by name rather than by metric, so it may be
+// set before the metric is registered and survives it being unregistered and
+// registered again.
+func (r *StandardRegistry) Describe(name, help, unit string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.descriptions[name] = description{help: help, unit: unit}
+}
+
+// Description returns the help text and unit set by Describe for name and
+// whether any were set.
+func (r *StandardRegistry) Description(name string) (help, unit string, ok bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	d, ok := r.descriptions[name]
+	return d.help, d.unit, ok
 }
 
 // Call the given function for each registered metric.
@@ -69,6 +249,24 @@ func (r *StandardRegistry) Each(f func(string, interface{})) {
 	}
 }
 
+// EachFiltered calls fn for each registered metric for which pred returns
+// true, say only the Counters for an exporter which handles nothing else.
+// pred is called with the registry locked and must not call back into it;
+// fn is called once it's unlocked.
+func (r *StandardRegistry) EachFiltered(pred func(string, interface{}) bool, fn func(string, interface{})) {
+	r.mutex.Lock()
+	metrics := make(map[string]interface{})
+	for name, i := range r.metrics {
+		if pred(name, i) {
+			metrics[name] = i
+		}
+	}
+	r.mutex.Unlock()
+	for name, i := range metrics {
+		fn(name, i)
+	}
+}
+
 // Get the metric by the given name or nil if none is registered.
 func (r *StandardRegistry) Get(name string) interface{} {
 	r.mutex.Lock()
@@ -79,25 +277,145 @@ func (r *StandardRegistry) Get(name string) interface{} {
 // Gets an existing metric or creates and registers a new one. Threadsafe
 // alternative to calling Get and Register on failure.
 // The interface can be the metric to register if not found in registry,
-// or a function returning the metric for lazy instantiation.
+// or a function returning the metric for lazy instantiation.  The function
+// is only called if no metric is registered under name and is called without
+// the registry locked.  If another goroutine registers a metric under name
+// meanwhile, that metric is returned and the constructed one is stopped if
+// it's Stoppable.
 func (r *StandardRegistry) GetOrRegister(name string, i interface{}) interface{} {
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	constructed := false
+	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
+		i, constructed = v.Call(nil)[0].Interface(), true
+	}
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	defer r.unlockAndNotify()
 	if metric, ok := r.metrics[name]; ok {
+		if s, ok := i.(Stoppable); ok && constructed {
+			s.Stop()
+		}
 		return metric
 	}
-	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
-		i = v.Call(nil)[0].Interface()
+	if ErrMaxMetrics == r.register(name, i) {
+		if s, ok := i.(Stoppable); ok && constructed {
+			s.Stop()
+		}
+		return nilMetric(i)
+	}
+	return i
+}
+
+// GetOrRegisterE gets an existing metric or registers the one returned by
+// ctor.  If the existing metric's concrete type differs from the constructed
+// one it returns the existing metric and a DuplicateMetric error rather than
+// leaving the caller to panic on a type assertion.  If the registry is full
+// it returns a no-op metric of the constructed kind and ErrMaxMetrics.  A
+// constructed metric that isn't registered is stopped if it's Stoppable.
+//
+// ctor is always called, without the registry locked, since the metric it
+// constructs is what the existing metric's type is checked against.
+func (r *StandardRegistry) GetOrRegisterE(name string, ctor func() interface{}) (interface{}, error) {
+	i := ctor()
+	r.mutex.Lock()
+	defer r.unlockAndNotify()
+	if metric, ok := r.metrics[name]; ok {
+		if s, ok := i.(Stoppable); ok {
+			s.Stop()
+		}
+		if reflect.TypeOf(metric) != reflect.TypeOf(i) {
+			return metric, DuplicateMetric(name)
+		}
+		return metric, nil
+	}
+	if err := r.register(name, i); ErrMaxMetrics == err {
+		if s, ok := i.(Stoppable); ok {
+			s.Stop()
+		}
+		return nilMetric(i), err
+	} else if nil != err {
+		return nil, err
+	}
+	return i, nil
+}
+
+// GetOrRegisterNamed gets an existing metric or registers the one returned by
+// ctor, which is passed name so that a single constructor can serve a loop
+// over, say, endpoints and construct child metrics or describe the metric
+// under its name.  Like a function passed to GetOrRegister, ctor is only
+// called if no metric is registered under name and is called without the
+// registry locked, so it may call back into it.
+func (r *StandardRegistry) GetOrRegisterNamed(name string, ctor func(string) interface{}) interface{} {
+	return r.GetOrRegister(name, func() interface{} { return ctor(name) })
+}
+
+// GetOrRegisterValue gets an existing metric or registers i, which unlike in
+// GetOrRegister is never called even if it's a function, so the caller
+// controls when metrics with side effects, say meters ticked by the arbiter,
+// are constructed.  If a metric is already registered or the registry is full
+// i is left as it is and it's up to the caller to Stop it if need be.  If the
+// registry is full it returns a no-op metric of the same kind.
+func (r *StandardRegistry) GetOrRegisterValue(name string, i interface{}) interface{} {
+	r.mutex.Lock()
+	defer r.unlockAndNotify()
+	if metric, ok := r.metrics[name]; ok {
+		return metric
+	}
+	if ErrMaxMetrics == r.register(name, i) {
+		return nilMetric(i)
 	}
-	r.register(name, i)
 	return i
 }
 
+// Len returns the number of names metrics are registered under, aliases
+// included.
+func (r *StandardRegistry) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.metrics)
+}
+
+// Names returns the names metrics are registered under, aliases included, in
+// lexical order.  The metrics themselves aren't read.
+func (r *StandardRegistry) Names() []string {
+	r.mutex.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	r.mutex.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// OnRegister calls f with the name and metric each time a metric is
+// registered, aliases included.  f is called synchronously by the
+// registering goroutine once the registry is unlocked, so it may call back
+// into the registry.  Each call adds another callback.
+func (r *StandardRegistry) OnRegister(f func(string, interface{})) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.onRegister = append(r.onRegister, f)
+}
+
+// OnUnregister calls f with the name each time a metric is unregistered,
+// aliases included.  f is called synchronously by the unregistering
+// goroutine once the registry is unlocked, so it may call back into the
+// registry.  Each call adds another callback.
+func (r *StandardRegistry) OnUnregister(f func(string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.onUnregister = append(r.onUnregister, f)
+}
+
 // Register the given metric under the given name.  Returns a DuplicateMetric
-// if a metric by the given name is already registered.
+// if a metric by the given name is already registered and ErrMaxMetrics if
+// the registry is full, in either case leaving the metric, which the caller
+// still owns, as it is.
 func (r *StandardRegistry) Register(name string, i interface{}) error {
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	defer r.unlockAndNotify()
 	return r.register(name, i)
 }
 
@@ -105,90 +423,190 @@ func (r *StandardRegistry) Register(name string, i interface{}) error {
 func (r *StandardRegistry) RunHealthchecks() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	for _, i := range r.metrics {
+	for name, i := range r.metrics {
+		if _, ok := r.aliases[name]; ok {
+			continue
+		}
 		if h, ok := i.(Healthcheck); ok {
 			h.Check()
 		}
 	}
 }
 
-// GetAll metrics in the Registry
+// SetMaxMetrics caps the number of names metrics may be registered under,
+// aliases included, to guard against unbounded cardinality, say from user IDs
+// in metric names.  Once the registry is full, Register returns ErrMaxMetrics
+// and GetOrRegister returns a no-op metric of the kind it would have
+// registered, the first time logging to ErrorLogger that the registry is
+// full.  Metrics already registered are kept even if there are more than n.
+// Zero, the default, means no cap.
+func (r *StandardRegistry) SetMaxMetrics(n int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.maxMetrics = n
+}
+
+// Snapshot returns read-only copies of all the metrics in the Registry keyed
+// by name.  The copies are taken in a single pass while holding the registry
+// lock, so the result reflects one consistent set of registered metrics even
+// when metrics are concurrently registered or unregistered.  Healthchecks are
+// returned as-is so that they can still be checked.  ResettingTimers are
+// copied without being cleared, unlike by their own Snapshot, so that only the
+// exporters which write them drain them.
+//
+// Metrics are still updated without the registry lock so a snapshot is not a
+// point-in-time view across metrics, but it is taken as close together as
+// possible.  FunctionalGauges are evaluated while the lock is held and so must
+// not call back into the Registry.  An aliased metric is snapshotted once and
+// the snapshot included under each of its names.
+func (r *StandardRegistry) Snapshot() map[string]interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	snapshot := make(map[string]interface{}, len(r.metrics))
+	for name, i := range r.metrics {
+		if _, ok := r.aliases[name]; !ok {
+			snapshot[name] = snapshotMetric(i)
+		}
+	}
+	for alias, name := range r.aliases {
+		snapshot[alias] = snapshot[name]
+	}
+	return snapshot
+}
+
+// GetAll metrics in the Registry.  The values are read from a single
+// Snapshot of the Registry.
 func (r *StandardRegistry) GetAll() map[string]map[string]interface{} {
+	return snapshotValues(r.Snapshot())
+}
+
+// snapshotValues flattens each metric in a snapshot into a map of its values,
+// as returned by GetAll.
+func snapshotValues(snapshot map[string]interface{}) map[string]map[string]interface{} {
 	data := make(map[string]map[string]interface{})
-	r.Each(func(name string, i interface{}) {
-		values := make(map[string]interface{})
-		switch metric := i.(type) {
-		case Counter:
-			values["count"] = metric.Count()
-		case Gauge:
-			values["value"] = metric.Value()
-		case GaugeFloat64:
-			values["value"] = metric.Value()
-		case Healthcheck:
-			values["error"] = nil
-			metric.Check()
-			if err := metric.Error(); nil != err {
-				values["error"] = metric.Error().Error()
-			}
-		case Histogram:
-			h := metric.Snapshot()
-			ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			values["count"] = h.Count()
-			values["min"] = h.Min()
-			values["max"] = h.Max()
-			values["mean"] = h.Mean()
-			values["stddev"] = h.StdDev()
-			values["median"] = ps[0]
-			values["75%"] = ps[1]
-			values["95%"] = ps[2]
-			values["99%"] = ps[3]
-			values["99.9%"] = ps[4]
-		case ThisMeter:
-			m := metric.Snapshot()
-			values["count"] = m.Count()
-			values["1m.rate"] = m.Rate1()
-			values["5m.rate"] = m.Rate5()
-			values["15m.rate"] = m.Rate15()
-			values["mean.rate"] = m.RateMean()
-		case Timer:
-			t := metric.Snapshot()
-			ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			values["count"] = t.Count()
-			values["min"] = t.Min()
-			values["max"] = t.Max()
-			values["mean"] = t.Mean()
-			values["stddev"] = t.StdDev()
-			values["median"] = ps[0]
-			values["75%"] = ps[1]
-			values["95%"] = ps[2]
-			values["99%"] = ps[3]
-			values["99.9%"] = ps[4]
-			values["1m.rate"] = t.Rate1()
-			values["5m.rate"] = t.Rate5()
-			values["15m.rate"] = t.Rate15()
-			values["mean.rate"] = t.RateMean()
-		}
-		data[name] = values
-	})
+	for name, i := range snapshot {
+		data[name] = metricValues(i)
+	}
 	return data
 }
 
+// metricValues flattens a metric into a map of its values keyed by the names
+// used by GetAll and MarshalJSON.
+func metricValues(i interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	switch metric := i.(type) {
+	case Counter:
+		values["count"] = metric.Count()
+	case FloatCounter:
+		values["count"] = metric.Count()
+	case Gauge:
+		values["value"] = metric.Value()
+	case GaugeFloat64:
+		values["value"] = metric.Value()
+	case Healthcheck:
+		values["error"] = nil
+		metric.Check()
+		if err := metric.Error(); nil != err {
+			values["error"] = metric.Error().Error()
+		}
+	case Histogram:
+		h := metric.Snapshot()
+		values["count"] = h.Count()
+		values["min"] = h.Min()
+		values["max"] = h.Max()
+		values["mean"] = h.Mean()
+		values["stddev"] = h.StdDev()
+		ps := ReportedPercentiles(h)
+		percentileValues(values, ps, h.Percentiles(ps))
+	case ThisMeter:
+		m, release := flushSnapshot(metric)
+		values["count"] = m.Count()
+		values["1m.rate"] = m.Rate1()
+		values["5m.rate"] = m.Rate5()
+		values["15m.rate"] = m.Rate15()
+		values["mean.rate"] = m.RateMean()
+		release()
+	case Timer:
+		t := metric.Snapshot()
+		values["count"] = t.Count()
+		values["min"] = t.Min()
+		values["max"] = t.Max()
+		values["mean"] = t.Mean()
+		values["stddev"] = t.StdDev()
+		ps := ReportedPercentiles(t)
+		percentileValues(values, ps, t.Percentiles(ps))
+		values["1m.rate"] = t.Rate1()
+		values["5m.rate"] = t.Rate5()
+		values["15m.rate"] = t.Rate15()
+		values["mean.rate"] = t.RateMean()
+	}
+	return values
+}
+
+// percentileValues adds the scores of the percentiles ps to values, labelled
+// by percentileLabel.
+func percentileValues(values map[string]interface{}, ps, scores []float64) {
+	for i, p := range ps {
+		values[percentileLabel(p)] = scores[i]
+	}
+}
+
+// SortedEach calls the given function for each registered metric in lexical
+// order by name.  The set of metrics is copied under the registry lock before
+// the first call.
+func (r *StandardRegistry) SortedEach(f func(string, interface{})) {
+	metrics := r.registered()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, metrics[name])
+	}
+}
+
 // Unregister the metric with the given name.
 func (r *StandardRegistry) Unregister(name string) {
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	r.stop(name)
-	delete(r.metrics, name)
+	defer r.unlockAndNotify()
+	r.unregister(name)
 }
 
 // Unregister all metrics.  (Mostly for testing.)
 func (r *StandardRegistry) UnregisterAll() {
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	defer r.unlockAndNotify()
 	for name, _ := range r.metrics {
-		r.stop(name)
-		delete(r.metrics, name)
+		r.unregister(name)
+	}
+}
+
+// UnregisterMatching unregisters every metric for which f returns true,
+// stopping it if it's Stoppable.  f is called with the registry locked and
+// must not call back into it.
+func (r *StandardRegistry) UnregisterMatching(f func(string, interface{}) bool) {
+	r.mutex.Lock()
+	defer r.unlockAndNotify()
+	for name, i := range r.metrics {
+		if f(name, i) {
+			r.unregister(name)
+		}
+	}
+}
+
+// full returns whether the registry holds its maximum number of metrics,
+// logging the first time it's found full.
+func (r *StandardRegistry) full() bool {
+	if 0 == r.maxMetrics || len(r.metrics) < r.maxMetrics {
+		r.overflowed = false
+		return false
 	}
+	if !r.overflowed {
+		loggerOrDefault(ErrorLogger).Printf("metrics: registry holds its maximum of %d metrics, not registering more", r.maxMetrics)
+		r.overflowed = true
+	}
+	return true
 }
 
 func (r *StandardRegistry) register(name string, i interface{}) error {
@@ -196,8 +614,12 @@ func (r *StandardRegistry) register(name string, i interface{}) error {
 		return DuplicateMetric(name)
 	}
 	switch i.(type) {
-	case Counter, Gauge, GaugeFloat64, Healthcheck, Histogram, ThisMeter, Timer:
+	case Counter, FloatCounter, Gauge, GaugeFloat64, Healthcheck, Histogram, ResettingTimer, ThisMeter, Timer:
+		if r.full() {
+			return ErrMaxMetrics
+		}
 		r.metrics[name] = i
+		r.events = append(r.events, registryEvent{name: name, metric: i})
 	}
 	return nil
 }
@@ -212,6 +634,112 @@ func (r *StandardRegistry) registered() map[string]interface{} {
 	return metrics
 }
 
+// readOnlyMetric returns the no-op metric of the kind of i or, if i is a
+// function, of the metric it constructs, which is stopped if it's Stoppable,
+// for a read-only registry to return in place of registering it.
+func readOnlyMetric(i interface{}) interface{} {
+	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
+		i = v.Call(nil)[0].Interface()
+		if s, ok := i.(Stoppable); ok {
+			s.Stop()
+		}
+	}
+	return nilMetric(i)
+}
+
+// nilMetric returns the no-op metric of the same kind as the given one, or the
+// given one if there's none.
+func nilMetric(i interface{}) interface{} {
+	switch i.(type) {
+	case WindowedCounter:
+		return NilWindowedCounter{}
+	case Counter:
+		return NilCounter{}
+	case FloatCounter:
+		return NilFloatCounter{}
+	case Gauge:
+		return NilGauge{}
+	case GaugeFloat64:
+		return NilGaugeFloat64{}
+	case Healthcheck:
+		return NilHealthcheck{}
+	case Histogram:
+		return NilHistogram{}
+	case ResettingTimer:
+		return NilResettingTimer{}
+	case ThisMeter:
+		return NilThisMeter{}
+	case Timer:
+		return NilTimer{}
+	}
+	return i
+}
+
+func snapshotMetric(i interface{}) interface{} {
+	switch metric := i.(type) {
+	case Counter:
+		return metric.Snapshot()
+	case FloatCounter:
+		return metric.Snapshot()
+	case Gauge:
+		return metric.Snapshot()
+	case GaugeFloat64:
+		return metric.Snapshot()
+	case Histogram:
+		return metric.Snapshot()
+	case ResettingTimer:
+		return peekResettingTimer(metric)
+	case ThisMeter:
+		return metric.Snapshot()
+	case Timer:
+		return metric.Snapshot()
+	}
+	return i
+}
+
+// unregister removes an alias or else stops and removes a metric along with
+// its aliases.
+func (r *StandardRegistry) unregister(name string) {
+	if _, ok := r.metrics[name]; !ok {
+		return
+	}
+	r.events = append(r.events, registryEvent{name: name, unregistered: true})
+	if _, ok := r.aliases[name]; ok {
+		delete(r.aliases, name)
+		delete(r.metrics, name)
+		return
+	}
+	r.stop(name)
+	delete(r.metrics, name)
+	for alias, primary := range r.aliases {
+		if primary == name {
+			delete(r.aliases, alias)
+			delete(r.metrics, alias)
+			r.events = append(r.events, registryEvent{name: alias, unregistered: true})
+		}
+	}
+}
+
+// unlockAndNotify unlocks the registry and then passes the registrations and
+// unregistrations made while it was locked to the callbacks, so that they
+// may call back into the registry.
+func (r *StandardRegistry) unlockAndNotify() {
+	events, onRegister, onUnregister := r.events, r.onRegister, r.onUnregister
+	r.events = nil
+	r.mutex.Unlock()
+	for _, e := range events {
+		if e.unregistered {
+			for _, f := range onUnregister {
+				f(e.name)
+			}
+			continue
+		}
+		for _, f := range onRegister {
+			f(e.name, e.metric)
+		}
+	}
+}
+
 func (r *StandardRegistry) stop(name string) {
 	if i, ok := r.metrics[name]; ok {
 		if s, ok := i.(Stoppable); ok {
@@ -225,11 +753,16 @@ type Stoppable interface {
 	Stop()
 }
 
+// PrefixedRegistry prepends its prefix to the names of the metrics it
+// registers in an underlying Registry and only sees the metrics whose names
+// carry the prefix.
 type PrefixedRegistry struct {
 	underlying Registry
 	prefix     string
 }
 
+// NewPrefixedRegistry constructs a PrefixedRegistry backed by a new
+// StandardRegistry.
 func NewPrefixedRegistry(prefix string) Registry {
 	return &PrefixedRegistry{
 		underlying: NewRegistry(),
@@ -237,6 +770,7 @@ func NewPrefixedRegistry(prefix string) Registry {
 	}
 }
 
+// NewPrefixedChildRegistry constructs a PrefixedRegistry backed by parent.
 func NewPrefixedChildRegistry(parent Registry, prefix string) Registry {
 	return &PrefixedRegistry{
 		underlying: parent,
@@ -244,6 +778,24 @@ func NewPrefixedChildRegistry(parent Registry, prefix string) Registry {
 	}
 }
 
+// Alias makes the metric registered under name also available under alias.
+// Both names will be prefixed.
+func (r *PrefixedRegistry) Alias(name, alias string) error {
+	return registryAlias(r.underlying, r.prefix+name, r.prefix+alias)
+}
+
+// Describe sets the help text and unit of the metric registered under name.
+// The name will be prefixed.
+func (r *PrefixedRegistry) Describe(name, help, unit string) {
+	registryDescribe(r.underlying, r.prefix+name, help, unit)
+}
+
+// Description returns the help text and unit of the metric registered under
+// name.  The name will be prefixed.
+func (r *PrefixedRegistry) Description(name string) (help, unit string, ok bool) {
+	return RegistryDescription(r.underlying, r.prefix+name)
+}
+
 // Call the given function for each registered metric.
 func (r *PrefixedRegistry) Each(fn func(string, interface{})) {
 	wrappedFn := func(prefix string) func(string, interface{}) {
@@ -260,14 +812,23 @@ func (r *PrefixedRegistry) Each(fn func(string, interface{})) {
 	baseRegistry.Each(wrappedFn(prefix))
 }
 
+// EachFiltered calls fn for each registered metric whose name carries the
+// prefix and for which pred, called with the fully-qualified name, returns
+// true.
+func (r *PrefixedRegistry) EachFiltered(pred func(string, interface{}) bool, fn func(string, interface{})) {
+	baseRegistry, prefix := findPrefix(r, "")
+	registryEachFiltered(baseRegistry, func(name string, i interface{}) bool {
+		return strings.HasPrefix(name, prefix) && pred(name, i)
+	}, fn)
+}
+
+// findPrefix returns the registry underlying any PrefixedRegistries, whatever
+// its type, and the prefix of the names they register in it.
 func findPrefix(registry Registry, prefix string) (Registry, string) {
-	switch r := registry.(type) {
-	case *PrefixedRegistry:
+	if r, ok := registry.(*PrefixedRegistry); ok {
 		return findPrefix(r.underlying, r.prefix+prefix)
-	case *StandardRegistry:
-		return r, prefix
 	}
-	return nil, ""
+	return registry, prefix
 }
 
 // Get the metric by the given name or nil if none is registered.
@@ -284,6 +845,69 @@ func (r *PrefixedRegistry) GetOrRegister(name string, metric interface{}) interf
 	return r.underlying.GetOrRegister(realName, metric)
 }
 
+// GetOrRegisterE gets an existing metric or registers the one returned by
+// ctor.  The name will be prefixed.
+func (r *PrefixedRegistry) GetOrRegisterE(name string, ctor func() interface{}) (interface{}, error) {
+	realName := r.prefix + name
+	return registryGetOrRegisterE(r.underlying, realName, ctor)
+}
+
+// GetOrRegisterNamed gets an existing metric or registers the one returned by
+// ctor.  The name will be prefixed but ctor is passed it as given.
+func (r *PrefixedRegistry) GetOrRegisterNamed(name string, ctor func(string) interface{}) interface{} {
+	realName := r.prefix + name
+	return registryGetOrRegisterNamed(r.underlying, realName, func(string) interface{} { return ctor(name) })
+}
+
+// GetOrRegisterValue gets an existing metric or registers the given one,
+// never calling it.  The name will be prefixed.
+func (r *PrefixedRegistry) GetOrRegisterValue(name string, metric interface{}) interface{} {
+	realName := r.prefix + name
+	return registryGetOrRegisterValue(r.underlying, realName, metric)
+}
+
+// Len returns the number of registered metrics whose names carry the prefix.
+func (r *PrefixedRegistry) Len() int {
+	n := 0
+	r.Each(func(string, interface{}) { n++ })
+	return n
+}
+
+// Names returns the fully-qualified names of the registered metrics whose
+// names carry the prefix in lexical order.
+func (r *PrefixedRegistry) Names() []string {
+	baseRegistry, prefix := findPrefix(r, "")
+	var names []string
+	for _, name := range registryNames(baseRegistry) {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OnRegister calls f with the fully-qualified name and metric each time a
+// metric whose name carries the prefix is registered.
+func (r *PrefixedRegistry) OnRegister(f func(string, interface{})) {
+	baseRegistry, prefix := findPrefix(r, "")
+	registryOnRegister(baseRegistry, func(name string, i interface{}) {
+		if strings.HasPrefix(name, prefix) {
+			f(name, i)
+		}
+	})
+}
+
+// OnUnregister calls f with the fully-qualified name each time a metric whose
+// name carries the prefix is unregistered.
+func (r *PrefixedRegistry) OnUnregister(f func(string)) {
+	baseRegistry, prefix := findPrefix(r, "")
+	registryOnUnregister(baseRegistry, func(name string) {
+		if strings.HasPrefix(name, prefix) {
+			f(name)
+		}
+	})
+}
+
 // Register the given metric under the given name. The name will be prefixed.
 func (r *PrefixedRegistry) Register(name string, metric interface{}) error {
 	realName := r.prefix + name
@@ -295,9 +919,41 @@ func (r *PrefixedRegistry) RunHealthchecks() {
 	r.underlying.RunHealthchecks()
 }
 
-// GetAll metrics in the Registry
+// Snapshot returns read-only copies of the metrics whose names carry the
+// prefix, keyed by their fully-qualified names.
+func (r *PrefixedRegistry) Snapshot() map[string]interface{} {
+	baseRegistry, prefix := findPrefix(r, "")
+	snapshot := SnapshotRegistry(baseRegistry)
+	for name := range snapshot {
+		if !strings.HasPrefix(name, prefix) {
+			delete(snapshot, name)
+		}
+	}
+	return snapshot
+}
+
+// SortedEach calls the given function for each metric whose name carries the
+// prefix, in lexical order by fully-qualified name.
+func (r *PrefixedRegistry) SortedEach(fn func(string, interface{})) {
+	baseRegistry, prefix := findPrefix(r, "")
+	registrySortedEach(baseRegistry, func(name string, i interface{}) {
+		if strings.HasPrefix(name, prefix) {
+			fn(name, i)
+		}
+	})
+}
+
+// GetAll metrics whose names carry the prefix, keyed by their
+// fully-qualified names.
 func (r *PrefixedRegistry) GetAll() map[string]map[string]interface{} {
-	return r.underlying.GetAll()
+	baseRegistry, prefix := findPrefix(r, "")
+	data := baseRegistry.GetAll()
+	for name := range data {
+		if !strings.HasPrefix(name, prefix) {
+			delete(data, name)
+		}
+	}
+	return data
 }
 
 // Unregister the metric with the given name. The name will be prefixed.
@@ -306,18 +962,56 @@ func (r *PrefixedRegistry) Unregister(name string) {
 	r.underlying.Unregister(realName)
 }
 
-// Unregister all metrics.  (Mostly for testing.)
+// Unregister all metrics whose names carry the prefix.  (Mostly for
+// testing.)
 func (r *PrefixedRegistry) UnregisterAll() {
-	r.underlying.UnregisterAll()
+	r.UnregisterMatching(func(string, interface{}) bool { return true })
+}
+
+// UnregisterMatching unregisters every metric whose name carries the prefix
+// and for which f, called with the fully-qualified name, returns true.
+func (r *PrefixedRegistry) UnregisterMatching(f func(string, interface{}) bool) {
+	baseRegistry, prefix := findPrefix(r, "")
+	registryUnregisterMatching(baseRegistry, func(name string, i interface{}) bool {
+		return strings.HasPrefix(name, prefix) && f(name, i)
+	})
 }
 
 var DefaultRegistry Registry = NewRegistry()
 
+// Alias makes the metric registered under name also available under alias.
+func Alias(name, alias string) error {
+	return registryAlias(DefaultRegistry, name, alias)
+}
+
+// Describe sets the help text and unit of the metric registered under name.
+func Describe(name, help, unit string) {
+	registryDescribe(DefaultRegistry, name, help, unit)
+}
+
+// Description returns the help text and unit of the metric registered under
+// name and whether it was described.
+func Description(name string) (help, unit string, ok bool) {
+	return RegistryDescription(DefaultRegistry, name)
+}
+
 // Call the given function for each registered metric.
 func Each(f func(string, interface{})) {
 	DefaultRegistry.Each(f)
 }
 
+// Call the second function for each registered metric for which the first
+// returns true.
+func EachFiltered(pred func(string, interface{}) bool, fn func(string, interface{})) {
+	registryEachFiltered(DefaultRegistry, pred, fn)
+}
+
+// Call the given function for each registered metric in lexical order by
+// name.
+func SortedEach(f func(string, interface{})) {
+	registrySortedEach(DefaultRegistry, f)
+}
+
 // Get the metric by the given name or nil if none is registered.
 func Get(name string) interface{} {
 	return DefaultRegistry.Get(name)
@@ -329,6 +1023,40 @@ func GetOrRegister(name string, i interface{}) interface{} {
 	return DefaultRegistry.GetOrRegister(name, i)
 }
 
+// Gets an existing metric or registers the one returned by the given
+// constructor, returning a DuplicateMetric error if the existing metric's type
+// differs from the constructed one.
+func GetOrRegisterE(name string, ctor func() interface{}) (interface{}, error) {
+	return registryGetOrRegisterE(DefaultRegistry, name, ctor)
+}
+
+// Gets an existing metric or registers the one returned by the given
+// constructor, which is passed the name.
+func GetOrRegisterNamed(name string, ctor func(string) interface{}) interface{} {
+	return registryGetOrRegisterNamed(DefaultRegistry, name, ctor)
+}
+
+// Names returns the names of the registered metrics in lexical order.
+func Names() []string {
+	return registryNames(DefaultRegistry)
+}
+
+// OnRegister calls the given function each time a metric is registered.
+func OnRegister(f func(string, interface{})) {
+	registryOnRegister(DefaultRegistry, f)
+}
+
+// OnUnregister calls the given function each time a metric is unregistered.
+func OnUnregister(f func(string)) {
+	registryOnUnregister(DefaultRegistry, f)
+}
+
+// Gets an existing metric or registers the given one, never calling it even if
+// it's a function.
+func GetOrRegisterValue(name string, i interface{}) interface{} {
+	return registryGetOrRegisterValue(DefaultRegistry, name, i)
+}
+
 // Register the given metric under the given name.  Returns a DuplicateMetric
 // if a metric by the given name is already registered.
 func Register(name string, i interface{}) error {
@@ -352,3 +1080,176 @@ func RunHealthchecks() {
 func Unregister(name string) {
 	DefaultRegistry.Unregister(name)
 }
+
+// SnapshotRegistry returns read-only copies of all the metrics in r keyed by
+// name, taken by its Snapshot if it's a SnapshotableRegistry and otherwise
+// one by one by Each.
+func SnapshotRegistry(r Registry) map[string]interface{} {
+	if s, ok := r.(SnapshotableRegistry); ok {
+		return s.Snapshot()
+	}
+	snapshot := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) { snapshot[name] = snapshotMetric(i) })
+	return snapshot
+}
+
+// RegistryDescription returns the help text and unit of the metric registered
+// under name in r and whether it was described, which it can't have been
+// unless r is a DescribableRegistry.
+func RegistryDescription(r Registry, name string) (help, unit string, ok bool) {
+	if d, ok := r.(DescribableRegistry); ok {
+		return d.Description(name)
+	}
+	return "", "", false
+}
+
+// registryAlias makes the metric registered under name in r also available
+// under alias or, unless r is an AliasingRegistry, registers it under alias
+// too.
+func registryAlias(r Registry, name, alias string) error {
+	if a, ok := r.(AliasingRegistry); ok {
+		return a.Alias(name, alias)
+	}
+	i := r.Get(name)
+	if nil == i {
+		return UnknownMetric(name)
+	}
+	return r.Register(alias, i)
+}
+
+// registryDescribe sets the help text and unit of the metric registered under
+// name in r if it's a DescribableRegistry.
+func registryDescribe(r Registry, name, help, unit string) {
+	if d, ok := r.(DescribableRegistry); ok {
+		d.Describe(name, help, unit)
+	}
+}
+
+// registryEachFiltered calls fn for each metric in r for which pred returns
+// true.
+func registryEachFiltered(r Registry, pred func(string, interface{}) bool, fn func(string, interface{})) {
+	if e, ok := r.(EnumerableRegistry); ok {
+		e.EachFiltered(pred, fn)
+		return
+	}
+	r.Each(func(name string, i interface{}) {
+		if pred(name, i) {
+			fn(name, i)
+		}
+	})
+}
+
+// registryGetOrRegisterE gets an existing metric from r or registers the one
+// returned by ctor, returning a DuplicateMetric error if the existing metric's
+// type differs from one ctor constructs.
+func registryGetOrRegisterE(r Registry, name string, ctor func() interface{}) (interface{}, error) {
+	if c, ok := r.(ConstructingRegistry); ok {
+		return c.GetOrRegisterE(name, ctor)
+	}
+	var constructed interface{}
+	metric := r.GetOrRegister(name, func() interface{} {
+		constructed = ctor()
+		return constructed
+	})
+	if nil != constructed {
+		return metric, nil
+	}
+	i := ctor()
+	if s, ok := i.(Stoppable); ok {
+		s.Stop()
+	}
+	if reflect.TypeOf(metric) != reflect.TypeOf(i) {
+		return metric, DuplicateMetric(name)
+	}
+	return metric, nil
+}
+
+// registryGetOrRegisterNamed gets an existing metric from r or registers the
+// one returned by ctor, which is passed name.
+func registryGetOrRegisterNamed(r Registry, name string, ctor func(string) interface{}) interface{} {
+	if c, ok := r.(ConstructingRegistry); ok {
+		return c.GetOrRegisterNamed(name, ctor)
+	}
+	return r.GetOrRegister(name, func() interface{} { return ctor(name) })
+}
+
+// registryGetOrRegisterValue gets an existing metric from r or registers i,
+// never calling it even if it's a function.
+func registryGetOrRegisterValue(r Registry, name string, i interface{}) interface{} {
+	if c, ok := r.(ConstructingRegistry); ok {
+		return c.GetOrRegisterValue(name, i)
+	}
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	if err := r.Register(name, i); nil == err {
+		return i
+	}
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	return nilMetric(i)
+}
+
+// registryNames returns the names of the metrics in r in lexical order.
+func registryNames(r Registry) []string {
+	if e, ok := r.(EnumerableRegistry); ok {
+		return e.Names()
+	}
+	var names []string
+	r.Each(func(name string, _ interface{}) { names = append(names, name) })
+	sort.Strings(names)
+	return names
+}
+
+// registryOnRegister calls f each time a metric is registered in r if it's an
+// ObservableRegistry.
+func registryOnRegister(r Registry, f func(string, interface{})) {
+	if o, ok := r.(ObservableRegistry); ok {
+		o.OnRegister(f)
+	}
+}
+
+// registryOnUnregister calls f each time a metric is unregistered from r if
+// it's an ObservableRegistry.
+func registryOnUnregister(r Registry, f func(string)) {
+	if o, ok := r.(ObservableRegistry); ok {
+		o.OnUnregister(f)
+	}
+}
+
+// registrySortedEach calls f for each metric in r in lexical order by name.
+func registrySortedEach(r Registry, f func(string, interface{})) {
+	if e, ok := r.(EnumerableRegistry); ok {
+		e.SortedEach(f)
+		return
+	}
+	metrics := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) { metrics[name] = i })
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, metrics[name])
+	}
+}
+
+// registryUnregisterMatching unregisters every metric in r for which f
+// returns true.
+func registryUnregisterMatching(r Registry, f func(string, interface{}) bool) {
+	if e, ok := r.(EnumerableRegistry); ok {
+		e.UnregisterMatching(f)
+		return
+	}
+	var names []string
+	r.Each(func(name string, i interface{}) {
+		if f(name, i) {
+			names = append(names, name)
+		}
+	})
+	for _, name := range names {
+		r.Unregister(name)
+	}
+}
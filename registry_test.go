@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -119,20 +123,495 @@ func TestRegistryGetOrRegisterWithLazyInstantiation(t *testing.T) {
 	}
 }
 
+func TestRegistryGetOrRegisterE(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	i, err := r.GetOrRegisterE("foo", func() interface{} { return NewCounter() })
+	if nil != err {
+		t.Fatal(err)
+	}
+	i.(Counter).Inc(47)
+	if i, err := r.GetOrRegisterE("foo", func() interface{} { return NewCounter() }); nil != err || 47 != i.(Counter).Count() {
+		t.Fatal(i, err)
+	}
+	l := arbiter.len()
+	i, err = r.GetOrRegisterE("foo", func() interface{} { return NewThisMeter() })
+	if _, ok := err.(DuplicateMetric); !ok {
+		t.Fatal(err)
+	}
+	if _, ok := i.(Counter); !ok {
+		t.Fatal(i)
+	}
+	if arbiter.len() != l {
+		t.Errorf("arbiter.meters: %d != %d\n", l, arbiter.len())
+	}
+}
+
+func TestRegistryGetOrRegisterEClosures(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	defer r.UnregisterAll()
+	ctor := func(i interface{}) func() interface{} { return func() interface{} { return i } }
+	if _, err := r.GetOrRegisterE("foo", ctor(NewCounter())); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := r.GetOrRegisterE("foo", ctor(NewGauge())); DuplicateMetric("foo") != err {
+		t.Errorf("r.GetOrRegisterE(\"foo\"): %v != %v\n", DuplicateMetric("foo"), err)
+	}
+	if _, err := r.GetOrRegisterE("bar", ctor(NewGauge())); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := r.GetOrRegisterE("bar", ctor(NewGauge())); nil != err {
+		t.Errorf("r.GetOrRegisterE(\"bar\"): nil != %v\n", err)
+	}
+	l := arbiter.len()
+	for i := 0; i < 3; i++ {
+		if _, err := r.GetOrRegisterE("foo", func() interface{} { return NewThisMeter() }); DuplicateMetric("foo") != err {
+			t.Errorf("r.GetOrRegisterE(): %v != %v\n", DuplicateMetric("foo"), err)
+		}
+	}
+	if arbiter.len() != l {
+		t.Errorf("arbiter.meters: %d != %d\n", l, arbiter.len())
+	}
+}
+
+func TestPrefixedRegistryGetOrRegisterE(t *testing.T) {
+	r := NewRegistry()
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	if _, err := pr.GetOrRegisterE("foo", func() interface{} { return NewCounter() }); nil != err {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get("prefix.foo").(Counter); !ok {
+		t.Fatal(r.Get("prefix.foo"))
+	}
+}
+
+func TestRegistryGetOrRegisterNamed(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	calls := 0
+	ctor := func(name string) interface{} {
+		calls++
+		r.Describe(name, "requests to "+name, "requests")
+		return NewCounter()
+	}
+	for _, endpoint := range []string{"/bar", "/foo", "/bar"} {
+		r.GetOrRegisterNamed(endpoint, ctor).(Counter).Inc(1)
+	}
+	if 2 != calls {
+		t.Errorf("calls: 2 != %v\n", calls)
+	}
+	if help, unit, ok := r.Description("/foo"); !ok || "requests to /foo" != help || "requests" != unit {
+		t.Errorf("r.Description(\"/foo\"): requests to /foo requests != %v %v\n", help, unit)
+	}
+	if c := r.Get("/bar").(Counter); 2 != c.Count() {
+		t.Errorf("c.Count(): 2 != %v\n", c.Count())
+	}
+}
+
+func TestPrefixedRegistryGetOrRegisterNamed(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	pr.GetOrRegisterNamed("foo", func(name string) interface{} {
+		pr.Describe(name, "the "+name, "")
+		return NewCounter()
+	})
+	if _, ok := r.Get("prefix.foo").(Counter); !ok {
+		t.Fatal(r.Get("prefix.foo"))
+	}
+	if help, _, ok := r.Description("prefix.foo"); !ok || "the foo" != help {
+		t.Errorf("r.Description(\"prefix.foo\"): the foo != %v\n", help)
+	}
+}
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	c := NewRegisteredCounter("foo", r)
+	m := NewRegisteredThisMeter("bar", r)
+	defer m.Stop()
+	c.Inc(47)
+	m.Mark(47)
+	snapshot := r.Snapshot()
+	c.Inc(1)
+	m.Mark(1)
+	if 2 != len(snapshot) {
+		t.Fatal(snapshot)
+	}
+	if cs, ok := snapshot["foo"].(CounterSnapshot); !ok || 47 != cs.Count() {
+		t.Fatal(snapshot["foo"])
+	}
+	if ms, ok := snapshot["bar"].(*ThisMeterSnapshot); !ok || 47 != ms.Count() {
+		t.Fatal(snapshot["bar"])
+	}
+}
+
+func TestPrefixedRegistrySnapshot(t *testing.T) {
+	r := NewRegistry()
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	r.Register("foo", NewCounter())
+	pr.Register("bar", NewCounter())
+	snapshot := pr.Snapshot()
+	if _, ok := snapshot["prefix.bar"]; !ok || 1 != len(snapshot) {
+		t.Fatal(snapshot)
+	}
+}
+
 func TestRegistryUnregister(t *testing.T) {
-	l := len(arbiter.meters)
+	l := arbiter.len()
 	r := NewRegistry()
 	r.Register("foo", NewCounter())
 	r.Register("bar", NewThisMeter())
 	r.Register("baz", NewTimer())
-	if len(arbiter.meters) != l+2 {
-		t.Errorf("arbiter.meters: %d != %d\n", l+2, len(arbiter.meters))
+	if arbiter.len() != l+2 {
+		t.Errorf("arbiter.meters: %d != %d\n", l+2, arbiter.len())
 	}
 	r.Unregister("foo")
 	r.Unregister("bar")
 	r.Unregister("baz")
-	if len(arbiter.meters) != l {
-		t.Errorf("arbiter.meters: %d != %d\n", l+2, len(arbiter.meters))
+	if arbiter.len() != l {
+		t.Errorf("arbiter.meters: %d != %d\n", l+2, arbiter.len())
+	}
+}
+
+func TestRegistryAlias(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	m := NewRegisteredThisMeter("requests", r)
+	if err := r.Alias("requests", "reqs"); nil != err {
+		t.Fatal(err)
+	}
+	GetMeter("reqs", r).Mark(2)
+	if count := m.Count(); 2 != count {
+		t.Errorf("m.Count(): 2 != %v\n", count)
+	}
+	names := make(map[string]bool)
+	r.Each(func(name string, i interface{}) { names[name] = m == i })
+	if !names["requests"] || !names["reqs"] || 2 != len(names) {
+		t.Errorf("r.Each(): %v\n", names)
+	}
+	if snapshot := r.Snapshot(); 2 != len(snapshot) || snapshot["requests"] != snapshot["reqs"] {
+		t.Errorf("r.Snapshot(): %v\n", snapshot)
+	}
+
+	// Unregistering the alias at the end of the deprecation window leaves
+	// the meter running.
+	r.Unregister("reqs")
+	if nil != r.Get("reqs") {
+		t.Error("r.Get(\"reqs\"): want == nil\n")
+	}
+	m.Mark(1)
+	if count := m.Count(); 3 != count {
+		t.Errorf("m.Count(): 3 != %v\n", count)
+	}
+
+	// Unregistering the name stops the meter and unregisters its aliases.
+	if err := r.Alias("requests", "reqs"); nil != err {
+		t.Fatal(err)
+	}
+	r.Unregister("requests")
+	if nil != r.Get("reqs") {
+		t.Error("r.Get(\"reqs\"): want == nil\n")
+	}
+	m.Mark(1)
+	if count := m.Count(); 3 != count {
+		t.Errorf("m.Count(): 3 != %v\n", count)
+	}
+}
+
+func TestRegistryAliasErrors(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	r.Register("foo", NewCounter())
+	r.Register("bar", NewCounter())
+	if err := r.Alias("baz", "qux"); UnknownMetric("baz") != err {
+		t.Errorf("r.Alias(): %v\n", err)
+	}
+	if err := r.Alias("foo", "bar"); DuplicateMetric("bar") != err {
+		t.Errorf("r.Alias(): %v\n", err)
+	}
+	if err := r.Register("bar", NewCounter()); DuplicateMetric("bar") != err {
+		t.Errorf("r.Register(): %v\n", err)
+	}
+}
+
+func TestPrefixedRegistryAlias(t *testing.T) {
+	r := NewRegistry()
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	c := NewRegisteredCounter("foo", pr)
+	if err := pr.Alias("foo", "bar"); nil != err {
+		t.Fatal(err)
+	}
+	if i := r.Get("prefix.bar"); c != i {
+		t.Errorf("r.Get(\"prefix.bar\"): %v != %v\n", c, i)
+	}
+}
+
+func TestRegistryMaxMetrics(t *testing.T) {
+	l := make(chanLogger, 2)
+	ErrorLogger = l
+	defer func() { ErrorLogger = nil }()
+	r := NewRegistry().(*StandardRegistry)
+	r.SetMaxMetrics(2)
+	GetOrRegisterCounter("foo", r)
+	GetOrRegisterCounter("bar", r)
+	if c := GetOrRegisterCounter("baz", r); (NilCounter{}) != c {
+		t.Errorf("GetOrRegisterCounter(): NilCounter{} != %T\n", c)
+	}
+	if 1 != len(l) || !strings.Contains(<-l, "maximum of 2 metrics") {
+		t.Error("ErrorLogger: registry not logged as full")
+	}
+	if m := GetOrRegisterThisMeter("qux", r); (NilThisMeter{}) != m {
+		t.Errorf("GetOrRegisterThisMeter(): NilThisMeter{} != %T\n", m)
+	}
+	if err := r.Register("baz", NewCounter()); ErrMaxMetrics != err {
+		t.Errorf("r.Register(): %v\n", err)
+	}
+	if i, err := r.GetOrRegisterE("baz", func() interface{} { return NewGauge() }); (NilGauge{}) != i || ErrMaxMetrics != err {
+		t.Errorf("r.GetOrRegisterE(): %v, %v\n", i, err)
+	}
+	if err := r.Alias("foo", "baz"); ErrMaxMetrics != err {
+		t.Errorf("r.Alias(): %v\n", err)
+	}
+	m := NewThisMeter()
+	defer m.Stop()
+	if err := r.Register("qux", m); ErrMaxMetrics != err {
+		t.Errorf("r.Register(): %v\n", err)
+	}
+	if got := r.GetOrRegisterValue("qux", m); (NilThisMeter{}) != got {
+		t.Errorf("r.GetOrRegisterValue(): NilThisMeter{} != %T\n", got)
+	}
+	if m.Mark(1); 1 != m.Count() {
+		t.Error("the registry stopped a meter it didn't construct")
+	}
+	var constructed ThisMeter
+	r.GetOrRegister("qux", func() interface{} { constructed = NewThisMeter(); return constructed })
+	if constructed.Mark(1); 0 != constructed.Count() {
+		t.Error("the registry didn't stop a meter it constructed")
+	}
+	if n := r.Len(); 2 != n {
+		t.Errorf("r.Len(): 2 != %v\n", n)
+	}
+	if c := GetOrRegisterCounter("foo", r); (NilCounter{}) == c {
+		t.Error("GetOrRegisterCounter(): want != NilCounter{}\n")
+	}
+	r.Unregister("bar")
+	if c := GetOrRegisterCounter("baz", r); (NilCounter{}) == c {
+		t.Error("GetOrRegisterCounter(): want != NilCounter{}\n")
+	}
+	if n := r.Len(); 2 != n {
+		t.Errorf("r.Len(): 2 != %v\n", n)
+	}
+}
+
+func TestPrefixedRegistryLen(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	r.Register("foo", NewCounter())
+	pr.Register("bar", NewCounter())
+	if n := pr.Len(); 1 != n {
+		t.Errorf("pr.Len(): 1 != %v\n", n)
+	}
+	if n := r.Len(); 2 != n {
+		t.Errorf("r.Len(): 2 != %v\n", n)
+	}
+}
+
+func TestRegistryNames(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	for _, name := range []string{"zzz", "bbb", "fff", "ggg"} {
+		r.Register(name, NewCounter())
+	}
+	r.Alias("bbb", "aaa")
+	if want, names := []string{"aaa", "bbb", "fff", "ggg", "zzz"}, r.Names(); !reflect.DeepEqual(want, names) {
+		t.Errorf("r.Names(): %v != %v\n", want, names)
+	}
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	pr.Register("foo", NewCounter())
+	if want, names := []string{"prefix.foo"}, pr.Names(); !reflect.DeepEqual(want, names) {
+		t.Errorf("pr.Names(): %v != %v\n", want, names)
+	}
+}
+
+func TestRegistryOnRegister(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	var registered, registered2, unregistered []string
+	r.OnRegister(func(name string, i interface{}) {
+		if r.Get(name) != i {
+			t.Errorf("r.Get(%q): %v != %v\n", name, i, r.Get(name))
+		}
+		registered = append(registered, name)
+	})
+	r.OnRegister(func(name string, _ interface{}) { registered2 = append(registered2, name) })
+	r.OnUnregister(func(name string) {
+		if nil != r.Get(name) {
+			t.Errorf("r.Get(%q): nil != %v\n", name, r.Get(name))
+		}
+		unregistered = append(unregistered, name)
+	})
+	r.Register("foo", NewCounter())
+	r.Register("foo", NewCounter())
+	GetOrRegisterCounter("bar", r)
+	GetOrRegisterCounter("bar", r)
+	r.Alias("bar", "baz")
+	r.Unregister("foo")
+	r.Unregister("foo")
+	r.Unregister("bar")
+	if want := []string{"foo", "bar", "baz"}; !reflect.DeepEqual(want, registered) {
+		t.Errorf("registered: %v != %v\n", want, registered)
+	}
+	if !reflect.DeepEqual(registered, registered2) {
+		t.Errorf("registered2: %v != %v\n", registered, registered2)
+	}
+	if want := []string{"foo", "bar", "baz"}; !reflect.DeepEqual(want, unregistered) {
+		t.Errorf("unregistered: %v != %v\n", want, unregistered)
+	}
+}
+
+func TestPrefixedRegistryOnRegister(t *testing.T) {
+	r := NewRegistry()
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	var registered, unregistered []string
+	pr.OnRegister(func(name string, _ interface{}) { registered = append(registered, name) })
+	pr.OnUnregister(func(name string) { unregistered = append(unregistered, name) })
+	r.Register("foo", NewCounter())
+	pr.Register("bar", NewCounter())
+	r.Unregister("foo")
+	pr.Unregister("bar")
+	if 1 != len(registered) || "prefix.bar" != registered[0] {
+		t.Errorf("registered: [prefix.bar] != %v\n", registered)
+	}
+	if 1 != len(unregistered) || "prefix.bar" != unregistered[0] {
+		t.Errorf("unregistered: [prefix.bar] != %v\n", unregistered)
+	}
+}
+
+func TestRegistryDescribe(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	if _, _, ok := r.Description("foo"); ok {
+		t.Error("r.Description(\"foo\"): ok before Describe")
+	}
+	r.Describe("foo", "Bytes read.", "bytes")
+	r.Register("foo", NewCounter())
+	r.Unregister("foo")
+	r.Register("foo", NewCounter())
+	if help, unit, ok := r.Description("foo"); !ok || "Bytes read." != help || "bytes" != unit {
+		t.Errorf("r.Description(\"foo\"): Bytes read. bytes true != %v %v %v\n", help, unit, ok)
+	}
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	pr.Describe("bar", "Bars.", "")
+	if help, _, ok := r.Description("prefix.bar"); !ok || "Bars." != help {
+		t.Errorf("r.Description(\"prefix.bar\"): Bars. true != %v %v\n", help, ok)
+	}
+}
+
+func TestRegistryEachFiltered(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	r.Register("foo", NewCounter())
+	r.Register("bar", NewGauge())
+	r.Register("baz", NewCounter())
+	r.Register("qux", NewGaugeFloat64())
+	isCounter := func(_ string, i interface{}) bool {
+		_, ok := i.(Counter)
+		return ok
+	}
+	var names []string
+	r.EachFiltered(isCounter, func(name string, i interface{}) {
+		if _, ok := i.(Counter); !ok {
+			t.Errorf("%s: Counter != %T\n", name, i)
+		}
+		names = append(names, name)
+	})
+	sort.Strings(names)
+	if want := []string{"baz", "foo"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("names: %v != %v\n", want, names)
+	}
+}
+
+func TestPrefixedRegistryEachFiltered(t *testing.T) {
+	r := NewRegistry()
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	r.Register("foo", NewCounter())
+	pr.Register("bar", NewCounter())
+	pr.Register("baz", NewGauge())
+	var names []string
+	pr.EachFiltered(func(_ string, i interface{}) bool {
+		_, ok := i.(Counter)
+		return ok
+	}, func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	if want := []string{"prefix.bar"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("names: %v != %v\n", want, names)
+	}
+}
+
+func TestRegistrySortedEach(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	for _, name := range []string{"zzz", "bbb", "fff", "ggg"} {
+		r.Register(name, NewCounter())
+	}
+	var names []string
+	r.SortedEach(func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	if s := strings.Join(names, ","); "bbb,fff,ggg,zzz" != s {
+		t.Errorf("r.SortedEach(): bbb,fff,ggg,zzz != %s\n", s)
+	}
+}
+
+func TestPrefixedRegistrySortedEach(t *testing.T) {
+	r := NewRegistry()
+	r.Register("aaa", NewCounter())
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	pr.Register("bbb", NewCounter())
+	pr.Register("aaa", NewCounter())
+	var names []string
+	pr.SortedEach(func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	if s := strings.Join(names, ","); "prefix.aaa,prefix.bbb" != s {
+		t.Errorf("pr.SortedEach(): prefix.aaa,prefix.bbb != %s\n", s)
+	}
+}
+
+func TestRegistryUnregisterMatching(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	var meters []*StandardThisMeter
+	for i := 0; i < 10; i++ {
+		meters = append(meters, NewRegisteredThisMeter(fmt.Sprintf("tenant.%d.requests.%d", i%2, i), r).(*StandardThisMeter))
+		r.Register(fmt.Sprintf("tenant.%d.errors.%d", i%2, i), NewCounter())
+	}
+	defer r.UnregisterAll()
+	r.UnregisterMatching(func(name string, _ interface{}) bool {
+		return strings.HasPrefix(name, "tenant.0.")
+	})
+	r.Each(func(name string, _ interface{}) {
+		if strings.HasPrefix(name, "tenant.0.") {
+			t.Errorf("%s wasn't unregistered\n", name)
+		}
+	})
+	if n := len(r.Snapshot()); 10 != n {
+		t.Errorf("len(r.Snapshot()): 10 != %d\n", n)
+	}
+	for i, m := range meters {
+		if 0 == i%2 && arbiter.has(m) {
+			t.Errorf("arbiter still references meter %d\n", i)
+		}
+	}
+}
+
+func TestPrefixedRegistryUnregisterMatching(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", NewCounter())
+	pr := NewPrefixedChildRegistry(r, "prefix.").(*PrefixedRegistry)
+	pr.Register("foo", NewCounter())
+	pr.Register("bar", NewCounter())
+	pr.UnregisterMatching(func(name string, _ interface{}) bool {
+		return "prefix.bar" != name
+	})
+	if nil == r.Get("foo") {
+		t.Error("foo outside the prefix was unregistered")
+	}
+	if nil != pr.Get("foo") {
+		t.Error("prefix.foo wasn't unregistered")
+	}
+	if nil == pr.Get("bar") {
+		t.Error("prefix.bar was unregistered")
 	}
 }
 
@@ -220,6 +699,31 @@ func TestPrefixedRegistryUnregister(t *testing.T) {
 	}
 }
 
+func TestPrefixedRegistryUnregisterAll(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", NewCounter())
+	pr := NewPrefixedChildRegistry(r, "prefix.")
+	pr.Register("foo", NewCounter())
+	pr.UnregisterAll()
+	if nil == r.Get("foo") {
+		t.Error("foo outside the prefix was unregistered")
+	}
+	if nil != r.Get("prefix.foo") {
+		t.Error("prefix.foo wasn't unregistered")
+	}
+}
+
+func TestPrefixedRegistryGetAll(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", NewCounter())
+	pr := NewPrefixedChildRegistry(r, "prefix.")
+	pr.Register("foo", NewCounter())
+	all := pr.GetAll()
+	if _, ok := all["prefix.foo"]; 1 != len(all) || !ok {
+		t.Fatal(all)
+	}
+}
+
 func TestPrefixedRegistryGet(t *testing.T) {
 	pr := NewPrefixedRegistry("prefix.")
 	name := "foo"
@@ -303,3 +807,80 @@ func TestWalkRegistries(t *testing.T) {
 	}
 
 }
+
+// plainRegistry is a Registry which implements none of the optional
+// interfaces, as one from outside this package might not.
+type plainRegistry struct{ Registry }
+
+func TestRegistryOptionalFallbacks(t *testing.T) {
+	r := plainRegistry{NewRegistry()}
+	NewRegisteredCounter("foo", r).Inc(47)
+	NewRegisteredGauge("bar", r).Update(48)
+
+	if c, ok := SnapshotRegistry(r)["foo"].(CounterSnapshot); !ok || 47 != c.Count() {
+		t.Errorf("SnapshotRegistry(): 47 != %v\n", SnapshotRegistry(r)["foo"])
+	}
+	registryDescribe(r, "foo", "things", "")
+	if _, _, ok := RegistryDescription(r, "foo"); ok {
+		t.Error("RegistryDescription(): ok")
+	}
+	if names := registryNames(r); !reflect.DeepEqual([]string{"bar", "foo"}, names) {
+		t.Errorf("registryNames(): [bar foo] != %v\n", names)
+	}
+	var sorted []string
+	registrySortedEach(r, func(name string, _ interface{}) { sorted = append(sorted, name) })
+	if !reflect.DeepEqual([]string{"bar", "foo"}, sorted) {
+		t.Errorf("registrySortedEach(): [bar foo] != %v\n", sorted)
+	}
+	if _, err := registryGetOrRegisterE(r, "foo", func() interface{} { return NewGauge() }); DuplicateMetric("foo") != err {
+		t.Errorf("registryGetOrRegisterE(): %v != %v\n", DuplicateMetric("foo"), err)
+	}
+	if i, err := registryGetOrRegisterE(r, "baz", func() interface{} { return NewCounter() }); nil != err || i != r.Get("baz") {
+		t.Errorf("registryGetOrRegisterE(): %v %v\n", i, err)
+	}
+	if i := registryGetOrRegisterValue(r, "bar", NewGauge()); i != r.Get("bar") {
+		t.Errorf("registryGetOrRegisterValue(): %v != %v\n", r.Get("bar"), i)
+	}
+	if err := registryAlias(r, "foo", "foo.old"); nil != err || r.Get("foo") != r.Get("foo.old") {
+		t.Errorf("registryAlias(): %v\n", err)
+	}
+	registryUnregisterMatching(r, func(name string, _ interface{}) bool { return strings.HasPrefix(name, "ba") })
+	if names := registryNames(r); !reflect.DeepEqual([]string{"foo", "foo.old"}, names) {
+		t.Errorf("registryNames(): [foo foo.old] != %v\n", names)
+	}
+}
+
+func TestPrefixedRegistryNonStandardUnderlying(t *testing.T) {
+	for _, underlying := range []Registry{plainRegistry{NewRegistry()}, MergedRegistry(NewRegistry())} {
+		pr := NewPrefixedChildRegistry(underlying, "prefix.").(*PrefixedRegistry)
+		if m, ok := underlying.(*mergedRegistry); ok {
+			NewRegisteredCounter("prefix.foo", m.regs[0]).Inc(47)
+			NewRegisteredCounter("other", m.regs[0])
+		} else {
+			NewRegisteredCounter("foo", pr).Inc(47)
+			NewRegisteredCounter("other", underlying)
+		}
+		pr.OnRegister(func(string, interface{}) {})
+		pr.OnUnregister(func(string) {})
+
+		if data := pr.GetAll(); 1 != len(data) || int64(47) != data["prefix.foo"]["count"] {
+			t.Errorf("%T: pr.GetAll(): %v\n", underlying, data)
+		}
+		if snapshot := pr.Snapshot(); 1 != len(snapshot) || nil == snapshot["prefix.foo"] {
+			t.Errorf("%T: pr.Snapshot(): %v\n", underlying, snapshot)
+		}
+		if names := pr.Names(); !reflect.DeepEqual([]string{"prefix.foo"}, names) {
+			t.Errorf("%T: pr.Names(): [prefix.foo] != %v\n", underlying, names)
+		}
+		var sorted, filtered []string
+		pr.SortedEach(func(name string, _ interface{}) { sorted = append(sorted, name) })
+		pr.EachFiltered(func(string, interface{}) bool { return true }, func(name string, _ interface{}) { filtered = append(filtered, name) })
+		if !reflect.DeepEqual([]string{"prefix.foo"}, sorted) || !reflect.DeepEqual([]string{"prefix.foo"}, filtered) {
+			t.Errorf("%T: sorted, filtered: %v %v\n", underlying, sorted, filtered)
+		}
+		pr.UnregisterAll()
+		if _, merged := underlying.(*mergedRegistry); !merged && (nil != underlying.Get("prefix.foo") || nil == underlying.Get("other")) {
+			t.Errorf("%T: pr.UnregisterAll(): %v %v\n", underlying, underlying.Get("prefix.foo"), underlying.Get("other"))
+		}
+	}
+}
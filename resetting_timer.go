@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResettingTimers capture the durations of events and compute percentiles
+// over only those recorded since the last snapshot.  Taking a Snapshot clears
+// the timer, so each ResettingTimer should have a single reader, typically
+// the exporter flushing it.
+type ResettingTimer interface {
+	Mean() float64
+	Percentiles([]float64) []int64
+	Snapshot() ResettingTimer
+	Time(func())
+	Update(time.Duration)
+	UpdateSince(time.Time)
+	Values() []int64
+}
+
+// GetOrRegisterResettingTimer returns an existing ResettingTimer or
+// constructs and registers a new StandardResettingTimer.
+func GetOrRegisterResettingTimer(name string, r Registry) ResettingTimer {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewResettingTimer).(ResettingTimer)
+}
+
+// NewRegisteredResettingTimer constructs and registers a new
+// StandardResettingTimer.
+func NewRegisteredResettingTimer(name string, r Registry) ResettingTimer {
+	c := NewResettingTimer()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// NewResettingTimer constructs a new StandardResettingTimer.
+func NewResettingTimer() ResettingTimer {
+	if UseNilMetrics || UseNilTimers {
+		return NilResettingTimer{}
+	}
+	return &StandardResettingTimer{}
+}
+
+// NilResettingTimer is a no-op ResettingTimer.
+type NilResettingTimer struct{}
+
+// Mean is a no-op.
+func (NilResettingTimer) Mean() float64 { return 0.0 }
+
+// Percentiles is a no-op.
+func (NilResettingTimer) Percentiles(ps []float64) []int64 {
+	return make([]int64, len(ps))
+}
+
+// Snapshot is a no-op.
+func (NilResettingTimer) Snapshot() ResettingTimer { return NilResettingTimer{} }
+
+// Time is a no-op.
+func (NilResettingTimer) Time(func()) {}
+
+// Update is a no-op.
+func (NilResettingTimer) Update(time.Duration) {}
+
+// UpdateSince is a no-op.
+func (NilResettingTimer) UpdateSince(time.Time) {}
+
+// Values is a no-op.
+func (NilResettingTimer) Values() []int64 { return []int64{} }
+
+// StandardResettingTimer is the standard implementation of a ResettingTimer
+// and keeps every value recorded since the last snapshot.
+type StandardResettingTimer struct {
+	mutex  sync.Mutex
+	values []int64
+}
+
+// Mean returns the mean of the values recorded since the last snapshot.
+func (t *StandardResettingTimer) Mean() float64 {
+	return SampleMean(t.Values())
+}
+
+// Percentiles returns a slice of arbitrary percentiles of the values recorded
+// since the last snapshot.
+func (t *StandardResettingTimer) Percentiles(ps []float64) []int64 {
+	values := t.Values()
+	sort.Sort(int64Slice(values))
+	return resettingTimerPercentiles(values, ps)
+}
+
+// Snapshot returns a read-only copy of the values recorded since the last
+// snapshot and clears the timer.  A registry's Snapshot doesn't call it, so
+// only the exporters which write ResettingTimers clear them.
+func (t *StandardResettingTimer) Snapshot() ResettingTimer {
+	t.mutex.Lock()
+	values := t.values
+	t.values = nil
+	t.mutex.Unlock()
+	sort.Sort(int64Slice(values))
+	return &ResettingTimerSnapshot{values: values}
+}
+
+// Record the duration of the execution of the given function.  The duration
+// is recorded even if f panics.
+func (t *StandardResettingTimer) Time(f func()) {
+	defer t.UpdateSince(time.Now())
+	f()
+}
+
+// Record the duration of an event.
+func (t *StandardResettingTimer) Update(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values = append(t.values, int64(d))
+}
+
+// Record the duration of an event that started at a time and ends now.
+func (t *StandardResettingTimer) UpdateSince(ts time.Time) {
+	t.Update(time.Since(ts))
+}
+
+// Values returns a copy of the values recorded since the last snapshot.
+func (t *StandardResettingTimer) Values() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	values := make([]int64, len(t.values))
+	copy(values, t.values)
+	return values
+}
+
+// peekResettingTimer returns a read-only copy of the values recorded by t
+// since its last Snapshot without clearing it.
+func peekResettingTimer(t ResettingTimer) ResettingTimer {
+	values := t.Values()
+	if _, ok := t.(*ResettingTimerSnapshot); ok {
+		values = append([]int64(nil), values...)
+	}
+	sort.Sort(int64Slice(values))
+	return &ResettingTimerSnapshot{values: values}
+}
+
+// ResettingTimerSnapshot is a read-only copy of the values recorded by a
+// ResettingTimer during one interval, kept in ascending order.
+type ResettingTimerSnapshot struct {
+	values []int64
+}
+
+// Mean returns the mean of the values at the time the snapshot was taken.
+func (t *ResettingTimerSnapshot) Mean() float64 {
+	return SampleMean(t.values)
+}
+
+// Percentiles returns a slice of arbitrary percentiles of the values at the
+// time the snapshot was taken.
+func (t *ResettingTimerSnapshot) Percentiles(ps []float64) []int64 {
+	return resettingTimerPercentiles(t.values, ps)
+}
+
+// Snapshot returns the snapshot.
+func (t *ResettingTimerSnapshot) Snapshot() ResettingTimer { return t }
+
+// Time panics.
+func (*ResettingTimerSnapshot) Time(func()) {
+	panic("Time called on a ResettingTimerSnapshot")
+}
+
+// Update panics.
+func (*ResettingTimerSnapshot) Update(time.Duration) {
+	panic("Update called on a ResettingTimerSnapshot")
+}
+
+// UpdateSince panics.
+func (*ResettingTimerSnapshot) UpdateSince(time.Time) {
+	panic("UpdateSince called on a ResettingTimerSnapshot")
+}
+
+// Values returns the values at the time the snapshot was taken, in ascending
+// order.
+func (t *ResettingTimerSnapshot) Values() []int64 { return t.values }
+
+// resettingTimerPercentiles returns the nearest-rank percentiles of the
+// sorted values, so every score is a value that was actually recorded.
+func resettingTimerPercentiles(values []int64, ps []float64) []int64 {
+	scores := make([]int64, len(ps))
+	size := len(values)
+	if 0 == size {
+		return scores
+	}
+	for i, p := range ps {
+		rank := int(math.Ceil(p * float64(size)))
+		if rank < 1 {
+			rank = 1
+		} else if rank > size {
+			rank = size
+		}
+		scores[i] = values[rank-1]
+	}
+	return scores
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkResettingTimer(b *testing.B) {
+	t := NewResettingTimer()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Update(time.Duration(i))
+	}
+}
+
+func TestGetOrRegisterResettingTimer(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredResettingTimer("foo", r).Update(47)
+	if tm := GetOrRegisterResettingTimer("foo", r); 1 != len(tm.Values()) {
+		t.Fatal(tm)
+	}
+}
+
+func TestResettingTimerSnapshotResets(t *testing.T) {
+	tm := NewResettingTimer()
+	for i := 100; i > 0; i-- {
+		tm.Update(time.Duration(i))
+	}
+	snapshot := tm.Snapshot()
+	if n := len(tm.Values()); 0 != n {
+		t.Errorf("len(tm.Values()): 0 != %v\n", n)
+	}
+	tm.Update(1000)
+	if mean := snapshot.Mean(); 50.5 != mean {
+		t.Errorf("snapshot.Mean(): 50.5 != %v\n", mean)
+	}
+	ps := snapshot.Percentiles([]float64{0, 0.5, 0.99, 1})
+	if 1 != ps[0] || 50 != ps[1] || 99 != ps[2] || 100 != ps[3] {
+		t.Errorf("snapshot.Percentiles(): [1 50 99 100] != %v\n", ps)
+	}
+	if mean := tm.Snapshot().Mean(); 1000 != mean {
+		t.Errorf("tm.Snapshot().Mean(): 1000 != %v\n", mean)
+	}
+}
+
+func TestResettingTimerSnapshotEmpty(t *testing.T) {
+	snapshot := NewResettingTimer().Snapshot()
+	if mean := snapshot.Mean(); 0 != mean {
+		t.Errorf("snapshot.Mean(): 0 != %v\n", mean)
+	}
+	if ps := snapshot.Percentiles([]float64{0.5}); 0 != ps[0] {
+		t.Errorf("snapshot.Percentiles(): [0] != %v\n", ps)
+	}
+}
+
+func TestResettingTimerSnapshotUpdatePanics(t *testing.T) {
+	snapshot := NewResettingTimer().Snapshot()
+	defer func() {
+		if nil == recover() {
+			t.Error("snapshot.Update() didn't panic")
+		}
+	}()
+	snapshot.Update(1)
+}
+
+func TestRegistrySnapshotKeepsResettingTimer(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	tm := NewRegisteredResettingTimer("foo", r)
+	tm.Update(47)
+	tm.Update(3)
+	r.GetAll()
+	snapshot := r.Snapshot()["foo"].(ResettingTimer)
+	if v := snapshot.Values(); 2 != len(v) || 3 != v[0] || 47 != v[1] {
+		t.Errorf("snapshot.Values(): [3 47] != %v\n", v)
+	}
+	if n := len(tm.Values()); 2 != n {
+		t.Errorf("len(tm.Values()): 2 != %v\n", n)
+	}
+	if n := len(tm.Snapshot().Values()); 2 != n {
+		t.Errorf("len(tm.Snapshot().Values()): 2 != %v\n", n)
+	}
+	if n := len(tm.Values()); 0 != n {
+		t.Errorf("len(tm.Values()): 0 != %v\n", n)
+	}
+}
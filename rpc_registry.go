@@ -0,0 +1,489 @@
+package metrics
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sort"
+)
+
+// ServeRegistry serves read-only access to r over net/rpc to the clients of
+// DialRegistry which connect to l, say so that a parent process can export
+// the metrics of its workers.  It blocks, serving each connection in its own
+// goroutine, until l is closed.
+func ServeRegistry(r Registry, l net.Listener) {
+	newRegistryServer(r).Accept(l)
+}
+
+// DialRegistry connects to a registry served by ServeRegistry at the given
+// TCP address and returns a read-only Registry of its metrics.  Each read
+// fetches snapshots of the remote metrics, so the metrics it returns are
+// read-only copies.  Methods which would register a metric return
+// ErrReadOnlyRegistry and those which would unregister one or configure the
+// registry are no-ops.  Histograms and timers are read as a summary of their
+// samples, so their Values are nil and percentiles other than the common ones
+// and their DefaultPercentiles are interpolated.  The Registry returned is an
+// io.Closer which closes the connection.
+func DialRegistry(addr string) (Registry, error) {
+	return DialRegistryWithConfig(RPCConfig{Addr: addr})
+}
+
+// RPCConfig provides a container with configuration parameters for
+// DialRegistryWithConfig.
+type RPCConfig struct {
+	Addr   string // TCP address of the registry served by ServeRegistry
+	Logger Logger // Logger for failed calls, the standard library's if nil
+}
+
+// DialRegistryWithConfig connects to a registry just like DialRegistry, but
+// it takes an RPCConfig instead.
+func DialRegistryWithConfig(c RPCConfig) (Registry, error) {
+	client, err := rpc.Dial("tcp", c.Addr)
+	if nil != err {
+		return nil, err
+	}
+	return &rpcRegistry{client: client, logger: loggerOrDefault(c.Logger)}, nil
+}
+
+func newRegistryServer(r Registry) *rpc.Server {
+	s := rpc.NewServer()
+	s.RegisterName("Registry", &registryService{r})
+	return s
+}
+
+// rpcMetric is a snapshot of a metric as sent by ServeRegistry.  Histograms
+// and timers are sent as a summary of their samples, with their scores at
+// rpcQuantiles and their DefaultPercentiles, rather than every value, so that
+// a big reservoir doesn't make every read as big.  Resetting timers, whose
+// values are their interface, are sent with their values.
+type rpcMetric struct {
+	Kind        string // as returned by metricKind
+	Count       int64
+	Float       float64 // of a FloatCounter or GaugeFloat64
+	Value       int64   // of a Gauge
+	Error       string  // of an unhealthy Healthcheck
+	Sum         int64
+	Min, Max    int64
+	Mean        float64
+	StdDev      float64
+	Variance    float64
+	Quantiles   []float64 // at which Scores were computed, in ascending order
+	Scores      []float64
+	Values      []int64    // of a ResettingTimer
+	Percentiles []float64  // DefaultPercentiles of a Histogram or Timer
+	Rates       [4]float64 // one-, five- and fifteen-minute and mean
+}
+
+// rpcQuantiles are the quantiles at which the scores of histograms and timers
+// are sent in addition to their DefaultPercentiles.
+var rpcQuantiles = []float64{0.5, 0.75, 0.9, 0.95, 0.99, 0.999}
+
+// rpcSummarized is the summary of a Histogram or Timer which is sent.
+type rpcSummarized interface {
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentiles([]float64) []float64
+	StdDev() float64
+	Sum() int64
+	Variance() float64
+}
+
+// summarize sets the summary fields of m from s.
+func (m *rpcMetric) summarize(s rpcSummarized) {
+	m.Count, m.Sum, m.Min, m.Max = s.Count(), s.Sum(), s.Min(), s.Max()
+	m.Mean, m.StdDev, m.Variance = s.Mean(), s.StdDev(), s.Variance()
+	m.Percentiles = ReportedPercentiles(s)
+	qs := append(append([]float64(nil), rpcQuantiles...), m.Percentiles...)
+	sort.Float64s(qs)
+	m.Quantiles = qs[:0]
+	for i, q := range qs {
+		if 0 == i || q != qs[i-1] {
+			m.Quantiles = append(m.Quantiles, q)
+		}
+	}
+	m.Scores = s.Percentiles(m.Quantiles)
+}
+
+// sample returns the read-only Sample of the summary in m.
+func (m *rpcMetric) sample() *rpcSample {
+	return &rpcSample{
+		count: m.Count, sum: m.Sum, min: m.Min, max: m.Max,
+		mean: m.Mean, stdDev: m.StdDev, variance: m.Variance,
+		quantiles: m.Quantiles, scores: m.Scores,
+	}
+}
+
+// encodeRPCMetric returns the rpcMetric of a metric snapshot.  A metric which
+// isn't of a kind a registry holds has an empty Kind.
+func encodeRPCMetric(i interface{}) rpcMetric {
+	m := rpcMetric{Kind: metricKind(i)}
+	switch metric := i.(type) {
+	case Counter:
+		m.Count = metric.Count()
+	case FloatCounter:
+		m.Float = metric.Count()
+	case Gauge:
+		m.Value = metric.Value()
+	case GaugeFloat64:
+		m.Float = metric.Value()
+	case Healthcheck:
+		if err := metric.Error(); nil != err {
+			m.Error = err.Error()
+		}
+	case Histogram:
+		m.summarize(metric)
+	case ResettingTimer:
+		m.Values = metric.Values()
+	case ThisMeter:
+		m.Count = metric.Count()
+		m.Rates = [4]float64{metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean()}
+	case Timer:
+		m.summarize(metric)
+		m.Rates = [4]float64{metric.Rate1(), metric.Rate5(), metric.Rate15(), metric.RateMean()}
+	}
+	return m
+}
+
+// decodeRPCMetric returns a read-only metric of an rpcMetric, or nil if it's
+// of an unknown kind.  A Healthcheck is returned with the remote status,
+// which Check leaves as it is.
+func decodeRPCMetric(m rpcMetric) interface{} {
+	switch m.Kind {
+	case "counter":
+		return CounterSnapshot(m.Count)
+	case "floatcounter":
+		return FloatCounterSnapshot(m.Float)
+	case "gauge":
+		return GaugeSnapshot(m.Value)
+	case "gaugefloat64":
+		return GaugeFloat64Snapshot(m.Float)
+	case "healthcheck":
+		h := &StandardHealthcheck{f: func(Healthcheck) {}}
+		if "" != m.Error {
+			h.err = errors.New(m.Error)
+		}
+		return h
+	case "histogram":
+		return &HistogramSnapshot{percentiles: m.Percentiles, sample: m.sample()}
+	case "meter":
+		return &ThisMeterSnapshot{count: m.Count, rate1: m.Rates[0], rate5: m.Rates[1], rate15: m.Rates[2], rateMean: m.Rates[3]}
+	case "resettingtimer":
+		return &ResettingTimerSnapshot{values: m.Values}
+	case "timer":
+		return &TimerSnapshot{
+			histogram: &HistogramSnapshot{percentiles: m.Percentiles, sample: m.sample()},
+			meter:     &ThisMeterSnapshot{count: m.Count, rate1: m.Rates[0], rate5: m.Rates[1], rate15: m.Rates[2], rateMean: m.Rates[3]},
+		}
+	}
+	return nil
+}
+
+// rpcSample is the read-only Sample of a histogram or timer read from a
+// remote registry, which holds only its summary.  Percentiles other than
+// those sent are interpolated linearly between the nearest sent, or the
+// minimum or maximum.  It holds no values.
+type rpcSample struct {
+	count, sum, min, max   int64
+	mean, stdDev, variance float64
+	quantiles, scores      []float64
+}
+
+// Clear panics.
+func (*rpcSample) Clear() {
+	panic("Clear called on an rpcSample")
+}
+
+// Count returns the number of values recorded by the remote sample.
+func (s *rpcSample) Count() int64 { return s.count }
+
+// Max returns the maximum value of the remote sample.
+func (s *rpcSample) Max() int64 { return s.max }
+
+// Mean returns the mean of the values of the remote sample.
+func (s *rpcSample) Mean() float64 { return s.mean }
+
+// Min returns the minimum value of the remote sample.
+func (s *rpcSample) Min() int64 { return s.min }
+
+// Percentile returns the score of the remote sample at the given quantile,
+// interpolated if it wasn't sent.
+func (s *rpcSample) Percentile(p float64) float64 {
+	if 0 == s.count {
+		return 0
+	}
+	i := sort.SearchFloat64s(s.quantiles, p)
+	if i < len(s.quantiles) && p == s.quantiles[i] {
+		return s.scores[i]
+	}
+	q0, v0, q1, v1 := 0.0, float64(s.min), 1.0, float64(s.max)
+	if 0 < i {
+		q0, v0 = s.quantiles[i-1], s.scores[i-1]
+	}
+	if i < len(s.quantiles) {
+		q1, v1 = s.quantiles[i], s.scores[i]
+	}
+	if p <= q0 {
+		return v0
+	}
+	if q1 <= p {
+		return v1
+	}
+	return v0 + (v1-v0)*(p-q0)/(q1-q0)
+}
+
+// Percentiles returns the scores of the remote sample at the given quantiles.
+func (s *rpcSample) Percentiles(ps []float64) []float64 {
+	scores := make([]float64, len(ps))
+	for i, p := range ps {
+		scores[i] = s.Percentile(p)
+	}
+	return scores
+}
+
+// Size returns zero since the sample holds no values.
+func (*rpcSample) Size() int { return 0 }
+
+// Snapshot returns the sample.
+func (s *rpcSample) Snapshot() Sample { return s }
+
+// StdDev returns the standard deviation of the values of the remote sample.
+func (s *rpcSample) StdDev() float64 { return s.stdDev }
+
+// Sum returns the sum of the values recorded by the remote sample.
+func (s *rpcSample) Sum() int64 { return s.sum }
+
+// Update panics.
+func (*rpcSample) Update(int64) {
+	panic("Update called on an rpcSample")
+}
+
+// Values returns nil since the sample holds no values.
+func (*rpcSample) Values() []int64 { return nil }
+
+// Variance returns the variance of the values of the remote sample.
+func (s *rpcSample) Variance() float64 { return s.variance }
+
+// registryService is the receiver of the RPCs served by ServeRegistry.
+type registryService struct {
+	r Registry
+}
+
+// Description replies with the help and unit of the named metric, or nothing
+// if it has no description.
+func (s *registryService) Description(name string, reply *[]string) error {
+	if help, unit, ok := RegistryDescription(s.r, name); ok {
+		*reply = []string{help, unit}
+	}
+	return nil
+}
+
+// Get replies with a snapshot of the named metric, if there is one.
+func (s *registryService) Get(name string, reply *map[string]rpcMetric) error {
+	*reply = make(map[string]rpcMetric)
+	if i := s.r.Get(name); nil != i {
+		(*reply)[name] = encodeRPCMetric(snapshotMetric(i))
+	}
+	return nil
+}
+
+// Names replies with the registry's Names.
+func (s *registryService) Names(_ int, reply *[]string) error {
+	*reply = registryNames(s.r)
+	return nil
+}
+
+// RunHealthchecks runs the registry's healthchecks.
+func (s *registryService) RunHealthchecks(_ int, _ *int) error {
+	s.r.RunHealthchecks()
+	return nil
+}
+
+// Snapshot replies with a Snapshot of the registry.
+func (s *registryService) Snapshot(_ int, reply *map[string]rpcMetric) error {
+	snapshot := SnapshotRegistry(s.r)
+	*reply = make(map[string]rpcMetric, len(snapshot))
+	for name, i := range snapshot {
+		(*reply)[name] = encodeRPCMetric(i)
+	}
+	return nil
+}
+
+// rpcRegistry is a read-only Registry of the metrics of a registry served by
+// ServeRegistry.  A failed call is logged and read as an empty registry.
+type rpcRegistry struct {
+	client *rpc.Client
+	logger Logger
+}
+
+// Alias returns ErrReadOnlyRegistry.
+func (r *rpcRegistry) Alias(string, string) error {
+	return ErrReadOnlyRegistry
+}
+
+// Close closes the connection to the remote registry.
+func (r *rpcRegistry) Close() error {
+	return r.client.Close()
+}
+
+// Describe is a no-op.
+func (r *rpcRegistry) Describe(string, string, string) {}
+
+// Description returns the description of name in the remote registry.
+func (r *rpcRegistry) Description(name string) (help, unit string, ok bool) {
+	var reply []string
+	if r.call("Registry.Description", name, &reply); 2 == len(reply) {
+		return reply[0], reply[1], true
+	}
+	return "", "", false
+}
+
+// Each calls the given function for a snapshot of each remote metric.
+func (r *rpcRegistry) Each(f func(string, interface{})) {
+	for name, i := range r.Snapshot() {
+		f(name, i)
+	}
+}
+
+// EachFiltered calls fn for a snapshot of each remote metric for which pred
+// returns true.
+func (r *rpcRegistry) EachFiltered(pred func(string, interface{}) bool, fn func(string, interface{})) {
+	r.Each(func(name string, i interface{}) {
+		if pred(name, i) {
+			fn(name, i)
+		}
+	})
+}
+
+// Get a snapshot of the remote metric by the given name or nil if none is
+// registered.
+func (r *rpcRegistry) Get(name string) interface{} {
+	var reply map[string]rpcMetric
+	r.call("Registry.Get", name, &reply)
+	if m, ok := reply[name]; ok {
+		return decodeRPCMetric(m)
+	}
+	return nil
+}
+
+// GetAll remote metrics.
+func (r *rpcRegistry) GetAll() map[string]map[string]interface{} {
+	return snapshotValues(r.Snapshot())
+}
+
+// GetOrRegister returns a snapshot of the existing remote metric or, since
+// nothing can be registered, a no-op metric of the kind of i, constructing and
+// stopping it if it's a function, so that GetOrRegisterCounter and the like
+// work.
+func (r *rpcRegistry) GetOrRegister(name string, i interface{}) interface{} {
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	return readOnlyMetric(i)
+}
+
+// GetOrRegisterE returns a snapshot of the existing remote metric or a no-op
+// metric of the kind ctor constructs and ErrReadOnlyRegistry.
+func (r *rpcRegistry) GetOrRegisterE(name string, ctor func() interface{}) (interface{}, error) {
+	if i := r.Get(name); nil != i {
+		return i, nil
+	}
+	return readOnlyMetric(ctor), ErrReadOnlyRegistry
+}
+
+// GetOrRegisterNamed returns a snapshot of the existing remote metric or,
+// since nothing can be registered, a no-op metric of the kind ctor constructs.
+func (r *rpcRegistry) GetOrRegisterNamed(name string, ctor func(string) interface{}) interface{} {
+	if i := r.Get(name); nil != i {
+		return i
+	}
+	return readOnlyMetric(func() interface{} { return ctor(name) })
+}
+
+// GetOrRegisterValue returns a snapshot of the existing remote metric or,
+// since nothing can be registered, a no-op metric of the kind of i.
+func (r *rpcRegistry) GetOrRegisterValue(name string, i interface{}) interface{} {
+	if metric := r.Get(name); nil != metric {
+		return metric
+	}
+	return nilMetric(i)
+}
+
+// Len returns the number of remote metrics.
+func (r *rpcRegistry) Len() int {
+	return len(r.Names())
+}
+
+// Names returns the names of the remote metrics in lexical order.
+func (r *rpcRegistry) Names() []string {
+	var names []string
+	r.call("Registry.Names", 0, &names)
+	return names
+}
+
+// OnRegister is a no-op since metrics registered remotely aren't observed.
+func (r *rpcRegistry) OnRegister(func(string, interface{})) {}
+
+// OnUnregister is a no-op since metrics unregistered remotely aren't
+// observed.
+func (r *rpcRegistry) OnUnregister(func(string)) {}
+
+// Register returns ErrReadOnlyRegistry.
+func (r *rpcRegistry) Register(string, interface{}) error {
+	return ErrReadOnlyRegistry
+}
+
+// RunHealthchecks runs the remote healthchecks.
+func (r *rpcRegistry) RunHealthchecks() {
+	var reply int
+	r.call("Registry.RunHealthchecks", 0, &reply)
+}
+
+// SetMaxMetrics is a no-op.
+func (r *rpcRegistry) SetMaxMetrics(int) {}
+
+// Snapshot returns read-only copies of all the remote metrics keyed by name,
+// from a single Snapshot of the remote registry.
+func (r *rpcRegistry) Snapshot() map[string]interface{} {
+	var reply map[string]rpcMetric
+	r.call("Registry.Snapshot", 0, &reply)
+	snapshot := make(map[string]interface{}, len(reply))
+	for name, m := range reply {
+		if i := decodeRPCMetric(m); nil != i {
+			snapshot[name] = i
+		}
+	}
+	return snapshot
+}
+
+// SortedEach calls the given function for a snapshot of each remote metric
+// in lexical order by name.
+func (r *rpcRegistry) SortedEach(f func(string, interface{})) {
+	snapshot := r.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, snapshot[name])
+	}
+}
+
+// Unregister is a no-op.
+func (r *rpcRegistry) Unregister(string) {}
+
+// UnregisterAll is a no-op.
+func (r *rpcRegistry) UnregisterAll() {}
+
+// UnregisterMatching is a no-op.
+func (r *rpcRegistry) UnregisterMatching(func(string, interface{}) bool) {}
+
+// call makes an RPC, logging rather than returning its error since the
+// Registry methods which read have no way to.
+func (r *rpcRegistry) call(method string, args interface{}, reply interface{}) {
+	if err := r.client.Call(method, args, reply); nil != err {
+		loggerOrDefault(r.logger).Printf("metrics: %s: %v", method, err)
+	}
+}
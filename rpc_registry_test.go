@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeRegistry serves r over an in-memory pipe and returns a client of it.
+func pipeRegistry(r Registry) *rpcRegistry {
+	server, client := net.Pipe()
+	go newRegistryServer(r).ServeConn(server)
+	return &rpcRegistry{client: rpc.NewClient(client)}
+}
+
+func TestRPCRegistry(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	NewRegisteredCounter("counter", r).Inc(47)
+	NewRegisteredGaugeFloat64("gaugefloat64", r).Update(2.5)
+	h := NewRegisteredHistogram("histogram", r, NewUniformSample(100))
+	for i := int64(1); i <= 100; i++ {
+		h.Update(i)
+	}
+	NewRegisteredThisMeter("meter", r).Mark(5)
+	NewRegisteredTimer("timer", r).Update(time.Second)
+	r.Register("healthcheck", NewHealthcheck(func(h Healthcheck) { h.Unhealthy(errors.New("down")) }))
+	r.Describe("counter", "things counted", "things")
+	defer r.UnregisterAll()
+
+	remote := pipeRegistry(r)
+	defer remote.Close()
+	if c, ok := remote.Get("counter").(Counter); !ok || 47 != c.Count() {
+		t.Errorf("remote.Get(\"counter\"): 47 != %v\n", remote.Get("counter"))
+	}
+	if nil != remote.Get("missing") {
+		t.Errorf("remote.Get(\"missing\"): nil != %v\n", remote.Get("missing"))
+	}
+	if g := remote.Get("gaugefloat64").(GaugeFloat64); 2.5 != g.Value() {
+		t.Errorf("g.Value(): 2.5 != %v\n", g.Value())
+	}
+	p := h.Percentile(0.95)
+	if h := remote.Get("histogram").(Histogram); 100 != h.Count() || 5050 != h.Sum() || p != h.Percentile(0.95) {
+		t.Errorf("h: 100 5050 %v != %v %v %v\n", p, h.Count(), h.Sum(), h.Percentile(0.95))
+	}
+	if m := remote.Get("meter").(ThisMeter); 5 != m.Count() {
+		t.Errorf("m.Count(): 5 != %v\n", m.Count())
+	}
+	if tm := remote.Get("timer").(Timer); 1 != tm.Count() || int64(time.Second) != tm.Max() {
+		t.Errorf("tm: 1 %v != %v %v\n", int64(time.Second), tm.Count(), tm.Max())
+	}
+	remote.RunHealthchecks()
+	if hc := remote.Get("healthcheck").(Healthcheck); nil == hc.Error() || "down" != hc.Error().Error() {
+		t.Errorf("hc.Error(): down != %v\n", hc.Error())
+	}
+	if help, unit, ok := remote.Description("counter"); !ok || "things counted" != help || "things" != unit {
+		t.Errorf("remote.Description(): things counted things != %v %v\n", help, unit)
+	}
+
+	var names []string
+	remote.SortedEach(func(name string, _ interface{}) { names = append(names, name) })
+	if 6 != len(names) || "counter" != names[0] || "timer" != names[5] {
+		t.Errorf("names: %v\n", names)
+	}
+	if 6 != remote.Len() {
+		t.Errorf("remote.Len(): 6 != %v\n", remote.Len())
+	}
+	if count := remote.GetAll()["counter"]["count"]; int64(47) != count {
+		t.Errorf("remote.GetAll(): 47 != %v\n", count)
+	}
+
+	if err := remote.Register("foo", NewCounter()); ErrReadOnlyRegistry != err {
+		t.Errorf("remote.Register(): %v != %v\n", ErrReadOnlyRegistry, err)
+	}
+	GetOrRegisterCounter("foo", remote).Inc(1)
+	if m := GetOrRegisterThisMeter("foo", remote); (NilThisMeter{}) != m {
+		t.Errorf("GetOrRegisterThisMeter(): NilThisMeter != %v\n", m)
+	}
+	if c := GetOrRegisterCounter("counter", remote); 47 != c.Count() {
+		t.Errorf("GetOrRegisterCounter(): 47 != %v\n", c.Count())
+	}
+	remote.Unregister("counter")
+	if nil == r.Get("counter") {
+		t.Error("remote.Unregister() unregistered counter")
+	}
+}
+
+func TestRPCRegistrySummary(t *testing.T) {
+	r := NewRegistry()
+	h := NewRegisteredHistogram("histogram", r, NewUniformSample(10000))
+	for i := int64(1); i <= 10000; i++ {
+		h.Update(i)
+	}
+	m := encodeRPCMetric(h.Snapshot())
+	if nil != m.Values {
+		t.Errorf("encodeRPCMetric(): %d values sent\n", len(m.Values))
+	}
+
+	remote := pipeRegistry(r)
+	defer remote.Close()
+	rh := remote.Get("histogram").(Histogram)
+	if h.Min() != rh.Min() || h.Max() != rh.Max() || h.Mean() != rh.Mean() || h.StdDev() != rh.StdDev() || h.Variance() != rh.Variance() {
+		t.Errorf("rh: %v %v %v %v != %v %v %v %v\n", h.Min(), h.Max(), h.Mean(), h.StdDev(), rh.Min(), rh.Max(), rh.Mean(), rh.StdDev())
+	}
+	for _, p := range append(ReportedPercentiles(rh), 0.9) {
+		if want, got := h.Percentile(p), rh.Percentile(p); want != got {
+			t.Errorf("rh.Percentile(%v): %v != %v\n", p, want, got)
+		}
+	}
+	// Between the scores sent at 0.5 and 0.75.
+	if want, got := (h.Percentile(0.5)+h.Percentile(0.75))/2, rh.Percentile(0.625); want != got {
+		t.Errorf("rh.Percentile(0.625): %v != %v\n", want, got)
+	}
+	if want, got := float64(h.Min()), rh.Percentile(0); want != got {
+		t.Errorf("rh.Percentile(0): %v != %v\n", want, got)
+	}
+}
+
+func TestServeRegistry(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(1)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go ServeRegistry(r, l)
+	remote, err := DialRegistry(l.Addr().String())
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer remote.(io.Closer).Close()
+	if c, ok := remote.Get("foo").(Counter); !ok || 1 != c.Count() {
+		t.Errorf("remote.Get(\"foo\"): 1 != %v\n", remote.Get("foo"))
+	}
+}
+
+func TestRPCRegistryLogger(t *testing.T) {
+	l := make(chanLogger, 1)
+	r := pipeRegistry(NewRegistry())
+	r.logger = l
+	r.Close()
+	if m := r.Get("foo"); nil != m {
+		t.Errorf("r.Get(): nil != %v\n", m)
+	}
+	select {
+	case line := <-l:
+		if !strings.HasPrefix(line, "metrics: Registry.Get: ") {
+			t.Errorf("logged %q\n", line)
+		}
+	default:
+		t.Error("failed call wasn't logged")
+	}
+}
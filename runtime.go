@@ -210,3 +210,29 @@ func RegisterRuntimeMemStats(r Registry) {
 	r.Register("runtime.NumThread", runtimeMetrics.NumThread)
 	r.Register("runtime.ReadMemStats", runtimeMetrics.ReadMemStats)
 }
+
+// RegisterRuntimeProfiles registers functional gauges reading the number of
+// goroutines and the number of records in the block and mutex profiles,
+// named runtime.Profiles.NumGoroutine, runtime.Profiles.Block and
+// runtime.Profiles.Mutex.  The block and mutex profiles are empty, and their
+// gauges zero, unless enabled with runtime.SetBlockProfileRate and
+// runtime.SetMutexProfileFraction.
+func RegisterRuntimeProfiles(r Registry) {
+	r.Register("runtime.Profiles.NumGoroutine", NewFunctionalGauge(func() int64 {
+		return int64(runtime.NumGoroutine())
+	}))
+	r.Register("runtime.Profiles.Block", NewFunctionalGauge(profileCount("block")))
+	r.Register("runtime.Profiles.Mutex", NewFunctionalGauge(profileCount("mutex")))
+}
+
+// profileCount returns a function counting the records in the named profile,
+// or returning zero if there's no such profile.
+func profileCount(name string) func() int64 {
+	p := pprof.Lookup(name)
+	return func() int64 {
+		if nil == p {
+			return 0
+		}
+		return int64(p.Count())
+	}
+}
@@ -75,6 +75,32 @@ func TestRuntimeMemStatsBlocking(t *testing.T) {
 	t.Log("i++ during time.Sleep:", <-ch)
 }
 
+func TestRuntimeProfiles(t *testing.T) {
+	r := NewRegistry()
+	RegisterRuntimeProfiles(r)
+	g := GetGauge("runtime.Profiles.NumGoroutine", r)
+	if nil == g {
+		t.Fatal("runtime.Profiles.NumGoroutine: want != nil\n")
+	}
+	before := g.Value()
+	ch := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		go func() { <-ch }()
+	}
+	defer close(ch)
+	// Allow for goroutines left by other tests exiting meanwhile.
+	if after := g.Value(); after < before+50 {
+		t.Errorf("g.Value(): %v < %v + 50\n", after, before)
+	}
+	for _, name := range []string{"runtime.Profiles.Block", "runtime.Profiles.Mutex"} {
+		if g := GetGauge(name, r); nil == g {
+			t.Errorf("%s: want != nil\n", name)
+		} else if v := g.Value(); v < 0 {
+			t.Errorf("%s: %v < 0\n", name, v)
+		}
+	}
+}
+
 func testRuntimeMemStatsBlocking(ch chan int) {
 	i := 0
 	for {
@@ -11,7 +11,8 @@ import (
 const rescaleThreshold = time.Hour
 
 // Samples maintain a statistically-significant selection of values from
-// a stream.
+// a stream.  Values always returns a copy of the values, never the sample's
+// own storage, so callers may sort or modify it freely.
 type Sample interface {
 	Clear()
 	Count() int64
@@ -29,6 +30,189 @@ type Sample interface {
 	Variance() float64
 }
 
+// MergeableSamples can merge in the values recorded by another sample, say to
+// combine the samples of a histogram sharded per core before computing its
+// percentiles.  A sample of the same kind is merged as exactly as the kind
+// allows; any other is merged by its Values, as if they'd been recorded.
+type MergeableSample interface {
+	Sample
+	Merge(Sample)
+}
+
+// EWMASample keeps the most recent values in a reservoir and weights them by
+// recency when computing percentiles, the newest with weight one and each
+// older value with 1-alpha times the weight of the one after it, so
+// percentiles follow a change in the distribution of values more quickly than
+// those of a uniform or exponentially-decaying sample.  The other statistics
+// are unweighted.
+type EWMASample struct {
+	alpha         float64
+	count         int64
+	mutex         sync.Mutex
+	next          int
+	reservoirSize int
+	sum           int64
+	values        []int64
+}
+
+// NewEWMASample constructs a new recency-weighted sample with the given
+// reservoir size and alpha, which must be between zero and one.  A reservoir
+// size less than one is taken to be one.
+func NewEWMASample(reservoirSize int, alpha float64) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	if reservoirSize < 1 {
+		reservoirSize = 1
+	}
+	return &EWMASample{
+		alpha:         alpha,
+		reservoirSize: reservoirSize,
+		values:        make([]int64, 0, reservoirSize),
+	}
+}
+
+// Clear clears all samples.
+func (s *EWMASample) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count = 0
+	s.next = 0
+	s.sum = 0
+	s.values = make([]int64, 0, s.reservoirSize)
+}
+
+// Count returns the number of samples recorded, which may exceed the
+// reservoir size.
+func (s *EWMASample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Max returns the maximum value in the sample, which may not be the maximum
+// value ever to be part of the sample.
+func (s *EWMASample) Max() int64 {
+	return SampleMax(s.Values())
+}
+
+// Mean returns the mean of the values in the sample.
+func (s *EWMASample) Mean() float64 {
+	return SampleMean(s.Values())
+}
+
+// Min returns the minimum value in the sample, which may not be the minimum
+// value ever to be part of the sample.
+func (s *EWMASample) Min() int64 {
+	return SampleMin(s.Values())
+}
+
+// Percentile returns an arbitrary recency-weighted percentile of values in
+// the sample.
+func (s *EWMASample) Percentile(p float64) float64 {
+	return s.Snapshot().Percentile(p)
+}
+
+// Percentiles returns a slice of arbitrary recency-weighted percentiles of
+// values in the sample.
+func (s *EWMASample) Percentiles(ps []float64) []float64 {
+	return s.Snapshot().Percentiles(ps)
+}
+
+// Size returns the size of the sample, which is at most the reservoir size.
+func (s *EWMASample) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.values)
+}
+
+// Snapshot returns a read-only copy of the sample which keeps the weight of
+// each value.
+func (s *EWMASample) Snapshot() Sample {
+	s.mutex.Lock()
+	values := s.ordered()
+	count, sum := s.count, s.sum
+	s.mutex.Unlock()
+	weights := make([]float64, len(values))
+	w := 1.0
+	for i := len(weights) - 1; i >= 0; i-- {
+		weights[i] = w
+		w *= 1 - s.alpha
+	}
+	return &EWMASampleSnapshot{
+		SampleSnapshot: &SampleSnapshot{count: count, sum: sum, values: values},
+		weights:        weights,
+	}
+}
+
+// StdDev returns the standard deviation of the values in the sample.
+func (s *EWMASample) StdDev() float64 {
+	return SampleStdDev(s.Values())
+}
+
+// Sum returns the sum of all values recorded, including those no longer in
+// the reservoir.
+func (s *EWMASample) Sum() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sum
+}
+
+// Update samples a new value, replacing the oldest once the reservoir is full.
+func (s *EWMASample) Update(v int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count++
+	s.sum += v
+	if len(s.values) < s.reservoirSize {
+		s.values = append(s.values, v)
+		return
+	}
+	s.values[s.next] = v
+	s.next = (s.next + 1) % len(s.values)
+}
+
+// Values returns a copy of the values in the sample, oldest first.
+func (s *EWMASample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ordered()
+}
+
+// Variance returns the variance of the values in the sample.
+func (s *EWMASample) Variance() float64 {
+	return SampleVariance(s.Values())
+}
+
+// ordered returns a copy of the values, oldest first.  The caller must hold
+// the mutex.
+func (s *EWMASample) ordered() []int64 {
+	values := make([]int64, 0, len(s.values))
+	values = append(values, s.values[s.next:]...)
+	return append(values, s.values[:s.next]...)
+}
+
+// EWMASampleSnapshot is a read-only copy of an EWMASample.
+type EWMASampleSnapshot struct {
+	*SampleSnapshot
+	weights []float64
+}
+
+// Percentile returns an arbitrary recency-weighted percentile of values at
+// the time the snapshot was taken.
+func (s *EWMASampleSnapshot) Percentile(p float64) float64 {
+	return SampleWeightedPercentiles(s.values, s.weights, []float64{p})[0]
+}
+
+// Percentiles returns a slice of arbitrary recency-weighted percentiles of
+// values at the time the snapshot was taken.
+func (s *EWMASampleSnapshot) Percentiles(ps []float64) []float64 {
+	return SampleWeightedPercentiles(s.values, s.weights, ps)
+}
+
+// Snapshot returns the snapshot.
+func (s *EWMASampleSnapshot) Snapshot() Sample { return s }
+
 // ExpDecaySample is an exponentially-decaying sample using a forward-decaying
 // priority reservoir.  See Cormode et al's "Forward Decay: A Practical Time
 // Decay Model for Streaming Systems".
@@ -36,9 +220,12 @@ type Sample interface {
 // <http://dimacs.rutgers.edu/~graham/pubs/papers/fwddecay.pdf>
 type ExpDecaySample struct {
 	alpha         float64
+	clock         Clock
 	count         int64
 	mutex         sync.Mutex
 	reservoirSize int
+	rng           *rand.Rand // nil for the global source
+	sum           int64
 	t0, t1        time.Time
 	values        *expDecaySampleHeap
 }
@@ -51,6 +238,7 @@ func NewExpDecaySample(reservoirSize int, alpha float64) Sample {
 	}
 	s := &ExpDecaySample{
 		alpha:         alpha,
+		clock:         systemClock{},
 		reservoirSize: reservoirSize,
 		t0:            time.Now(),
 		values:        newExpDecaySampleHeap(reservoirSize),
@@ -59,12 +247,25 @@ func NewExpDecaySample(reservoirSize int, alpha float64) Sample {
 	return s
 }
 
+// NewExpDecaySampleWithRand constructs a new exponentially-decaying sample
+// with the given reservoir size and alpha which draws priorities from r rather
+// than the global source, so a seeded r makes it reproducible.  r mustn't be
+// used by anything else since it isn't safe for concurrent use.
+func NewExpDecaySampleWithRand(reservoirSize int, alpha float64, r *rand.Rand) Sample {
+	s := NewExpDecaySample(reservoirSize, alpha)
+	if s, ok := s.(*ExpDecaySample); ok {
+		s.rng = r
+	}
+	return s
+}
+
 // Clear clears all samples.
 func (s *ExpDecaySample) Clear() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.count = 0
-	s.t0 = time.Now()
+	s.sum = 0
+	s.t0 = s.clock.Now()
 	s.t1 = s.t0.Add(rescaleThreshold)
 	s.values.Clear()
 }
@@ -88,6 +289,53 @@ func (s *ExpDecaySample) Mean() float64 {
 	return SampleMean(s.Values())
 }
 
+// Merge adds the values recorded by other to the sample without changing
+// other.  The merge is approximate: the values of another ExpDecaySample
+// keep their priorities, rescaled to the sample's landmark, so recent values
+// are still favoured but by the sample's alpha rather than other's, and the
+// values of any other kind of sample are given priorities as if they'd just
+// been recorded.
+func (s *ExpDecaySample) Merge(other Sample) {
+	var (
+		values     []expDecaySample
+		t0         time.Time
+		count, sum int64
+	)
+	o, ok := other.(*ExpDecaySample)
+	if ok {
+		o.mutex.Lock()
+		values = append(values, o.values.Values()...)
+		t0, count, sum = o.t0, o.count, o.sum
+		o.mutex.Unlock()
+	} else {
+		for _, v := range other.Values() {
+			values = append(values, expDecaySample{v: v})
+		}
+		count, sum = other.Count(), other.Sum()
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.clock.Now()
+	s.rescaleIfNeeded(now)
+	s.count += count
+	s.sum += sum
+	scale := math.Exp(s.alpha * t0.Sub(s.t0).Seconds())
+	for _, v := range values {
+		if ok {
+			v.k *= scale
+		} else {
+			v.k = s.priority(now)
+		}
+		if s.values.Size() == s.reservoirSize {
+			if v.k <= s.values.Values()[0].k {
+				continue
+			}
+			s.values.Pop()
+		}
+		s.values.Push(v)
+	}
+}
+
 // Min returns the minimum value in the sample, which may not be the minimum
 // value ever to be part of the sample.
 func (s *ExpDecaySample) Min() int64 {
@@ -123,6 +371,7 @@ func (s *ExpDecaySample) Snapshot() Sample {
 	}
 	return &SampleSnapshot{
 		count:  s.count,
+		sum:    s.sum,
 		values: values,
 	}
 }
@@ -132,17 +381,20 @@ func (s *ExpDecaySample) StdDev() float64 {
 	return SampleStdDev(s.Values())
 }
 
-// Sum returns the sum of the values in the sample.
+// Sum returns the sum of all values recorded, including those no longer in
+// the reservoir.
 func (s *ExpDecaySample) Sum() int64 {
-	return SampleSum(s.Values())
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sum
 }
 
 // Update samples a new value.
 func (s *ExpDecaySample) Update(v int64) {
-	s.update(time.Now(), v)
+	s.update(s.clock.Now(), v)
 }
 
-// Values returns a copy of the values in the sample.
+// Values returns a copy of the values in the sample in no particular order.
 func (s *ExpDecaySample) Values() []int64 {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -165,23 +417,41 @@ func (s *ExpDecaySample) update(t time.Time, v int64) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.count++
+	s.sum += v
+	s.rescaleIfNeeded(t)
 	if s.values.Size() == s.reservoirSize {
 		s.values.Pop()
 	}
-	s.values.Push(expDecaySample{
-		k: math.Exp(t.Sub(s.t0).Seconds()*s.alpha) / rand.Float64(),
-		v: v,
-	})
-	if t.After(s.t1) {
-		values := s.values.Values()
-		t0 := s.t0
-		s.values.Clear()
-		s.t0 = t
-		s.t1 = s.t0.Add(rescaleThreshold)
-		for _, v := range values {
-			v.k = v.k * math.Exp(-s.alpha*s.t0.Sub(t0).Seconds())
-			s.values.Push(v)
-		}
+	s.values.Push(expDecaySample{k: s.priority(t), v: v})
+}
+
+// priority returns a random priority for a value recorded at t.  The caller
+// must hold the mutex.
+func (s *ExpDecaySample) priority(t time.Time) float64 {
+	u := rand.Float64
+	if nil != s.rng {
+		u = s.rng.Float64
+	}
+	return math.Exp(t.Sub(s.t0).Seconds()*s.alpha) / u()
+}
+
+// rescaleIfNeeded moves the landmark time priorities are computed from up to
+// now and scales down every priority to match once the rescale threshold has
+// passed, before priorities grow too large for a float64.  It's called before
+// the priority of a new value is computed so that even a value recorded long
+// after the last can't overflow.  The caller must hold the mutex.
+func (s *ExpDecaySample) rescaleIfNeeded(now time.Time) {
+	if !now.After(s.t1) {
+		return
+	}
+	values := s.values.Values()
+	t0 := s.t0
+	s.values.Clear()
+	s.t0 = now
+	s.t1 = s.t0.Add(rescaleThreshold)
+	for _, v := range values {
+		v.k = v.k * math.Exp(-s.alpha*s.t0.Sub(t0).Seconds())
+		s.values.Push(v)
 	}
 }
 
@@ -246,12 +516,26 @@ func SampleMax(values []int64) int64 {
 	return max
 }
 
-// SampleMean returns the mean value of the slice of int64.
+// SampleMean returns the mean value of the slice of int64.  It sums the
+// values in floating point, with Neumaier's compensated summation to recover
+// the rounding error, rather than as int64s, which overflows for large values
+// like nanosecond durations.
 func SampleMean(values []int64) float64 {
 	if 0 == len(values) {
 		return 0.0
 	}
-	return float64(SampleSum(values)) / float64(len(values))
+	var sum, c float64
+	for _, v := range values {
+		x := float64(v)
+		t := sum + x
+		if math.Abs(sum) >= math.Abs(x) {
+			c += (sum - t) + x
+		} else {
+			c += (x - t) + sum
+		}
+		sum = t
+	}
+	return (sum + c) / float64(len(values))
 }
 
 // SampleMin returns the minimum value of the slice of int64.
@@ -268,18 +552,25 @@ func SampleMin(values []int64) int64 {
 	return min
 }
 
-// SamplePercentiles returns an arbitrary percentile of the slice of int64.
+// SamplePercentile returns an arbitrary percentile of the slice of int64,
+// which it sorts in place.
 func SamplePercentile(values int64Slice, p float64) float64 {
 	return SamplePercentiles(values, []float64{p})[0]
 }
 
 // SamplePercentiles returns a slice of arbitrary percentiles of the slice of
-// int64.
+// int64, which it sorts in place.
 func SamplePercentiles(values int64Slice, ps []float64) []float64 {
+	sort.Sort(values)
+	return sortedPercentiles(values, ps)
+}
+
+// sortedPercentiles is SamplePercentiles for an already sorted slice, which it
+// leaves untouched.
+func sortedPercentiles(values []int64, ps []float64) []float64 {
 	scores := make([]float64, len(ps))
 	size := len(values)
 	if size > 0 {
-		sort.Sort(values)
 		for i, p := range ps {
 			pos := p * float64(size+1)
 			if pos < 1.0 {
@@ -296,15 +587,51 @@ func SamplePercentiles(values int64Slice, ps []float64) []float64 {
 	return scores
 }
 
-// SampleSnapshot is a read-only copy of another Sample.
+// SampleWeightedPercentiles returns a slice of arbitrary percentiles of the
+// slice of int64, each value counting in proportion to the weight at the
+// same index.  Each score is the smallest value whose cumulative weight
+// reaches the percentile.
+func SampleWeightedPercentiles(values []int64, weights []float64, ps []float64) []float64 {
+	scores := make([]float64, len(ps))
+	size := len(values)
+	if 0 == size {
+		return scores
+	}
+	sorted := make(weightedValueSlice, size)
+	var total float64
+	for i, v := range values {
+		sorted[i] = weightedValue{v, weights[i]}
+		total += weights[i]
+	}
+	sort.Sort(sorted)
+	for i, p := range ps {
+		target, cumulative := p*total, 0.0
+		scores[i] = float64(sorted[size-1].v)
+		for _, wv := range sorted {
+			cumulative += wv.w
+			if cumulative >= target {
+				scores[i] = float64(wv.v)
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// SampleSnapshot is a read-only copy of another Sample.  It sorts a copy of
+// its values the first time a percentile is read and reuses it for every
+// percentile read after.
 type SampleSnapshot struct {
-	count  int64
-	values []int64
+	count, sum int64
+	values     []int64
+	sortOnce   sync.Once
+	sorted     []int64
 }
 
 func NewSampleSnapshot(count int64, values []int64) *SampleSnapshot {
 	return &SampleSnapshot{
 		count:  count,
+		sum:    SampleSum(values),
 		values: values,
 	}
 }
@@ -329,13 +656,13 @@ func (s *SampleSnapshot) Min() int64 { return SampleMin(s.values) }
 // Percentile returns an arbitrary percentile of values at the time the
 // snapshot was taken.
 func (s *SampleSnapshot) Percentile(p float64) float64 {
-	return SamplePercentile(s.values, p)
+	return sortedPercentiles(s.sortedValues(), []float64{p})[0]
 }
 
 // Percentiles returns a slice of arbitrary percentiles of values at the time
 // the snapshot was taken.
 func (s *SampleSnapshot) Percentiles(ps []float64) []float64 {
-	return SamplePercentiles(s.values, ps)
+	return sortedPercentiles(s.sortedValues(), ps)
 }
 
 // Size returns the size of the sample at the time the snapshot was taken.
@@ -349,14 +676,25 @@ func (s *SampleSnapshot) Snapshot() Sample { return s }
 func (s *SampleSnapshot) StdDev() float64 { return SampleStdDev(s.values) }
 
 // Sum returns the sum of values at the time the snapshot was taken.
-func (s *SampleSnapshot) Sum() int64 { return SampleSum(s.values) }
+func (s *SampleSnapshot) Sum() int64 { return s.sum }
 
 // Update panics.
 func (*SampleSnapshot) Update(int64) {
 	panic("Update called on a SampleSnapshot")
 }
 
-// Values returns a copy of the values in the sample.
+// sortedValues returns the values sorted, sorting a copy of them only the first
+// time it's called.
+func (s *SampleSnapshot) sortedValues() []int64 {
+	s.sortOnce.Do(func() {
+		s.sorted = s.Values()
+		sort.Sort(int64Slice(s.sorted))
+	})
+	return s.sorted
+}
+
+// Values returns a copy of the values in the sample in the order the sampled
+// Sample's Values returned them at the time the snapshot was taken.
 func (s *SampleSnapshot) Values() []int64 {
 	values := make([]int64, len(s.values))
 	copy(values, s.values)
@@ -380,18 +718,26 @@ func SampleSum(values []int64) int64 {
 	return sum
 }
 
-// SampleVariance returns the variance of the slice of int64.
+// SampleVariance returns the variance of the slice of int64.  It uses
+// Welford's one-pass algorithm, accumulating the mean in floating point
+// rather than summing the values as int64s, which overflows for large
+// values like nanosecond durations.  The values of a reservoir come and go
+// so it's computed afresh from them rather than maintained by Update.
 func SampleVariance(values []int64) float64 {
 	if 0 == len(values) {
 		return 0.0
 	}
-	m := SampleMean(values)
-	var sum float64
-	for _, v := range values {
-		d := float64(v) - m
-		sum += d * d
+	var m, m2 float64
+	for i, v := range values {
+		x := float64(v)
+		d := x - m
+		m += d / float64(i+1)
+		m2 += d * (x - m)
+	}
+	if m2 < 0 { // rounding, never a real negative variance
+		return 0.0
 	}
-	return sum / float64(len(values))
+	return m2 / float64(len(values))
 }
 
 // A uniform sample using Vitter's Algorithm R.
@@ -401,6 +747,8 @@ type UniformSample struct {
 	count         int64
 	mutex         sync.Mutex
 	reservoirSize int
+	rng           *rand.Rand // nil for the global source
+	sum           int64
 	values        []int64
 }
 
@@ -416,11 +764,24 @@ func NewUniformSample(reservoirSize int) Sample {
 	}
 }
 
+// NewUniformSampleWithRand constructs a new uniform sample with the given
+// reservoir size which chooses the values to evict with r rather than the
+// global source, so a seeded r makes it reproducible.  r mustn't be used by
+// anything else since it isn't safe for concurrent use.
+func NewUniformSampleWithRand(reservoirSize int, r *rand.Rand) Sample {
+	s := NewUniformSample(reservoirSize)
+	if s, ok := s.(*UniformSample); ok {
+		s.rng = r
+	}
+	return s
+}
+
 // Clear clears all samples.
 func (s *UniformSample) Clear() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.count = 0
+	s.sum = 0
 	s.values = make([]int64, 0, s.reservoirSize)
 }
 
@@ -447,6 +808,35 @@ func (s *UniformSample) Mean() float64 {
 	return SampleMean(s.values)
 }
 
+// Merge adds the values recorded by other to the sample without changing
+// other.  If both reservoirs fit in the sample's they're combined, otherwise
+// the reservoir is refilled by drawing values at random from each in
+// proportion to the number of values each sample has recorded, so it's as if
+// a single sample had recorded all of them.
+func (s *UniformSample) Merge(other Sample) {
+	theirs, count, sum := other.Values(), other.Count(), other.Sum()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	mine := s.values
+	if len(mine)+len(theirs) <= s.reservoirSize {
+		s.values = append(mine, theirs...)
+	} else {
+		s.shuffle(mine)
+		s.shuffle(theirs)
+		merged := make([]int64, 0, s.reservoirSize)
+		for len(merged) < s.reservoirSize {
+			if 0 != len(mine) && (0 == len(theirs) || s.int63n(s.count+count) < s.count) {
+				merged, mine = append(merged, mine[0]), mine[1:]
+			} else {
+				merged, theirs = append(merged, theirs[0]), theirs[1:]
+			}
+		}
+		s.values = merged
+	}
+	s.count += count
+	s.sum += sum
+}
+
 // Min returns the minimum value in the sample, which may not be the minimum
 // value ever to be part of the sample.
 func (s *UniformSample) Min() int64 {
@@ -457,17 +847,13 @@ func (s *UniformSample) Min() int64 {
 
 // Percentile returns an arbitrary percentile of values in the sample.
 func (s *UniformSample) Percentile(p float64) float64 {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	return SamplePercentile(s.values, p)
+	return SamplePercentile(s.Values(), p)
 }
 
 // Percentiles returns a slice of arbitrary percentiles of values in the
 // sample.
 func (s *UniformSample) Percentiles(ps []float64) []float64 {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	return SamplePercentiles(s.values, ps)
+	return SamplePercentiles(s.Values(), ps)
 }
 
 // Size returns the size of the sample, which is at most the reservoir size.
@@ -485,6 +871,7 @@ func (s *UniformSample) Snapshot() Sample {
 	copy(values, s.values)
 	return &SampleSnapshot{
 		count:  s.count,
+		sum:    s.sum,
 		values: values,
 	}
 }
@@ -496,11 +883,12 @@ func (s *UniformSample) StdDev() float64 {
 	return SampleStdDev(s.values)
 }
 
-// Sum returns the sum of the values in the sample.
+// Sum returns the sum of all values recorded, including those no longer in
+// the reservoir.
 func (s *UniformSample) Sum() int64 {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	return SampleSum(s.values)
+	return s.sum
 }
 
 // Update samples a new value.
@@ -508,17 +896,19 @@ func (s *UniformSample) Update(v int64) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.count++
+	s.sum += v
 	if len(s.values) < s.reservoirSize {
 		s.values = append(s.values, v)
 	} else {
-		r := rand.Int63n(s.count)
-		if r < int64(len(s.values)) {
+		if r := s.int63n(s.count); r < int64(len(s.values)) {
 			s.values[int(r)] = v
 		}
 	}
 }
 
-// Values returns a copy of the values in the sample.
+// Values returns a copy of the values in the sample in reservoir order, which
+// is insertion order until the reservoir is full and after that has each new
+// value in the place of the one it evicted.
 func (s *UniformSample) Values() []int64 {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -534,6 +924,22 @@ func (s *UniformSample) Variance() float64 {
 	return SampleVariance(s.values)
 }
 
+// int63n returns a random number in [0, n).  The caller must hold the mutex.
+func (s *UniformSample) int63n(n int64) int64 {
+	if nil != s.rng {
+		return s.rng.Int63n(n)
+	}
+	return rand.Int63n(n)
+}
+
+// shuffle shuffles the values in place.  The caller must hold the mutex.
+func (s *UniformSample) shuffle(values []int64) {
+	for i := len(values) - 1; 0 < i; i-- {
+		j := s.int63n(int64(i + 1))
+		values[i], values[j] = values[j], values[i]
+	}
+}
+
 // expDecaySample represents an individual sample in a heap.
 type expDecaySample struct {
 	k float64
@@ -614,3 +1020,17 @@ type int64Slice []int64
 func (p int64Slice) Len() int           { return len(p) }
 func (p int64Slice) Less(i, j int) bool { return p[i] < p[j] }
 func (p int64Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// weightedValue is a value and its weight in a weighted percentile.
+type weightedValue struct {
+	v int64
+	w float64
+}
+
+// weightedValueSlice is a slice of weightedValues that implements
+// sort.Interface, ordering by value.
+type weightedValueSlice []weightedValue
+
+func (s weightedValueSlice) Len() int           { return len(s) }
+func (s weightedValueSlice) Less(i, j int) bool { return s[i].v < s[j].v }
+func (s weightedValueSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
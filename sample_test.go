@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"math"
 	"math/rand"
+	"reflect"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 )
@@ -78,6 +81,97 @@ func BenchmarkUniformSample1028(b *testing.B) {
 	benchmarkSample(b, NewUniformSample(1028))
 }
 
+// BenchmarkSamplePercentile3 is the baseline for
+// BenchmarkSampleSnapshotPercentile3, sorting the values for each percentile.
+func BenchmarkSamplePercentile3(b *testing.B) {
+	s := NewUniformSample(1028)
+	for i := 0; i < 1028; i++ {
+		s.Update(rand.Int63())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SamplePercentile(s.Values(), 0.5)
+		SamplePercentile(s.Values(), 0.95)
+		SamplePercentile(s.Values(), 0.99)
+	}
+}
+
+func BenchmarkSampleSnapshotPercentile3(b *testing.B) {
+	s := NewUniformSample(1028)
+	for i := 0; i < 1028; i++ {
+		s.Update(rand.Int63())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		snapshot := s.Snapshot()
+		snapshot.Percentile(0.5)
+		snapshot.Percentile(0.95)
+		snapshot.Percentile(0.99)
+	}
+}
+
+func TestEWMASample(t *testing.T) {
+	s := NewEWMASample(3, 0.5)
+	for i := 1; i <= 5; i++ {
+		s.Update(int64(i))
+	}
+	if size := s.Size(); 3 != size {
+		t.Errorf("s.Size(): 3 != %v\n", size)
+	}
+	if count := s.Count(); 5 != count {
+		t.Errorf("s.Count(): 5 != %v\n", count)
+	}
+	values := s.Values()
+	if 3 != len(values) || 3 != values[0] || 4 != values[1] || 5 != values[2] {
+		t.Errorf("s.Values(): [3 4 5] != %v\n", values)
+	}
+	// The weights of 3, 4 and 5 are 1/4, 1/2 and 1, out of 7/4.
+	ps := s.Snapshot().Percentiles([]float64{0, 0.1, 0.5, 1})
+	if 3 != ps[0] || 3 != ps[1] || 5 != ps[2] || 5 != ps[3] {
+		t.Errorf("s.Snapshot().Percentiles(): [3 3 5 5] != %v\n", ps)
+	}
+}
+
+func TestEWMASampleZeroReservoir(t *testing.T) {
+	s := NewEWMASample(0, 0.5)
+	s.Update(1)
+	s.Update(2)
+	if size := s.Size(); 1 != size {
+		t.Errorf("s.Size(): 1 != %v\n", size)
+	}
+	if values := s.Values(); 1 != len(values) || 2 != values[0] {
+		t.Errorf("s.Values(): [2] != %v\n", values)
+	}
+}
+
+// TestEWMASampleStepChange feeds a step change in values to a recency-weighted
+// sample and a uniform sample and counts how many values it takes for each
+// median to follow.
+func TestEWMASampleStepChange(t *testing.T) {
+	rand.Seed(1)
+	ewma, uniform := NewEWMASample(1000, 0.05), NewUniformSample(1000)
+	for i := 0; i < 1000; i++ {
+		ewma.Update(10)
+		uniform.Update(10)
+	}
+	steps := func(s Sample) int {
+		for i := 1; i <= 10000; i++ {
+			s.Update(1000)
+			if 1000 == s.Snapshot().Percentile(0.5) {
+				return i
+			}
+		}
+		return 10000
+	}
+	ewmaSteps, uniformSteps := steps(ewma), steps(uniform)
+	if 14 != ewmaSteps {
+		t.Errorf("ewmaSteps: 14 != %v\n", ewmaSteps)
+	}
+	if ewmaSteps*10 > uniformSteps {
+		t.Errorf("ewmaSteps: %v, uniformSteps: %v\n", ewmaSteps, uniformSteps)
+	}
+}
+
 func TestExpDecaySample10(t *testing.T) {
 	rand.Seed(1)
 	s := NewExpDecaySample(100, 0.99)
@@ -169,6 +263,29 @@ func TestExpDecaySampleNanosecondRegression(t *testing.T) {
 	}
 }
 
+// This test makes sure that values recorded long ago are displaced by recent
+// values as time advances.
+func TestExpDecaySampleAgesOut(t *testing.T) {
+	rand.Seed(1)
+	now := time.Now()
+	s := NewExpDecaySample(100, 0.015).(*ExpDecaySample)
+	for i := 0; i < 1000; i++ {
+		s.update(now, 10)
+	}
+	for i := 0; i < 1000; i++ {
+		s.update(now.Add(30*time.Minute), 20)
+	}
+	old := 0
+	for _, v := range s.Values() {
+		if 10 == v {
+			old++
+		}
+	}
+	if old > 0 {
+		t.Errorf("old values still in sample: %v\n", old)
+	}
+}
+
 func TestExpDecaySampleRescale(t *testing.T) {
 	s := NewExpDecaySample(2, 0.001).(*ExpDecaySample)
 	s.update(time.Now(), 1)
@@ -180,6 +297,83 @@ func TestExpDecaySampleRescale(t *testing.T) {
 	}
 }
 
+// This test makes sure that percentiles don't jump when priorities are
+// rescaled and that no priority overflows, even after a long gap, though old
+// ones may underflow to zero.
+func TestExpDecaySampleRescaleStable(t *testing.T) {
+	rand.Seed(1)
+	clock := newManualClock()
+	s := NewExpDecaySample(1028, 0.015).(*ExpDecaySample)
+	s.clock = clock
+	s.Clear()
+	var before []float64
+	for hour := 0; hour < 3; hour++ {
+		for i := 0; i < 3600; i++ {
+			s.Update(int64(i%100) + 1)
+			clock.Add(time.Second)
+			if 0 == hour && 3599 == i {
+				before = s.Percentiles([]float64{0.5, 0.99})
+			}
+		}
+	}
+	if !s.t0.After(time.Unix(0, 0).Add(rescaleThreshold)) {
+		t.Errorf("s.t0: not rescaled: %v\n", s.t0)
+	}
+	after := s.Percentiles([]float64{0.5, 0.99})
+	for i := range before {
+		if 2 < math.Abs(before[i]-after[i]) {
+			t.Errorf("percentile %d: %v != %v\n", i, before[i], after[i])
+		}
+	}
+	clock.Add(24 * time.Hour)
+	s.Update(1)
+	for _, v := range s.values.Values() {
+		if math.IsInf(v.k, 0) || math.IsNaN(v.k) {
+			t.Fatalf("v.k: %v\n", v.k)
+		}
+	}
+}
+
+func TestExpDecaySampleWithRand(t *testing.T) {
+	s1 := NewExpDecaySampleWithRand(100, 0.99, rand.New(rand.NewSource(1))).(*ExpDecaySample)
+	s2 := NewExpDecaySampleWithRand(100, 0.99, rand.New(rand.NewSource(1))).(*ExpDecaySample)
+	s2.t0, s2.t1 = s1.t0, s1.t1
+	now := s1.t0
+	for i := 0; i < 1000; i++ {
+		now = now.Add(time.Millisecond)
+		s1.update(now, int64(i))
+		s2.update(now, int64(i))
+	}
+	if v1, v2 := s1.Values(), s2.Values(); !reflect.DeepEqual(v1, v2) {
+		t.Errorf("s1.Values(): %v != %v\n", v2, v1)
+	}
+}
+
+// This test makes sure that merging two samples, one of values below 1000 and
+// three times as many above it, keeps as many values of each as a single
+// sample of all of them would.
+func TestExpDecaySampleMerge(t *testing.T) {
+	rand.Seed(1)
+	clock := newManualClock()
+	s1 := NewExpDecaySample(1028, 0.015).(*ExpDecaySample)
+	s2 := NewExpDecaySample(1028, 0.015).(*ExpDecaySample)
+	for _, s := range []*ExpDecaySample{s1, s2} {
+		s.clock = clock
+		s.Clear()
+	}
+	for i := 0; i < 10000; i++ {
+		s1.Update(rand.Int63n(1000))
+	}
+	for i := 0; i < 30000; i++ {
+		s2.Update(1000 + rand.Int63n(1000))
+	}
+	s1.Merge(s2)
+	testMergedPercentiles(t, s1)
+	if size := s1.Size(); 1028 != size {
+		t.Errorf("s1.Size(): 1028 != %v\n", size)
+	}
+}
+
 func TestExpDecaySampleSnapshot(t *testing.T) {
 	now := time.Now()
 	rand.Seed(1)
@@ -202,6 +396,105 @@ func TestExpDecaySampleStatistics(t *testing.T) {
 	testExpDecaySampleStatistics(t, s)
 }
 
+// TestSamplePercentiles checks that percentiles are interpolated like Coda
+// Hale's library: pos = p * (n + 1), linear between the surrounding values
+// and clamped to the smallest and largest values at the ends.
+func TestSamplePercentiles(t *testing.T) {
+	for _, tc := range []struct {
+		values []int64
+		ps     []float64
+		want   []float64
+	}{
+		{[]int64{}, []float64{0.5, 0.99}, []float64{0, 0}},
+		{[]int64{7}, []float64{0, 0.1, 0.5, 1}, []float64{7, 7, 7, 7}},
+		{[]int64{1, 2, 3, 4}, []float64{0.1, 0.25, 0.5, 0.75, 0.99}, []float64{1, 1.25, 2.5, 3.75, 4}},
+		{[]int64{5, 1, 4, 2, 3}, []float64{0.5}, []float64{3}},
+		{[]int64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, []float64{0.75, 0.95}, []float64{8.25, 10}},
+	} {
+		values := make(int64Slice, len(tc.values))
+		copy(values, tc.values)
+		got := SamplePercentiles(values, tc.ps)
+		for i := range tc.ps {
+			if tc.want[i] != got[i] {
+				t.Errorf("%v percentile %v: %v != %v\n", tc.values, tc.ps[i], tc.want[i], got[i])
+			}
+		}
+		if 1 == len(tc.ps) {
+			if p := SamplePercentile(tc.values, tc.ps[0]); tc.want[0] != p {
+				t.Errorf("SamplePercentile(%v, %v): %v != %v\n", tc.values, tc.ps[0], tc.want[0], p)
+			}
+		}
+	}
+}
+
+func TestSampleValuesCopy(t *testing.T) {
+	for _, s := range []Sample{NewUniformSample(10), NewExpDecaySample(10, 0.015), NewEWMASample(10, 0.1)} {
+		for i := 1; i <= 5; i++ {
+			s.Update(int64(i))
+		}
+		for _, sample := range []Sample{s, s.Snapshot()} {
+			values := sample.Values()
+			for i := range values {
+				values[i] = 0
+			}
+			if min := sample.Min(); 1 != min {
+				t.Errorf("%T.Min(): 1 != %v\n", sample, min)
+			}
+		}
+	}
+}
+
+// TestSampleSnapshotConcurrentUpdate is meant to be run under the race
+// detector.
+func TestSampleSnapshotConcurrentUpdate(t *testing.T) {
+	for _, s := range []Sample{NewUniformSample(100), NewExpDecaySample(100, 0.015), NewEWMASample(100, 0.1)} {
+		for i := 0; i < 100; i++ {
+			s.Update(int64(i))
+		}
+		snapshot := s.Snapshot()
+		want := snapshot.Values()
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.Update(int64(i))
+				s.Percentile(0.5)
+			}
+		}()
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					snapshot.Percentiles([]float64{0.5, 0.99})
+					snapshot.Values()
+				}
+			}()
+		}
+		wg.Wait()
+		if values := snapshot.Values(); !reflect.DeepEqual(want, values) {
+			t.Errorf("%T.Values(): %v != %v\n", snapshot, want, values)
+		}
+	}
+}
+
+func TestSampleSnapshotPercentileCached(t *testing.T) {
+	s := NewUniformSample(100)
+	for i := 100; i > 0; i-- {
+		s.Update(int64(i))
+	}
+	snapshot := s.Snapshot()
+	if p := snapshot.Percentile(0.5); 50.5 != p {
+		t.Errorf("median: 50.5 != %v\n", p)
+	}
+	if ps := snapshot.Percentiles([]float64{0.5, 0.99}); 50.5 != ps[0] || 99.99 != ps[1] {
+		t.Errorf("percentiles: [50.5 99.99] != %v\n", ps)
+	}
+	if values := snapshot.Values(); 100 != values[0] {
+		t.Errorf("values[0]: 100 != %v\n", values[0])
+	}
+}
+
 func TestUniformSample(t *testing.T) {
 	rand.Seed(1)
 	s := NewUniformSample(100)
@@ -224,6 +517,116 @@ func TestUniformSample(t *testing.T) {
 	}
 }
 
+func TestSampleVarianceLargeValues(t *testing.T) {
+	// The sum of these overflows an int64 but they're exact as float64s.
+	values := make([]int64, 200)
+	for i := range values {
+		values[i] = 5e16 + int64(i)*1000
+	}
+	want := 1000 * math.Sqrt((200*200-1)/12.0)
+	if stdDev := SampleStdDev(values); math.IsNaN(stdDev) || 1e-9 < math.Abs(stdDev-want)/want {
+		t.Errorf("SampleStdDev(): %v != %v\n", want, stdDev)
+	}
+	h := NewHistogram(NewUniformSample(200))
+	for _, v := range values {
+		h.Update(v)
+	}
+	if stdDev := h.StdDev(); math.IsNaN(stdDev) || 1e-9 < math.Abs(stdDev-want)/want {
+		t.Errorf("h.StdDev(): %v != %v\n", want, stdDev)
+	}
+	if variance := SampleVariance([]int64{1 << 53, 1 << 53, 1 << 53}); 0 != variance {
+		t.Errorf("SampleVariance(): 0 != %v\n", variance)
+	}
+}
+
+func TestSampleMeanLargeValues(t *testing.T) {
+	// The sum of these overflows an int64 but they're exact as float64s.
+	values := make([]int64, 200)
+	for i := range values {
+		values[i] = 5e16 + int64(i)*1000
+	}
+	if mean := SampleMean(values); 5e16+99500 != mean {
+		t.Errorf("SampleMean(): %v != %v\n", 5e16+99500, mean)
+	}
+	if mean := SampleMean([]int64{math.MaxInt64, math.MaxInt64}); float64(math.MaxInt64) != mean {
+		t.Errorf("SampleMean(): %v != %v\n", float64(math.MaxInt64), mean)
+	}
+	if mean := SampleMean([]int64{1 << 60, 1, -(1 << 60), 2}); 0.75 != mean {
+		t.Errorf("SampleMean(): 0.75 != %v\n", mean)
+	}
+}
+
+// This test makes sure that merging two samples, one of values below 1000 and
+// three times as many above it, keeps as many values of each as a single
+// sample of all of them would.
+func TestUniformSampleMerge(t *testing.T) {
+	rand.Seed(1)
+	s1, s2 := NewUniformSample(1028), NewUniformSample(1028)
+	for i := 0; i < 10000; i++ {
+		s1.Update(rand.Int63n(1000))
+	}
+	for i := 0; i < 30000; i++ {
+		s2.Update(1000 + rand.Int63n(1000))
+	}
+	s1.(MergeableSample).Merge(s2)
+	testMergedPercentiles(t, s1)
+	if size := s1.Size(); 1028 != size {
+		t.Errorf("s1.Size(): 1028 != %v\n", size)
+	}
+	if count := s2.Count(); 30000 != count {
+		t.Errorf("s2.Count(): 30000 != %v\n", count)
+	}
+}
+
+func TestUniformSampleMergeSmall(t *testing.T) {
+	s1, s2 := NewUniformSample(100), NewUniformSample(100)
+	for i := int64(1); i <= 10; i++ {
+		s1.Update(i)
+		s2.Update(10 + i)
+	}
+	s1.(MergeableSample).Merge(s2.Snapshot())
+	if size := s1.Size(); 20 != size {
+		t.Errorf("s1.Size(): 20 != %v\n", size)
+	}
+	if min, max := s1.Min(), s1.Max(); 1 != min || 20 != max {
+		t.Errorf("s1.Min(), s1.Max(): 1 20 != %v %v\n", min, max)
+	}
+}
+
+func TestUniformSampleWithRand(t *testing.T) {
+	s1 := NewUniformSampleWithRand(100, rand.New(rand.NewSource(1)))
+	s2 := NewUniformSampleWithRand(100, rand.New(rand.NewSource(1)))
+	for i := 0; i < 1000; i++ {
+		s1.Update(int64(i))
+		s2.Update(int64(i))
+	}
+	if v1, v2 := s1.Values(), s2.Values(); !reflect.DeepEqual(v1, v2) {
+		t.Errorf("s1.Values(): %v != %v\n", v2, v1)
+	}
+}
+
+// This test makes sure that every value in the stream has the same chance of
+// ending up in the reservoir by checking that, over many runs, the values are
+// spread evenly across each tenth of the stream.
+func TestUniformSampleUnbiased(t *testing.T) {
+	rand.Seed(1)
+	var buckets [10]int
+	for run := 0; run < 200; run++ {
+		s := NewUniformSample(100)
+		for i := 0; i < 10000; i++ {
+			s.Update(int64(i))
+		}
+		for _, v := range s.Values() {
+			buckets[v/1000]++
+		}
+	}
+	for i, n := range buckets {
+		if n < 1800 || n > 2200 {
+			t.Errorf("bucket %d: out of range [1800, 2200]: %v\n", i, n)
+		}
+	}
+}
+
 func TestUniformSampleIncludesTail(t *testing.T) {
 	rand.Seed(1)
 	s := NewUniformSample(100)
@@ -261,6 +664,22 @@ func TestUniformSampleStatistics(t *testing.T) {
 	testUniformSampleStatistics(t, s)
 }
 
+// testMergedPercentiles checks the percentiles of a sample merged from 10000
+// values below 1000 and 30000 between 1000 and 2000 against those of the
+// combined values.
+func testMergedPercentiles(t *testing.T, s Sample) {
+	if count := s.Count(); 40000 != count {
+		t.Errorf("s.Count(): 40000 != %v\n", count)
+	}
+	ps := []float64{0.5, 0.75, 0.9}
+	want := []float64{1333, 1667, 1867}
+	for i, p := range s.Percentiles(ps) {
+		if 0.05 < math.Abs(p-want[i])/want[i] {
+			t.Errorf("%v percentile: %v != %v\n", ps[i], want[i], p)
+		}
+	}
+}
+
 func benchmarkSample(b *testing.B, s Sample) {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
@@ -288,8 +707,8 @@ func testExpDecaySampleStatistics(t *testing.T, s Sample) {
 	if mean := s.Mean(); 4965.98 != mean {
 		t.Errorf("s.Mean(): 4965.98 != %v\n", mean)
 	}
-	if stdDev := s.StdDev(); 2959.825156930727 != stdDev {
-		t.Errorf("s.StdDev(): 2959.825156930727 != %v\n", stdDev)
+	if stdDev := s.StdDev(); 2959.8251569307263 != stdDev {
+		t.Errorf("s.StdDev(): 2959.8251569307263 != %v\n", stdDev)
 	}
 	ps := s.Percentiles([]float64{0.5, 0.75, 0.99})
 	if 4615 != ps[0] {
@@ -316,8 +735,8 @@ func testUniformSampleStatistics(t *testing.T, s Sample) {
 	if mean := s.Mean(); 4748.14 != mean {
 		t.Errorf("s.Mean(): 4748.14 != %v\n", mean)
 	}
-	if stdDev := s.StdDev(); 2826.684117548333 != stdDev {
-		t.Errorf("s.StdDev(): 2826.684117548333 != %v\n", stdDev)
+	if stdDev := s.StdDev(); 2826.684117548334 != stdDev {
+		t.Errorf("s.StdDev(): 2826.684117548334 != %v\n", stdDev)
 	}
 	ps := s.Percentiles([]float64{0.5, 0.75, 0.99})
 	if 4599 != ps[0] {
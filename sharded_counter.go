@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// NewShardedCounter constructs a new ShardedCounter with the given number of
+// shards, or one per GOMAXPROCS if shards is not positive.
+func NewShardedCounter(shards int) Counter {
+	if UseNilMetrics || UseNilCounters {
+		return NilCounter{}
+	}
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	c := &ShardedCounter{cells: make([]counterCell, shards)}
+	c.pool.New = func() interface{} {
+		i := int(atomic.AddUint32(&c.next, 1)-1) % len(c.cells)
+		return &i
+	}
+	return c
+}
+
+// NewRegisteredShardedCounter constructs and registers a new ShardedCounter.
+func NewRegisteredShardedCounter(name string, r Registry, shards int) Counter {
+	c := NewShardedCounter(shards)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// ShardedCounter is a Counter spread over several cells, each on its own
+// cache line, so that goroutines incrementing it on different CPUs don't
+// contend.  Each goroutine updates the cell cached for its P and Count sums
+// the cells.  Count and Clear aren't atomic with respect to concurrent
+// updates, which may or may not be reflected.
+type ShardedCounter struct {
+	cells []counterCell
+	pool  sync.Pool
+	next  uint32
+}
+
+// counterCell pads a count to 64 bytes so that no two counts share a cache
+// line.
+type counterCell struct {
+	count int64
+	_     [56]byte
+}
+
+// Clear sets the counter to zero.
+func (c *ShardedCounter) Clear() {
+	for i := range c.cells {
+		atomic.StoreInt64(&c.cells[i].count, 0)
+	}
+}
+
+// Count returns the sum of the cells.
+func (c *ShardedCounter) Count() int64 {
+	var count int64
+	for i := range c.cells {
+		count += atomic.LoadInt64(&c.cells[i].count)
+	}
+	return count
+}
+
+// Dec decrements the counter by the given amount.
+func (c *ShardedCounter) Dec(i int64) {
+	c.add(-i)
+}
+
+// Inc increments the counter by the given amount.
+func (c *ShardedCounter) Inc(i int64) {
+	c.add(i)
+}
+
+// Snapshot returns a read-only copy of the counter.
+func (c *ShardedCounter) Snapshot() Counter {
+	return CounterSnapshot(c.Count())
+}
+
+func (c *ShardedCounter) add(i int64) {
+	cell := c.pool.Get().(*int)
+	atomic.AddInt64(&c.cells[*cell].count, i)
+	c.pool.Put(cell)
+}
+
+//////////////////
+// Meter functions
+//////////////////
+
+func (c *ShardedCounter) Mark(n int64) { c.Inc(n) }
+
+func (c *ShardedCounter) Rate1() float64 { return 0.0 }
+
+func (c *ShardedCounter) Rate5() float64 { return 0.0 }
+
+func (c *ShardedCounter) Rate15() float64 { return 0.0 }
+
+func (c *ShardedCounter) RateMean() float64 { return 0.0 }
+
+func (c *ShardedCounter) Stop() {}
+
+//////////////////
+//////////////////
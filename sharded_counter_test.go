@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+)
+
+func BenchmarkShardedCounterParallel(b *testing.B) {
+	c := NewShardedCounter(0)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc(1)
+		}
+	})
+}
+
+func TestShardedCounter(t *testing.T) {
+	c := NewShardedCounter(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc(2)
+				c.Dec(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Count(); 8000 != count {
+		t.Errorf("c.Count(): 8000 != %v\n", count)
+	}
+	snapshot := c.Snapshot()
+	c.Clear()
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+	if count := snapshot.Count(); 8000 != count {
+		t.Errorf("snapshot.Count(): 8000 != %v\n", count)
+	}
+}
+
+func TestNewRegisteredShardedCounter(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredShardedCounter("foo", r, 2).Inc(47)
+	if c := GetOrRegisterCounter("foo", r); 47 != c.Count() {
+		t.Fatal(c)
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RegistrySnapshot is the state of every metric in a Registry at one time,
+// as returned by GetAll.
+type RegistrySnapshot struct {
+	Time    time.Time                         `json:"time"`
+	Metrics map[string]map[string]interface{} `json:"metrics"`
+}
+
+// SnapshotRing keeps the most recent snapshots of a Registry in memory to
+// serve a short time series without an external database.
+type SnapshotRing struct {
+	registry  Registry
+	mutex     sync.Mutex
+	snapshots []RegistrySnapshot
+	next      int
+	full      bool
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewSnapshotRing constructs a SnapshotRing holding the last n snapshots of
+// r and launches a goroutine which takes one every d.
+// Be sure to call Stop() once the ring is of no use to allow for garbage collection.
+func NewSnapshotRing(r Registry, d time.Duration, n int) *SnapshotRing {
+	s := newSnapshotRing(r, n)
+	go s.run(d)
+	return s
+}
+
+func newSnapshotRing(r Registry, n int) *SnapshotRing {
+	if n < 1 {
+		n = 1
+	}
+	return &SnapshotRing{
+		registry:  r,
+		snapshots: make([]RegistrySnapshot, n),
+		done:      make(chan struct{}),
+	}
+}
+
+// ServeHTTP writes the snapshots, oldest first, as a JSON array.
+func (s *SnapshotRing) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(s.Snapshots())
+}
+
+// Snapshots returns the snapshots held by the ring, oldest first.
+func (s *SnapshotRing) Snapshots() []RegistrySnapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.full {
+		snapshots := make([]RegistrySnapshot, s.next)
+		copy(snapshots, s.snapshots)
+		return snapshots
+	}
+	snapshots := make([]RegistrySnapshot, 0, len(s.snapshots))
+	snapshots = append(snapshots, s.snapshots[s.next:]...)
+	return append(snapshots, s.snapshots[:s.next]...)
+}
+
+// Stop stops taking snapshots.  The snapshots already taken are kept.
+func (s *SnapshotRing) Stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// capture takes a snapshot, overwriting and so releasing the oldest one once
+// the ring is full.
+func (s *SnapshotRing) capture(now time.Time) {
+	snapshot := RegistrySnapshot{Time: now, Metrics: s.registry.GetAll()}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.snapshots[s.next] = snapshot
+	s.next = (s.next + 1) % len(s.snapshots)
+	if 0 == s.next {
+		s.full = true
+	}
+}
+
+func (s *SnapshotRing) run(d time.Duration) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.capture(now)
+		case <-s.done:
+			return
+		}
+	}
+}
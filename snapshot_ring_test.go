@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRingWraps(t *testing.T) {
+	r := NewRegistry()
+	c := NewRegisteredCounter("foo", r)
+	s := newSnapshotRing(r, 3)
+	if n := len(s.Snapshots()); 0 != n {
+		t.Errorf("len(s.Snapshots()): 0 != %v\n", n)
+	}
+	for i := 1; i <= 5; i++ {
+		c.Inc(1)
+		s.capture(time.Unix(int64(i), 0))
+	}
+	snapshots := s.Snapshots()
+	if 3 != len(snapshots) {
+		t.Fatalf("len(s.Snapshots()): 3 != %v\n", len(snapshots))
+	}
+	for i, snapshot := range snapshots {
+		if want := int64(i + 3); want != snapshot.Time.Unix() || want != snapshot.Metrics["foo"]["count"] {
+			t.Errorf("snapshot %d: %v at %v\n", i, snapshot.Metrics["foo"], snapshot.Time)
+		}
+	}
+}
+
+func TestSnapshotRingServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	s := newSnapshotRing(r, 2)
+	s.capture(time.Unix(1, 0))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	var snapshots []RegistrySnapshot
+	if err := json.NewDecoder(w.Body).Decode(&snapshots); nil != err {
+		t.Fatal(err)
+	}
+	if 1 != len(snapshots) || 47.0 != snapshots[0].Metrics["foo"]["count"] {
+		t.Fatal(snapshots)
+	}
+}
+
+func TestSnapshotRingStop(t *testing.T) {
+	s := NewSnapshotRing(NewRegistry(), time.Millisecond, 2)
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+	s.Stop()
+	if n := len(s.Snapshots()); 0 == n {
+		t.Error("no snapshots taken")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/rcrowley/go-metrics"
 	"github.com/stathat/go"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -28,17 +30,16 @@ func sh(r metrics.Registry, userkey string) error {
 			stathat.PostEZValue(name, userkey, float64(metric.Value()))
 		case metrics.Histogram:
 			h := metric.Snapshot()
-			ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+			ps := metrics.ReportedPercentiles(h)
+			scores := h.Percentiles(ps)
 			stathat.PostEZCount(name+".count", userkey, int(h.Count()))
 			stathat.PostEZValue(name+".min", userkey, float64(h.Min()))
 			stathat.PostEZValue(name+".max", userkey, float64(h.Max()))
 			stathat.PostEZValue(name+".mean", userkey, float64(h.Mean()))
 			stathat.PostEZValue(name+".std-dev", userkey, float64(h.StdDev()))
-			stathat.PostEZValue(name+".50-percentile", userkey, float64(ps[0]))
-			stathat.PostEZValue(name+".75-percentile", userkey, float64(ps[1]))
-			stathat.PostEZValue(name+".95-percentile", userkey, float64(ps[2]))
-			stathat.PostEZValue(name+".99-percentile", userkey, float64(ps[3]))
-			stathat.PostEZValue(name+".999-percentile", userkey, float64(ps[4]))
+			for i, p := range ps {
+				stathat.PostEZValue(name+"."+percentileKey(p), userkey, scores[i])
+			}
 		case metrics.ThisMeter:
 			m := metric.Snapshot()
 			stathat.PostEZCount(name+".count", userkey, int(m.Count()))
@@ -48,17 +49,16 @@ func sh(r metrics.Registry, userkey string) error {
 			stathat.PostEZValue(name+".mean", userkey, float64(m.RateMean()))
 		case metrics.Timer:
 			t := metric.Snapshot()
-			ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+			ps := metrics.ReportedPercentiles(t)
+			scores := t.Percentiles(ps)
 			stathat.PostEZCount(name+".count", userkey, int(t.Count()))
 			stathat.PostEZValue(name+".min", userkey, float64(t.Min()))
 			stathat.PostEZValue(name+".max", userkey, float64(t.Max()))
 			stathat.PostEZValue(name+".mean", userkey, float64(t.Mean()))
 			stathat.PostEZValue(name+".std-dev", userkey, float64(t.StdDev()))
-			stathat.PostEZValue(name+".50-percentile", userkey, float64(ps[0]))
-			stathat.PostEZValue(name+".75-percentile", userkey, float64(ps[1]))
-			stathat.PostEZValue(name+".95-percentile", userkey, float64(ps[2]))
-			stathat.PostEZValue(name+".99-percentile", userkey, float64(ps[3]))
-			stathat.PostEZValue(name+".999-percentile", userkey, float64(ps[4]))
+			for i, p := range ps {
+				stathat.PostEZValue(name+"."+percentileKey(p), userkey, scores[i])
+			}
 			stathat.PostEZValue(name+".one-minute", userkey, float64(t.Rate1()))
 			stathat.PostEZValue(name+".five-minute", userkey, float64(t.Rate5()))
 			stathat.PostEZValue(name+".fifteen-minute", userkey, float64(t.Rate15()))
@@ -67,3 +67,9 @@ func sh(r metrics.Registry, userkey string) error {
 	})
 	return nil
 }
+
+// percentileKey returns the suffix of the name of a percentile, say
+// "999-percentile" for 0.999.
+func percentileKey(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p*100.0, 'f', -1, 64), ".", "", 1) + "-percentile"
+}
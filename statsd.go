@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStatsDMTU is a safe StatsDConfig.MTU for Ethernet: its 1500 byte MTU
+// less the IP and UDP headers, leaving room for options.
+const DefaultStatsDMTU = 1432
+
+// StatsDConfig provides a container with configuration parameters for
+// the StatsD exporter
+type StatsDConfig struct {
+	Addr          *net.UDPAddr      // Network address to send to
+	Registry      Registry          // Registry to be exported
+	FlushInterval time.Duration     // Flush interval
+	DurationUnit  time.Duration     // Time conversion unit for durations, milliseconds if zero
+	Prefix        string            // Prefix to be prepended to metric names
+	Percentiles   []float64         // Percentiles to export from timers and histograms, each one's DefaultPercentiles if nil
+	DogStatsD     bool              // Whether to append DogStatsD tags to every line, including those of names encoded by EncodeTaggedName
+	Tags          map[string]string // Tags appended to every line in DogStatsD mode
+	Logger        Logger            // Logger for errors, the standard library's if nil
+	MTU           int               // Bytes of lines to batch into each datagram, say DefaultStatsDMTU, or one line per datagram if zero
+	Dropped       Counter           // Counts lines which failed to send, go-metrics.statsd.dropped in DefaultRegistry if nil
+	AlignFlush    bool              // Whether to flush on multiples of FlushInterval, say on the minute, rather than every FlushInterval from starting
+}
+
+// StatsD is a blocking exporter function which reports metrics in r to a
+// StatsD server located at addr, flushing them every d duration and
+// prepending metric names with prefix.
+//
+// Counters, float counters and meters are sent as StatsD counters holding the
+// increment since the previous flush, gauges as gauges and the percentiles of timers and
+// histograms as timings and histograms respectively.  In DogStatsD mode, the
+// names of metrics registered by GetOrRegisterTagged are sent as their base
+// name tagged with their tags, which take precedence over the configured
+// Tags.
+func StatsD(r Registry, d time.Duration, prefix string, addr *net.UDPAddr) {
+	StatsDWithConfig(StatsDConfig{
+		Addr:          addr,
+		Registry:      r,
+		FlushInterval: d,
+		DurationUnit:  time.Millisecond,
+		Prefix:        prefix,
+	})
+}
+
+// StatsDWithConfig is a blocking exporter function just like StatsD,
+// but it takes a StatsDConfig instead.
+func StatsDWithConfig(c StatsDConfig) {
+	StatsDWithContext(context.Background(), c)
+}
+
+// StatsDWithContext is a blocking exporter function just like
+// StatsDWithConfig but it returns once ctx is done, after a final flush so
+// that the counts of the last, partial interval aren't lost on shutdown.
+func StatsDWithContext(ctx context.Context, c StatsDConfig) {
+	s := newStatsD(c)
+	l := loggerOrDefault(c.Logger)
+	ticker := NewFlushTicker(c.FlushInterval, c.AlignFlush)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			done = true
+		}
+		if err := s.flush(); nil != err {
+			l.Printf("%v", err)
+		}
+	}
+}
+
+// statsD holds the counts sent by the previous flush so that counters can be
+// sent as deltas.
+type statsD struct {
+	c           StatsDConfig
+	counts      map[string]int64
+	floatCounts map[string]float64
+}
+
+func newStatsD(c StatsDConfig) *statsD {
+	if nil == c.Dropped {
+		c.Dropped = GetOrRegisterCounter("go-metrics.statsd.dropped", DefaultRegistry)
+	}
+	return &statsD{c: c, counts: make(map[string]int64), floatCounts: make(map[string]float64)}
+}
+
+func (s *statsD) flush() error {
+	conn, err := net.DialUDP("udp", nil, s.c.Addr)
+	if nil != err {
+		return err
+	}
+	defer conn.Close()
+	var lines []string
+	du := float64(s.durationUnit())
+	counts := make(map[string]int64, len(s.counts))
+	delta := func(name string, count int64) string {
+		counts[name] = count
+		return strconv.FormatInt(count-s.counts[name], 10)
+	}
+	floatCounts := make(map[string]float64, len(s.floatCounts))
+	floatDelta := func(name string, count float64) string {
+		floatCounts[name] = count
+		return strconv.FormatFloat(count-s.floatCounts[name], 'f', -1, 64)
+	}
+	s.c.Registry.Each(func(name string, i interface{}) {
+		base, tags := name, map[string]string(nil)
+		if s.c.DogStatsD {
+			base, tags = DecodeTaggedName(name)
+		}
+		suffix := s.tags(tags)
+		line := func(name, value, kind string) {
+			lines = append(lines, s.name(name)+":"+value+"|"+kind+suffix)
+		}
+		switch metric := i.(type) {
+		case Counter:
+			line(base, delta(name, metric.Count()), "c")
+		case FloatCounter:
+			line(base, floatDelta(name, metric.Count()), "c")
+		case Gauge:
+			if v := metric.Value(); v < 0 {
+				// A signed gauge value is an adjustment, so reset it first.
+				line(base, "0", "g")
+				line(base, strconv.FormatInt(v, 10), "g")
+			} else {
+				line(base, strconv.FormatInt(v, 10), "g")
+			}
+		case GaugeFloat64:
+			if v := metric.Value(); v < 0 {
+				line(base, "0", "g")
+				line(base, strconv.FormatFloat(v, 'f', -1, 64), "g")
+			} else {
+				line(base, strconv.FormatFloat(v, 'f', -1, 64), "g")
+			}
+		case Histogram:
+			h := metric.Snapshot()
+			keys := s.c.Percentiles
+			if nil == keys {
+				keys = ReportedPercentiles(h)
+			}
+			ps := h.Percentiles(keys)
+			line(base+".count", delta(name, h.Count()), "c")
+			for psIdx, psKey := range keys {
+				line(base+"."+percentileKey(psKey), strconv.FormatFloat(ps[psIdx], 'f', -1, 64), "h")
+			}
+		case ThisMeter:
+			line(base, delta(name, metric.Count()), "c")
+		case Timer:
+			t := metric.Snapshot()
+			keys := s.c.Percentiles
+			if nil == keys {
+				keys = ReportedPercentiles(t)
+			}
+			ps := t.Percentiles(keys)
+			line(base+".count", delta(name, t.Count()), "c")
+			for psIdx, psKey := range keys {
+				line(base+"."+percentileKey(psKey), strconv.FormatFloat(ps[psIdx]/du, 'f', -1, 64), "ms")
+			}
+		}
+	})
+	s.counts, s.floatCounts = counts, floatCounts
+	return s.send(conn, lines)
+}
+
+// send writes the lines to conn, batching as many into each datagram as fit
+// in the MTU.  Datagrams which fail to send, say because the socket buffer is
+// full, don't stop the rest being sent; their lines are counted as dropped
+// and the first error is returned.
+func (s *statsD) send(conn *net.UDPConn, lines []string) error {
+	var firstErr error
+	var buf []byte
+	n := 0
+	write := func() {
+		if _, err := conn.Write(buf); nil != err {
+			s.c.Dropped.Inc(int64(n))
+			if nil == firstErr {
+				firstErr = err
+			}
+		}
+		buf, n = buf[:0], 0
+	}
+	for _, l := range lines {
+		if 0 < n && len(buf)+1+len(l) > s.c.MTU {
+			write()
+		}
+		if 0 < n {
+			buf = append(buf, '\n')
+		}
+		buf = append(buf, l...)
+		n++
+	}
+	if 0 < n {
+		write()
+	}
+	return firstErr
+}
+
+// durationUnit returns the unit timers are sent in, milliseconds unless
+// configured otherwise.
+func (s *statsD) durationUnit() time.Duration {
+	if 0 == s.c.DurationUnit {
+		return time.Millisecond
+	}
+	return s.c.DurationUnit
+}
+
+func (s *statsD) name(name string) string {
+	if "" == s.c.Prefix {
+		return name
+	}
+	return s.c.Prefix + "." + name
+}
+
+// tags returns the DogStatsD tag suffix of the configured tags and those of a
+// metric, which take precedence, sorted by tag name, or the empty string if
+// DogStatsD mode is off.
+func (s *statsD) tags(metricTags map[string]string) string {
+	if !s.c.DogStatsD || 0 == len(s.c.Tags)+len(metricTags) {
+		return ""
+	}
+	tags := make(map[string]string, len(s.c.Tags)+len(metricTags))
+	for k, v := range s.c.Tags {
+		tags[k] = v
+	}
+	for k, v := range metricTags {
+		tags[k] = v
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteString("|#")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%s:%s", k, tags[k])
+	}
+	return buf.String()
+}
+
+// percentileKey names a percentile the same way the Graphite exporter does,
+// e.g. 0.999 becomes "999-percentile".
+func percentileKey(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p*100.0, 'f', -1, 64), ".", "", 1) + "-percentile"
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleStatsD() {
+	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:8125")
+	go StatsD(DefaultRegistry, 1*time.Second, "some.prefix", addr)
+}
+
+func TestStatsDFlush(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	c := NewRegisteredCounter("foo", r)
+	NewRegisteredGauge("bar", r).Update(-3)
+	tm := NewRegisteredTimer("baz", r)
+	defer tm.Stop()
+	tm.Update(2 * time.Millisecond)
+	s := newStatsD(StatsDConfig{
+		Addr:         conn.LocalAddr().(*net.UDPAddr),
+		Registry:     r,
+		DurationUnit: time.Millisecond,
+		Prefix:       "prefix",
+		Percentiles:  []float64{0.5},
+	})
+
+	c.Inc(47)
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{
+		"prefix.bar:-3|g",
+		"prefix.bar:0|g",
+		"prefix.baz.50-percentile:2|ms",
+		"prefix.baz.count:1|c",
+		"prefix.foo:47|c",
+	})
+
+	c.Inc(3)
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{
+		"prefix.bar:-3|g",
+		"prefix.bar:0|g",
+		"prefix.baz.50-percentile:2|ms",
+		"prefix.baz.count:0|c",
+		"prefix.foo:3|c",
+	})
+}
+
+func TestStatsDFlushFloatCounter(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	c := NewRegisteredFloatCounter("foo", r)
+	s := newStatsD(StatsDConfig{Addr: conn.LocalAddr().(*net.UDPAddr), Registry: r})
+	c.Inc(2.5)
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{"foo:2.5|c"})
+	c.Inc(0.25)
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{"foo:0.25|c"})
+}
+
+func TestStatsDFlushDogStatsD(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	s := newStatsD(StatsDConfig{
+		Addr:      conn.LocalAddr().(*net.UDPAddr),
+		Registry:  r,
+		DogStatsD: true,
+		Tags:      map[string]string{"region": "us", "env": "prod"},
+	})
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{"foo:47|c|#env:prod,region:us"})
+}
+
+func TestStatsDFlushDogStatsDTaggedNames(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	GetOrRegisterTagged("hits", map[string]string{"path": "/a", "region": "eu"}, NewCounter(), r).(Counter).Inc(2)
+	GetOrRegisterTagged("hits", map[string]string{"path": "/b"}, NewCounter(), r).(Counter).Inc(3)
+	s := newStatsD(StatsDConfig{
+		Addr:      conn.LocalAddr().(*net.UDPAddr),
+		Registry:  r,
+		DogStatsD: true,
+		Tags:      map[string]string{"region": "us"},
+	})
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{
+		"hits:2|c|#path:/a,region:eu",
+		"hits:3|c|#path:/b,region:us",
+	})
+}
+
+func TestStatsDFlushDefaultDurationUnit(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	tm := NewRegisteredTimer("baz", r)
+	defer tm.Stop()
+	tm.Update(2 * time.Millisecond)
+	s := newStatsD(StatsDConfig{
+		Addr:        conn.LocalAddr().(*net.UDPAddr),
+		Registry:    r,
+		Percentiles: []float64{0.5},
+	})
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{"baz.50-percentile:2|ms", "baz.count:1|c"})
+}
+
+func TestStatsDWithContextFlushesOnCancel(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		StatsDWithContext(ctx, StatsDConfig{
+			Addr:          conn.LocalAddr().(*net.UDPAddr),
+			Registry:      r,
+			FlushInterval: time.Hour,
+		})
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StatsDWithContext didn't return after its context was cancelled")
+	}
+	testStatsDPackets(t, conn, []string{"foo:47|c"})
+}
+
+func TestStatsDSendBatchesToMTU(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s := newStatsD(StatsDConfig{Addr: conn.LocalAddr().(*net.UDPAddr), MTU: 15, Dropped: NewCounter()})
+	w, err := net.DialUDP("udp", nil, s.c.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := s.send(w, []string{"foo:1|c", "bar:2|c", "baz:3|c", "quux:40000|c"}); err != nil {
+		t.Fatal(err)
+	}
+	testStatsDPackets(t, conn, []string{"baz:3|c", "foo:1|c\nbar:2|c", "quux:40000|c"})
+}
+
+func TestStatsDSendCountsDropped(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	dropped := NewCounter()
+	s := newStatsD(StatsDConfig{Addr: conn.LocalAddr().(*net.UDPAddr), Dropped: dropped})
+	w, err := net.DialUDP("udp", nil, s.c.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.SetWriteBuffer(1)
+	// No datagram this long fits in the socket buffer, or in UDP at all.
+	tooLong := strings.Repeat("x", 1<<16) + ":1|c"
+	if err := s.send(w, []string{"foo:1|c", tooLong, "bar:2|c"}); err == nil {
+		t.Error("s.send(): nil error sending an oversized datagram")
+	}
+	if count := dropped.Count(); 1 != count {
+		t.Errorf("dropped.Count(): 1 != %v\n", count)
+	}
+	testStatsDPackets(t, conn, []string{"bar:2|c", "foo:1|c"})
+}
+
+// testStatsDPackets reads len(want) lines from conn and compares them, in
+// sorted order, to want.
+func testStatsDPackets(t *testing.T, conn *net.UDPConn, want []string) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var got []string
+	buf := make([]byte, 1500)
+	for len(got) < len(want) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err, got)
+		}
+		got = append(got, string(buf[:n]))
+	}
+	sort.Strings(got)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("packet %d: %q != %q\n", i, want[i], got[i])
+		}
+	}
+}
@@ -5,13 +5,16 @@ package metrics
 import (
 	"fmt"
 	"log/syslog"
+	"strings"
 	"time"
 )
 
 // Output each metric in the given registry to syslog periodically using
 // the given syslogger.
 func Syslog(r Registry, d time.Duration, w *syslog.Writer) {
-	for _ = range time.Tick(d) {
+	ticker := NewFlushTicker(d, false)
+	defer ticker.Stop()
+	for _ = range ticker.C {
 		r.Each(func(name string, i interface{}) {
 			switch metric := i.(type) {
 			case Counter:
@@ -25,23 +28,19 @@ func Syslog(r Registry, d time.Duration, w *syslog.Writer) {
 				w.Info(fmt.Sprintf("healthcheck %s: error: %v", name, metric.Error()))
 			case Histogram:
 				h := metric.Snapshot()
-				ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+				ps := ReportedPercentiles(h)
 				w.Info(fmt.Sprintf(
-					"histogram %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f",
+					"histogram %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f%s",
 					name,
 					h.Count(),
 					h.Min(),
 					h.Max(),
 					h.Mean(),
 					h.StdDev(),
-					ps[0],
-					ps[1],
-					ps[2],
-					ps[3],
-					ps[4],
+					syslogPercentiles(ps, h.Percentiles(ps)),
 				))
 			case ThisMeter:
-				m := metric.Snapshot()
+				m, release := flushSnapshot(metric)
 				w.Info(fmt.Sprintf(
 					"meter %s: count: %d 1-min: %.2f 5-min: %.2f 15-min: %.2f mean: %.2f",
 					name,
@@ -51,22 +50,19 @@ func Syslog(r Registry, d time.Duration, w *syslog.Writer) {
 					m.Rate15(),
 					m.RateMean(),
 				))
+				release()
 			case Timer:
 				t := metric.Snapshot()
-				ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+				ps := ReportedPercentiles(t)
 				w.Info(fmt.Sprintf(
-					"timer %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f 1-min: %.2f 5-min: %.2f 15-min: %.2f mean-rate: %.2f",
+					"timer %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f%s 1-min: %.2f 5-min: %.2f 15-min: %.2f mean-rate: %.2f",
 					name,
 					t.Count(),
 					t.Min(),
 					t.Max(),
 					t.Mean(),
 					t.StdDev(),
-					ps[0],
-					ps[1],
-					ps[2],
-					ps[3],
-					ps[4],
+					syslogPercentiles(ps, t.Percentiles(ps)),
 					t.Rate1(),
 					t.Rate5(),
 					t.Rate15(),
@@ -76,3 +72,13 @@ func Syslog(r Registry, d time.Duration, w *syslog.Writer) {
 		})
 	}
 }
+
+// syslogPercentiles formats the scores of the percentiles ps as they're
+// logged, say " median: 1.00 75%: 2.00".
+func syslogPercentiles(ps, scores []float64) string {
+	var b strings.Builder
+	for i, p := range ps {
+		fmt.Fprintf(&b, " %s: %.2f", percentileLabel(p), scores[i])
+	}
+	return b.String()
+}
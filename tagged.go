@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// EncodeTaggedName encodes a base name and tags into a registry key of the
+// form base{key=value,...} with the tags sorted by key, so that the same tags
+// always produce the same key.  Backslashes and the characters {}=, are
+// escaped with a backslash.  A name without tags is the base name, escaped
+// only if it contains any of those characters, so that it can't be mistaken
+// for a tagged name.
+func EncodeTaggedName(base string, tags map[string]string) string {
+	if 0 == len(tags) {
+		if !strings.ContainsAny(base, tagReserved) {
+			return base
+		}
+		var buf bytes.Buffer
+		writeTagEscaped(&buf, base)
+		return buf.String()
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	writeTagEscaped(&buf, base)
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if 0 < i {
+			buf.WriteByte(',')
+		}
+		writeTagEscaped(&buf, k)
+		buf.WriteByte('=')
+		writeTagEscaped(&buf, tags[k])
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// DecodeTaggedName decodes a registry key encoded by EncodeTaggedName into
+// its base name and tags.  Keys encoded without tags are returned unescaped
+// with nil tags and keys which weren't encoded by EncodeTaggedName at all are
+// returned unchanged with nil tags.
+func DecodeTaggedName(name string) (string, map[string]string) {
+	if 0 == len(name) || '}' != name[len(name)-1] && -1 == strings.IndexByte(name, '\\') {
+		return name, nil
+	}
+	var (
+		base  string
+		key   string
+		buf   bytes.Buffer
+		tags  map[string]string
+		inKey bool
+	)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if '\\' == c {
+			if i+1 == len(name) || -1 == strings.IndexByte(tagReserved, name[i+1]) {
+				return name, nil
+			}
+			i++
+			buf.WriteByte(name[i])
+			continue
+		}
+		switch {
+		case nil == tags && '{' == c:
+			base, inKey = buf.String(), true
+			tags = make(map[string]string)
+			buf.Reset()
+		case nil == tags && ('}' == c || '=' == c || ',' == c):
+			return name, nil
+		case nil == tags:
+			buf.WriteByte(c)
+		case inKey && '=' == c:
+			key, inKey = buf.String(), false
+			buf.Reset()
+		case !inKey && (',' == c || '}' == c):
+			if '}' == c && i != len(name)-1 {
+				return name, nil
+			}
+			tags[key], inKey = buf.String(), true
+			buf.Reset()
+		case '{' == c || '}' == c || '=' == c || ',' == c:
+			return name, nil
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	if nil == tags {
+		return buf.String(), nil
+	}
+	if !inKey || 0 != buf.Len() {
+		return name, nil
+	}
+	return base, tags
+}
+
+// GetOrRegisterTagged gets an existing metric or registers the given one
+// under the base name and tags encoded by EncodeTaggedName.  Like
+// GetOrRegister, the interface can be the metric to register or a function
+// returning it.  Exporters which support dimensions decode the tags back out
+// of the name.
+func GetOrRegisterTagged(base string, tags map[string]string, i interface{}, r Registry) interface{} {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(EncodeTaggedName(base, tags), i)
+}
+
+// tagReserved are the characters writeTagEscaped escapes.
+const tagReserved = `\{}=,`
+
+func writeTagEscaped(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '{', '}', '=', ',':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeTaggedName(t *testing.T) {
+	name := EncodeTaggedName("http.requests", map[string]string{"status": "200", "method": "GET"})
+	if "http.requests{method=GET,status=200}" != name {
+		t.Errorf("EncodeTaggedName(): http.requests{method=GET,status=200} != %v\n", name)
+	}
+	if name := EncodeTaggedName("foo", nil); "foo" != name {
+		t.Errorf("EncodeTaggedName(): foo != %v\n", name)
+	}
+	if name := EncodeTaggedName("x{a=b}", nil); `x\{a\=b\}` != name {
+		t.Errorf("EncodeTaggedName(): x\\{a\\=b\\} != %v\n", name)
+	}
+}
+
+func TestDecodeTaggedName(t *testing.T) {
+	for _, tc := range []struct {
+		base string
+		tags map[string]string
+	}{
+		{"foo", nil},
+		{"foo", map[string]string{"a": "1"}},
+		{"foo", map[string]string{"a": "1", "b": ""}},
+		{`f{o}o\`, map[string]string{"a=b": "c,d}", `\`: "{"}},
+		{"x{a=b}", nil},
+		{`a\b,c`, nil},
+		{"x{a=b}", map[string]string{"c": "d"}},
+	} {
+		base, tags := DecodeTaggedName(EncodeTaggedName(tc.base, tc.tags))
+		if tc.base != base || !reflect.DeepEqual(tc.tags, tags) {
+			t.Errorf("DecodeTaggedName(EncodeTaggedName(%q, %v)): %q, %v\n", tc.base, tc.tags, base, tags)
+		}
+	}
+	for _, name := range []string{"foo}", "foo{a}", "foo{a=1}}", "foo{a=1,}", "foo{a=1}b}", "{", `C:\foo`, `foo\`, "a,b\\{"} {
+		if base, tags := DecodeTaggedName(name); name != base || nil != tags {
+			t.Errorf("DecodeTaggedName(%q): %q, %v\n", name, base, tags)
+		}
+	}
+}
+
+func TestGetOrRegisterTagged(t *testing.T) {
+	r := NewRegistry()
+	GetOrRegisterTagged("requests", map[string]string{"status": "200"}, NewCounter, r).(Counter).Inc(47)
+	GetOrRegisterTagged("requests", map[string]string{"status": "500"}, NewCounter, r).(Counter).Inc(1)
+	if c := GetOrRegisterTagged("requests", map[string]string{"status": "200"}, NewCounter, r).(Counter); 47 != c.Count() {
+		t.Errorf("c.Count(): 47 != %v\n", c.Count())
+	}
+	if c := GetCounter("requests{status=500}", r); nil == c || 1 != c.Count() {
+		t.Errorf("GetCounter(\"requests{status=500}\", r): %v\n", c)
+	}
+}
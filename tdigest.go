@@ -0,0 +1,385 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// TDigestSample estimates percentiles with Ted Dunning's t-digest, which
+// clusters values into centroids that are smaller towards the extremes of
+// the distribution, so its memory use is bounded by the compression rather
+// than the number of values, its estimates are most accurate for the
+// percentiles usually reported, like the 99th, and digests can be merged, say
+// across shards.  See Dunning and Ertl's "Computing Extremely Accurate
+// Quantiles Using t-Digests".
+//
+// <https://arxiv.org/abs/1902.04023>
+//
+// Count, Max, Mean, Min and Sum are exact.  Percentile, StdDev and Variance
+// are estimated from the centroids, whose means Values returns.
+type TDigestSample struct {
+	buffer      []float64 // values not yet merged into centroids
+	centroids   []centroid
+	compression float64
+	count       int64
+	max, min    int64
+	mutex       sync.Mutex
+	sum         int64
+}
+
+// centroid is the mean of count values in a t-digest.
+type centroid struct {
+	mean, count float64
+}
+
+// NewTDigestSample constructs a new t-digest with the given compression,
+// which bounds the number of centroids to about twice it.  100 is typical;
+// more is more accurate but uses more memory.
+func NewTDigestSample(compression float64) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	if compression < 10 {
+		compression = 10
+	}
+	return &TDigestSample{
+		buffer:      make([]float64, 0, int(5*compression)),
+		compression: compression,
+	}
+}
+
+// Clear clears all samples.
+func (s *TDigestSample) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.buffer = s.buffer[:0]
+	s.centroids = nil
+	s.count, s.max, s.min, s.sum = 0, 0, 0, 0
+}
+
+// Count returns the number of samples recorded.
+func (s *TDigestSample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Max returns the maximum value recorded.
+func (s *TDigestSample) Max() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.max
+}
+
+// Mean returns the mean of the values recorded.
+func (s *TDigestSample) Mean() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count {
+		return 0.0
+	}
+	return float64(s.sum) / float64(s.count)
+}
+
+// Merge adds the values recorded by other to the sample, as if they'd been
+// recorded by it, without changing other.  The centroids of another
+// TDigestSample are merged with the sample's and the values of any other
+// sample become centroids each standing for an equal share of its count.
+func (s *TDigestSample) Merge(other Sample) {
+	if snapshot, ok := other.(*TDigestSampleSnapshot); ok {
+		other = snapshot.s
+	}
+	var (
+		centroids            []centroid
+		count, max, min, sum int64
+	)
+	if o, ok := other.(*TDigestSample); ok {
+		o.mutex.Lock()
+		o.compress()
+		centroids = make([]centroid, len(o.centroids))
+		copy(centroids, o.centroids)
+		count, max, min, sum = o.count, o.max, o.min, o.sum
+		o.mutex.Unlock()
+	} else {
+		values := other.Values()
+		count, max, min, sum = other.Count(), other.Max(), other.Min(), other.Sum()
+		centroids = make([]centroid, len(values))
+		for i, v := range values {
+			centroids[i] = centroid{mean: float64(v), count: float64(count) / float64(len(values))}
+		}
+	}
+	if 0 == count {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count || min < s.min {
+		s.min = min
+	}
+	if 0 == s.count || max > s.max {
+		s.max = max
+	}
+	s.count += count
+	s.sum += sum
+	s.merge(centroids)
+}
+
+// Min returns the minimum value recorded.
+func (s *TDigestSample) Min() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.min
+}
+
+// Percentile returns an estimate of an arbitrary percentile of the values
+// recorded.
+func (s *TDigestSample) Percentile(p float64) float64 {
+	return s.Percentiles([]float64{p})[0]
+}
+
+// Percentiles returns a slice of estimates of arbitrary percentiles of the
+// values recorded.
+func (s *TDigestSample) Percentiles(ps []float64) []float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.compress()
+	scores := make([]float64, len(ps))
+	for i, p := range ps {
+		scores[i] = s.quantile(p)
+	}
+	return scores
+}
+
+// Size returns the number of centroids.
+func (s *TDigestSample) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.compress()
+	return len(s.centroids)
+}
+
+// Snapshot returns a read-only copy of the sample.
+func (s *TDigestSample) Snapshot() Sample {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.compress()
+	c := &TDigestSample{
+		centroids:   make([]centroid, len(s.centroids)),
+		compression: s.compression,
+		count:       s.count,
+		max:         s.max,
+		min:         s.min,
+		sum:         s.sum,
+	}
+	copy(c.centroids, s.centroids)
+	return &TDigestSampleSnapshot{c}
+}
+
+// StdDev returns an estimate of the standard deviation of the values
+// recorded.
+func (s *TDigestSample) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Sum returns the sum of the values recorded.
+func (s *TDigestSample) Sum() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sum
+}
+
+// Update records a new value.
+func (s *TDigestSample) Update(v int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count || v < s.min {
+		s.min = v
+	}
+	if 0 == s.count || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+	s.buffer = append(s.buffer, float64(v))
+	if len(s.buffer) == cap(s.buffer) {
+		s.compress()
+	}
+}
+
+// Values returns the means of the centroids, rounded, in ascending order.
+func (s *TDigestSample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.compress()
+	values := make([]int64, len(s.centroids))
+	for i, c := range s.centroids {
+		values[i] = int64(math.Floor(c.mean + 0.5))
+	}
+	return values
+}
+
+// Variance returns an estimate of the variance of the values recorded, which
+// counts every value as its centroid's mean.
+func (s *TDigestSample) Variance() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if 0 == s.count {
+		return 0.0
+	}
+	s.compress()
+	m := float64(s.sum) / float64(s.count)
+	var sum float64
+	for _, c := range s.centroids {
+		d := c.mean - m
+		sum += c.count * d * d
+	}
+	return sum / float64(s.count)
+}
+
+// compress merges the buffered values into the centroids.  The caller must
+// hold the mutex.
+func (s *TDigestSample) compress() {
+	if 0 == len(s.buffer) {
+		return
+	}
+	centroids := make([]centroid, len(s.buffer))
+	for i, v := range s.buffer {
+		centroids[i] = centroid{mean: v, count: 1}
+	}
+	s.buffer = s.buffer[:0]
+	s.merge(centroids)
+}
+
+// merge merges the given centroids with the sample's, combining neighbours
+// as long as each centroid stays within the size the scale function allows
+// at its quantile.  The caller must hold the mutex.
+func (s *TDigestSample) merge(centroids []centroid) {
+	all := append(centroids, s.centroids...)
+	sort.Sort(centroidsByMean(all))
+	var total float64
+	for _, c := range all {
+		total += c.count
+	}
+	merged := make([]centroid, 0, len(s.centroids)+1)
+	cur := all[0]
+	var seen float64 // count before cur
+	kLow := s.k(0)
+	for _, c := range all[1:] {
+		if s.k((seen+cur.count+c.count)/total)-kLow <= 1 {
+			cur.mean += (c.mean - cur.mean) * c.count / (cur.count + c.count)
+			cur.count += c.count
+			continue
+		}
+		merged = append(merged, cur)
+		seen += cur.count
+		kLow = s.k(seen / total)
+		cur = c
+	}
+	s.centroids = append(merged, cur)
+}
+
+// k is the t-digest's k1 scale function, which maps a quantile to a scale on
+// which every centroid may span at most one.
+func (s *TDigestSample) k(q float64) float64 {
+	return s.compression / (2 * math.Pi) * math.Asin(2*math.Min(q, 1)-1)
+}
+
+// quantile estimates the value at quantile q by interpolating between the
+// centres of the centroids either side of it, and the minimum and maximum at
+// the ends.  The caller must hold the mutex and have compressed the buffer.
+func (s *TDigestSample) quantile(q float64) float64 {
+	if 0 == len(s.centroids) {
+		return 0.0
+	}
+	if q <= 0 {
+		return float64(s.min)
+	}
+	if q >= 1 {
+		return float64(s.max)
+	}
+	index := q * float64(s.count)
+	first := s.centroids[0]
+	if index < first.count/2 {
+		return float64(s.min) + (first.mean-float64(s.min))*index/(first.count/2)
+	}
+	seen := 0.0
+	for i := 0; i < len(s.centroids)-1; i++ {
+		c, next := s.centroids[i], s.centroids[i+1]
+		left, right := seen+c.count/2, seen+c.count+next.count/2
+		if index < right {
+			return c.mean + (next.mean-c.mean)*(index-left)/(right-left)
+		}
+		seen += c.count
+	}
+	last := s.centroids[len(s.centroids)-1]
+	left, right := float64(s.count)-last.count/2, float64(s.count)
+	return last.mean + (float64(s.max)-last.mean)*(index-left)/(right-left)
+}
+
+// centroidsByMean sorts centroids in ascending order of mean.
+type centroidsByMean []centroid
+
+func (cs centroidsByMean) Len() int           { return len(cs) }
+func (cs centroidsByMean) Less(i, j int) bool { return cs[i].mean < cs[j].mean }
+func (cs centroidsByMean) Swap(i, j int)      { cs[i], cs[j] = cs[j], cs[i] }
+
+// TDigestSampleSnapshot is a read-only copy of a TDigestSample.
+type TDigestSampleSnapshot struct {
+	s *TDigestSample
+}
+
+// Clear panics.
+func (*TDigestSampleSnapshot) Clear() {
+	panic("Clear called on a TDigestSampleSnapshot")
+}
+
+// Count returns the count of inputs at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Count() int64 { return s.s.Count() }
+
+// Max returns the maximal value at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Max() int64 { return s.s.Max() }
+
+// Mean returns the mean value at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Mean() float64 { return s.s.Mean() }
+
+// Min returns the minimal value at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Min() int64 { return s.s.Min() }
+
+// Percentile returns an estimate of an arbitrary percentile of values at the
+// time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Percentile(p float64) float64 {
+	return s.s.Percentile(p)
+}
+
+// Percentiles returns a slice of estimates of arbitrary percentiles of values
+// at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Percentiles(ps []float64) []float64 {
+	return s.s.Percentiles(ps)
+}
+
+// Size returns the number of centroids at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Size() int { return s.s.Size() }
+
+// Snapshot returns the snapshot.
+func (s *TDigestSampleSnapshot) Snapshot() Sample { return s }
+
+// StdDev returns an estimate of the standard deviation of values at the time
+// the snapshot was taken.
+func (s *TDigestSampleSnapshot) StdDev() float64 { return s.s.StdDev() }
+
+// Sum returns the sum of values at the time the snapshot was taken.
+func (s *TDigestSampleSnapshot) Sum() int64 { return s.s.Sum() }
+
+// Update panics.
+func (*TDigestSampleSnapshot) Update(int64) {
+	panic("Update called on a TDigestSampleSnapshot")
+}
+
+// Values returns the means of the centroids at the time the snapshot was
+// taken.
+func (s *TDigestSampleSnapshot) Values() []int64 { return s.s.Values() }
+
+// Variance returns an estimate of the variance of values at the time the
+// snapshot was taken.
+func (s *TDigestSampleSnapshot) Variance() float64 { return s.s.Variance() }
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func BenchmarkTDigestSample(b *testing.B) {
+	benchmarkSample(b, NewTDigestSample(100))
+}
+
+// testTDigestQuantiles checks each estimated percentile against the exact
+// one, allowing less error towards the tails as the t-digest promises.
+func testTDigestQuantiles(t *testing.T, s Sample, values []int64) {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Sort(int64Slice(sorted))
+	n := float64(len(sorted))
+	for _, test := range []struct{ p, maxErr float64 }{
+		{0.01, 0.002},
+		{0.25, 0.005},
+		{0.5, 0.005},
+		{0.75, 0.005},
+		{0.99, 0.002},
+		{0.999, 0.0005},
+	} {
+		estimate := s.Percentile(test.p)
+		// Compare ranks rather than values so the distribution doesn't matter.
+		rank := float64(sort.Search(len(sorted), func(i int) bool { return float64(sorted[i]) >= estimate })) / n
+		if test.maxErr < math.Abs(rank-test.p) {
+			t.Errorf("%v percentile: %v is at rank %v\n", test.p, estimate, rank)
+		}
+	}
+}
+
+func TestTDigestSample(t *testing.T) {
+	rand.Seed(1)
+	s := NewTDigestSample(100)
+	values := make([]int64, 100000)
+	for i := range values {
+		values[i] = int64(rand.ExpFloat64() * 1e6)
+		s.Update(values[i])
+	}
+	if count := s.Count(); 100000 != count {
+		t.Errorf("s.Count(): 100000 != %v\n", count)
+	}
+	if sum := s.Sum(); SampleSum(values) != sum {
+		t.Errorf("s.Sum(): %v != %v\n", SampleSum(values), sum)
+	}
+	if max := s.Max(); SampleMax(values) != max {
+		t.Errorf("s.Max(): %v != %v\n", SampleMax(values), max)
+	}
+	if min := s.Min(); SampleMin(values) != min {
+		t.Errorf("s.Min(): %v != %v\n", SampleMin(values), min)
+	}
+	if size := s.Size(); 200 < size {
+		t.Errorf("s.Size(): 200 < %v\n", size)
+	}
+	testTDigestQuantiles(t, s, values)
+	testTDigestQuantiles(t, s.Snapshot(), values)
+}
+
+func TestTDigestSampleMerge(t *testing.T) {
+	rand.Seed(1)
+	s1, s2 := NewTDigestSample(100).(*TDigestSample), NewTDigestSample(100).(*TDigestSample)
+	values := make([]int64, 100000)
+	for i := range values {
+		values[i] = rand.Int63n(1e6)
+		if 0 == i%2 {
+			s1.Update(values[i])
+		} else {
+			s2.Update(values[i])
+		}
+	}
+	s1.Merge(s2)
+	if count := s1.Count(); 100000 != count {
+		t.Errorf("s1.Count(): 100000 != %v\n", count)
+	}
+	if count := s2.Count(); 50000 != count {
+		t.Errorf("s2.Count(): 50000 != %v\n", count)
+	}
+	testTDigestQuantiles(t, s1, values)
+}
+
+func TestTDigestSampleMergeOtherSample(t *testing.T) {
+	rand.Seed(1)
+	s, other := NewTDigestSample(100), NewUniformSample(1028)
+	for i := 0; i < 10000; i++ {
+		s.Update(rand.Int63n(1000))
+	}
+	for i := 0; i < 30000; i++ {
+		other.Update(1000 + rand.Int63n(1000))
+	}
+	s.(MergeableSample).Merge(other)
+	testMergedPercentiles(t, s)
+}
+
+func TestTDigestSampleSmall(t *testing.T) {
+	s := NewTDigestSample(100)
+	if p := s.Percentile(0.5); 0 != p {
+		t.Errorf("s.Percentile(0.5): 0 != %v\n", p)
+	}
+	for i := int64(1); i <= 3; i++ {
+		s.Update(i)
+	}
+	ps := s.Percentiles([]float64{0, 0.5, 1})
+	if 1 != ps[0] || 2 != ps[1] || 3 != ps[2] {
+		t.Errorf("s.Percentiles(): [1 2 3] != %v\n", ps)
+	}
+	if values := s.Values(); 3 != len(values) {
+		t.Errorf("s.Values(): [1 2 3] != %v\n", values)
+	}
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -27,6 +28,46 @@ type Timer interface {
 	Variance() float64
 }
 
+// ErrorTimers can time functions which return an error or take a context.
+// It's not part of Timer so that implementations predating it still are
+// Timers; TimeErr and TimeCtx fall back to timing the function with
+// UpdateSince.
+type ErrorTimer interface {
+	Timer
+	TimeCtx(context.Context, func(context.Context) error) error
+	TimeErr(func() error) error
+}
+
+// TimeCtx records the duration of the execution of the given function in t, by
+// its TimeCtx if it's an ErrorTimer, and returns its error.
+func TimeCtx(t Timer, ctx context.Context, f func(context.Context) error) error {
+	if e, ok := t.(ErrorTimer); ok {
+		return e.TimeCtx(ctx, f)
+	}
+	defer t.UpdateSince(time.Now())
+	return f(ctx)
+}
+
+// TimeErr records the duration of the execution of the given function in t, by
+// its TimeErr if it's an ErrorTimer, and returns its error.
+func TimeErr(t Timer, f func() error) error {
+	if e, ok := t.(ErrorTimer); ok {
+		return e.TimeErr(f)
+	}
+	defer t.UpdateSince(time.Now())
+	return f()
+}
+
+// GetTimer returns the Timer registered under the given name or nil if there is
+// none or it is another kind of metric.
+func GetTimer(name string, r Registry) Timer {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m, _ := r.Get(name).(Timer)
+	return m
+}
+
 // GetOrRegisterTimer returns an existing Timer or constructs and registers a
 // new StandardTimer.
 // Be sure to unregister the meter from the registry once it is of no use to
@@ -41,7 +82,7 @@ func GetOrRegisterTimer(name string, r Registry) Timer {
 // NewCustomTimer constructs a new StandardTimer from a Histogram and a Meter.
 // Be sure to call Stop() once the timer is of no use to allow for garbage collection.
 func NewCustomTimer(h Histogram, m ThisMeter) Timer {
-	if UseNilMetrics {
+	if UseNilMetrics || UseNilTimers {
 		return NilTimer{}
 	}
 	return &StandardTimer{
@@ -63,16 +104,37 @@ func NewRegisteredTimer(name string, r Registry) Timer {
 }
 
 // NewTimer constructs a new StandardTimer using an exponentially-decaying
-// sample with the same reservoir size and alpha as UNIX load averages.
+// sample with the same reservoir size and alpha as UNIX load averages.  Only
+// UseNilMetrics and UseNilTimers stub it out; UseNilHistograms and
+// UseNilMeters don't affect its histogram and meter.
 // Be sure to call Stop() once the timer is of no use to allow for garbage collection.
 func NewTimer() Timer {
-	if UseNilMetrics {
+	return NewTimerP(nil)
+}
+
+// NewTimerP constructs a new StandardTimer just like NewTimer whose
+// DefaultPercentiles, which every exporter reports, are ps.
+// Be sure to call Stop() once the timer is of no use to allow for garbage collection.
+func NewTimerP(ps []float64) Timer {
+	if UseNilMetrics || UseNilTimers {
 		return NilTimer{}
 	}
 	return &StandardTimer{
-		histogram: NewHistogram(NewExpDecaySample(1028, 0.015)),
-		meter:     NewThisMeter(),
+		histogram: &StandardHistogram{percentiles: append([]float64(nil), ps...), sample: NewExpDecaySample(1028, 0.015)},
+		meter:     startThisMeter(defaultTickInterval),
+	}
+}
+
+// TimedResult records the duration of the execution of the given function in
+// the timer, as TimeErr does, marks the error meter if the function returns
+// an error, and returns its error.  The duration is recorded whether or not
+// the function fails.  A nil error meter is ignored.
+func TimedResult(t Timer, errMeter ThisMeter, f func() error) error {
+	err := TimeErr(t, f)
+	if nil != err && nil != errMeter {
+		errMeter.Mark(1)
 	}
+	return err
 }
 
 // NilTimer is a no-op Timer.
@@ -84,6 +146,9 @@ type NilTimer struct {
 // Count is a no-op.
 func (NilTimer) Count() int64 { return 0 }
 
+// DefaultPercentiles returns the package's DefaultPercentiles.
+func (NilTimer) DefaultPercentiles() []float64 { return DefaultPercentiles }
+
 // Max is a no-op.
 func (NilTimer) Max() int64 { return 0 }
 
@@ -128,6 +193,14 @@ func (NilTimer) Sum() int64 { return 0 }
 // Time is a no-op.
 func (NilTimer) Time(func()) {}
 
+// TimeCtx calls f and returns its error without recording anything.
+func (NilTimer) TimeCtx(ctx context.Context, f func(context.Context) error) error {
+	return f(ctx)
+}
+
+// TimeErr calls f and returns its error without recording anything.
+func (NilTimer) TimeErr(f func() error) error { return f() }
+
 // Update is a no-op.
 func (NilTimer) Update(time.Duration) {}
 
@@ -138,8 +211,11 @@ func (NilTimer) UpdateSince(time.Time) {}
 func (NilTimer) Variance() float64 { return 0.0 }
 
 // StandardTimer is the standard implementation of a Timer and uses a Histogram
-// and Meter.
+// for the durations of events and a ThisMeter, rather than the Meter alias of
+// Counter, for their rate, so Rate1, Rate5, Rate15 and RateMean are moving
+// averages of its throughput.
 type StandardTimer struct {
+	cancelled Counter
 	histogram Histogram
 	meter     ThisMeter
 	mutex     sync.Mutex
@@ -150,6 +226,12 @@ func (t *StandardTimer) Count() int64 {
 	return t.histogram.Count()
 }
 
+// DefaultPercentiles returns the percentiles exporters report of the timer,
+// those of its histogram.
+func (t *StandardTimer) DefaultPercentiles() []float64 {
+	return ReportedPercentiles(t.histogram)
+}
+
 // Max returns the maximum value in the sample.
 func (t *StandardTimer) Max() int64 {
 	return t.histogram.Max()
@@ -221,11 +303,45 @@ func (t *StandardTimer) Sum() int64 {
 	return t.histogram.Sum()
 }
 
-// Record the duration of the execution of the given function.
+// Record the duration of the execution of the given function.  The duration
+// is recorded even if f panics.
 func (t *StandardTimer) Time(f func()) {
-	ts := time.Now()
+	defer t.UpdateSince(time.Now())
 	f()
-	t.Update(time.Since(ts))
+}
+
+// SetCancelledCounter sets a counter TimeCtx increments whenever the context
+// is done by the time the function it timed returns.
+func (t *StandardTimer) SetCancelledCounter(c Counter) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.cancelled = c
+}
+
+// TimeCtx records the duration of the execution of the given function, as
+// Time does, and returns its error.  If the context is done by then and a
+// counter was set by SetCancelledCounter it's incremented too.
+func (t *StandardTimer) TimeCtx(ctx context.Context, f func(context.Context) error) error {
+	defer func(ts time.Time) {
+		t.UpdateSince(ts)
+		if nil == ctx.Err() {
+			return
+		}
+		t.mutex.Lock()
+		c := t.cancelled
+		t.mutex.Unlock()
+		if nil != c {
+			c.Inc(1)
+		}
+	}(time.Now())
+	return f(ctx)
+}
+
+// TimeErr records the duration of the execution of the given function, as
+// Time does, and returns its error.
+func (t *StandardTimer) TimeErr(f func() error) error {
+	defer t.UpdateSince(time.Now())
+	return f()
 }
 
 // Record the duration of an event.
@@ -259,27 +375,66 @@ type TimerSnapshot struct {
 // taken.
 func (t *TimerSnapshot) Count() int64 { return t.histogram.Count() }
 
+// DefaultPercentiles returns the percentiles exporters report of the timer.
+func (t *TimerSnapshot) DefaultPercentiles() []float64 {
+	return ReportedPercentiles(t.histogram)
+}
+
 // Max returns the maximum value at the time the snapshot was taken.
 func (t *TimerSnapshot) Max() int64 { return t.histogram.Max() }
 
+// MaxFor returns the maximum value at the time the snapshot was taken in the
+// given unit.
+func (t *TimerSnapshot) MaxFor(unit time.Duration) float64 {
+	return float64(t.Max()) / float64(unit)
+}
+
 // Mean returns the mean value at the time the snapshot was taken.
 func (t *TimerSnapshot) Mean() float64 { return t.histogram.Mean() }
 
+// MeanFor returns the mean value at the time the snapshot was taken in the
+// given unit.
+func (t *TimerSnapshot) MeanFor(unit time.Duration) float64 {
+	return t.Mean() / float64(unit)
+}
+
 // Min returns the minimum value at the time the snapshot was taken.
 func (t *TimerSnapshot) Min() int64 { return t.histogram.Min() }
 
+// MinFor returns the minimum value at the time the snapshot was taken in the
+// given unit.
+func (t *TimerSnapshot) MinFor(unit time.Duration) float64 {
+	return float64(t.Min()) / float64(unit)
+}
+
 // Percentile returns an arbitrary percentile of sampled values at the time the
 // snapshot was taken.
 func (t *TimerSnapshot) Percentile(p float64) float64 {
 	return t.histogram.Percentile(p)
 }
 
+// PercentileFor returns an arbitrary percentile of sampled values at the time
+// the snapshot was taken in the given unit.
+func (t *TimerSnapshot) PercentileFor(p float64, unit time.Duration) float64 {
+	return t.Percentile(p) / float64(unit)
+}
+
 // Percentiles returns a slice of arbitrary percentiles of sampled values at
 // the time the snapshot was taken.
 func (t *TimerSnapshot) Percentiles(ps []float64) []float64 {
 	return t.histogram.Percentiles(ps)
 }
 
+// PercentilesFor returns a slice of arbitrary percentiles of sampled values at
+// the time the snapshot was taken in the given unit.
+func (t *TimerSnapshot) PercentilesFor(ps []float64, unit time.Duration) []float64 {
+	scores := t.Percentiles(ps)
+	for i := range scores {
+		scores[i] /= float64(unit)
+	}
+	return scores
+}
+
 // Rate1 returns the one-minute moving average rate of events per second at the
 // time the snapshot was taken.
 func (t *TimerSnapshot) Rate1() float64 { return t.meter.Rate1() }
@@ -303,6 +458,12 @@ func (t *TimerSnapshot) Snapshot() Timer { return t }
 // was taken.
 func (t *TimerSnapshot) StdDev() float64 { return t.histogram.StdDev() }
 
+// StdDevFor returns the standard deviation of the values at the time the
+// snapshot was taken in the given unit.
+func (t *TimerSnapshot) StdDevFor(unit time.Duration) float64 {
+	return t.StdDev() / float64(unit)
+}
+
 // Stop is a no-op.
 func (t *TimerSnapshot) Stop() {}
 
@@ -314,6 +475,16 @@ func (*TimerSnapshot) Time(func()) {
 	panic("Time called on a TimerSnapshot")
 }
 
+// TimeCtx panics.
+func (*TimerSnapshot) TimeCtx(context.Context, func(context.Context) error) error {
+	panic("TimeCtx called on a TimerSnapshot")
+}
+
+// TimeErr panics.
+func (*TimerSnapshot) TimeErr(func() error) error {
+	panic("TimeErr called on a TimerSnapshot")
+}
+
 // Update panics.
 func (*TimerSnapshot) Update(time.Duration) {
 	panic("Update called on a TimerSnapshot")
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -15,6 +17,21 @@ func BenchmarkTimer(b *testing.B) {
 	}
 }
 
+func TestGetTimer(t *testing.T) {
+	r := NewRegistry()
+	m := NewRegisteredTimer("foo", r)
+	NewRegisteredThisMeter("bar", r)
+	if got := GetTimer("foo", r); m != got {
+		t.Errorf("GetTimer(\"foo\", r): %v != %v\n", m, got)
+	}
+	if got := GetTimer("bar", r); nil != got {
+		t.Errorf("GetTimer(\"bar\", r): nil != %v\n", got)
+	}
+	if got := GetTimer("baz", r); nil != got {
+		t.Errorf("GetTimer(\"baz\", r): nil != %v\n", got)
+	}
+}
+
 func TestGetOrRegisterTimer(t *testing.T) {
 	r := NewRegistry()
 	NewRegisteredTimer("foo", r).Update(47)
@@ -32,15 +49,63 @@ func TestTimerExtremes(t *testing.T) {
 	}
 }
 
+func TestTimerSnapshotFor(t *testing.T) {
+	tm := NewTimer()
+	defer tm.Stop()
+	tm.Update(time.Millisecond)
+	tm.Update(3 * time.Millisecond)
+	s := tm.Snapshot().(*TimerSnapshot)
+	if min := s.MinFor(time.Millisecond); 1 != min {
+		t.Errorf("s.MinFor(time.Millisecond): 1 != %v\n", min)
+	}
+	if max := s.MaxFor(time.Millisecond); 3 != max {
+		t.Errorf("s.MaxFor(time.Millisecond): 3 != %v\n", max)
+	}
+	if mean := s.MeanFor(time.Millisecond); 2 != mean {
+		t.Errorf("s.MeanFor(time.Millisecond): 2 != %v\n", mean)
+	}
+	if stdDev := s.StdDevFor(time.Millisecond); 1 != stdDev {
+		t.Errorf("s.StdDevFor(time.Millisecond): 1 != %v\n", stdDev)
+	}
+	if p := s.PercentileFor(0.5, time.Millisecond); 2 != p {
+		t.Errorf("s.PercentileFor(0.5, time.Millisecond): 2 != %v\n", p)
+	}
+	ps := s.PercentilesFor([]float64{0, 1}, time.Millisecond)
+	if 1 != ps[0] || 3 != ps[1] {
+		t.Errorf("s.PercentilesFor([]float64{0, 1}, time.Millisecond): [1 3] != %v\n", ps)
+	}
+}
+
+func TestTimerRate(t *testing.T) {
+	clock := newManualClock()
+	m := newStandardThisMeter()
+	m.clock, m.startTime = clock, clock.Now()
+	tm := NewCustomTimer(NewHistogram(NewUniformSample(100)), m)
+	for i := 0; i < 10; i++ {
+		tm.Time(func() {})
+	}
+	clock.Add(5 * time.Second)
+	m.tick()
+	if count := tm.Count(); 10 != count {
+		t.Errorf("tm.Count(): 10 != %v\n", count)
+	}
+	if rateMean := tm.RateMean(); 2 != rateMean {
+		t.Errorf("tm.RateMean(): 2 != %v\n", rateMean)
+	}
+	if rate1 := tm.Rate1(); 2 != rate1 {
+		t.Errorf("tm.Rate1(): 2 != %v\n", rate1)
+	}
+}
+
 func TestTimerStop(t *testing.T) {
-	l := len(arbiter.meters)
+	l := arbiter.len()
 	tm := NewTimer()
-	if len(arbiter.meters) != l+1 {
-		t.Errorf("arbiter.meters: %d != %d\n", l+1, len(arbiter.meters))
+	if arbiter.len() != l+1 {
+		t.Errorf("arbiter.meters: %d != %d\n", l+1, arbiter.len())
 	}
 	tm.Stop()
-	if len(arbiter.meters) != l {
-		t.Errorf("arbiter.meters: %d != %d\n", l, len(arbiter.meters))
+	if arbiter.len() != l {
+		t.Errorf("arbiter.meters: %d != %d\n", l, arbiter.len())
 	}
 }
 
@@ -52,6 +117,102 @@ func TestTimerFunc(t *testing.T) {
 	}
 }
 
+func TestTimerFuncPanics(t *testing.T) {
+	tm := NewTimer()
+	defer tm.Stop()
+	func() {
+		defer func() {
+			if r := recover(); "boom" != r {
+				t.Errorf("recover(): boom != %v\n", r)
+			}
+		}()
+		tm.Time(func() { panic("boom") })
+	}()
+	if count := tm.Count(); 1 != count {
+		t.Errorf("tm.Count(): 1 != %v\n", count)
+	}
+}
+
+func TestTimerTimeCtx(t *testing.T) {
+	tm := NewTimer()
+	defer tm.Stop()
+	c := NewCounter()
+	tm.(*StandardTimer).SetCancelledCounter(c)
+	if err := TimeCtx(tm, context.Background(), func(context.Context) error { return nil }); nil != err {
+		t.Errorf("TimeCtx(): nil != %v\n", err)
+	}
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := TimeCtx(tm, ctx, func(ctx context.Context) error { return ctx.Err() })
+	if context.Canceled != err {
+		t.Errorf("TimeCtx(): %v != %v\n", context.Canceled, err)
+	}
+	if count := tm.Count(); 2 != count {
+		t.Errorf("tm.Count(): 2 != %v\n", count)
+	}
+	if count := c.Count(); 1 != count {
+		t.Errorf("c.Count(): 1 != %v\n", count)
+	}
+}
+
+func TestTimedResult(t *testing.T) {
+	tm, m := NewTimer(), NewThisMeter()
+	defer tm.Stop()
+	defer m.Stop()
+	if e := TimedResult(tm, m, func() error { return nil }); nil != e {
+		t.Errorf("TimedResult(): nil != %v\n", e)
+	}
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	err := errors.New("boom")
+	if e := TimedResult(tm, m, func() error { return err }); err != e {
+		t.Errorf("TimedResult(): %v != %v\n", err, e)
+	}
+	if count := m.Count(); 1 != count {
+		t.Errorf("m.Count(): 1 != %v\n", count)
+	}
+	if e := TimedResult(tm, nil, func() error { return err }); err != e {
+		t.Errorf("TimedResult(): %v != %v\n", err, e)
+	}
+	if count := tm.Count(); 3 != count {
+		t.Errorf("tm.Count(): 3 != %v\n", count)
+	}
+}
+
+func TestTimeErrPlainTimer(t *testing.T) {
+	tm := struct{ Timer }{NewTimer()}
+	defer tm.Stop()
+	err := errors.New("failed")
+	if e := TimeErr(tm, func() error { return err }); err != e {
+		t.Errorf("TimeErr(): %v != %v\n", err, e)
+	}
+	if e := TimeCtx(tm, context.Background(), func(context.Context) error { return nil }); nil != e {
+		t.Errorf("TimeCtx(): nil != %v\n", e)
+	}
+	if count := tm.Count(); 2 != count {
+		t.Errorf("tm.Count(): 2 != %v\n", count)
+	}
+}
+
+func TestTimerTimeErr(t *testing.T) {
+	tm := NewTimer()
+	defer tm.Stop()
+	err := errors.New("boom")
+	if e := TimeErr(tm, func() error { return err }); err != e {
+		t.Errorf("TimeErr(): %v != %v\n", err, e)
+	}
+	if e := TimeErr(tm, func() error { return nil }); nil != e {
+		t.Errorf("TimeErr(): nil != %v\n", e)
+	}
+	if count := tm.Count(); 2 != count {
+		t.Errorf("tm.Count(): 2 != %v\n", count)
+	}
+}
+
 func TestTimerZero(t *testing.T) {
 	tm := NewTimer()
 	if count := tm.Count(); 0 != count {
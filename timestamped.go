@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimestampedMetrics know when they were last updated so that, say, an
+// exporter can skip the series which haven't changed in some number of
+// intervals.  StandardCounter, StandardFloatCounter, StandardGauge,
+// StandardGaugeFloat64 and StandardThisMeter are TimestampedMetrics.
+//
+// Reading the time on every update would cost far more than the update
+// itself, so the time they record is that of a coarse clock which lags by up
+// to LastUpdateResolution.
+type TimestampedMetric interface {
+	LastUpdate() time.Time
+}
+
+// LastUpdateResolution is how far behind the time a TimestampedMetric records
+// on each update may be.
+const LastUpdateResolution = time.Second
+
+var (
+	coarseClock     int64 // nanoseconds since the Unix epoch
+	coarseClockOnce sync.Once
+)
+
+// coarseNow returns the time of the coarse clock in nanoseconds since the
+// Unix epoch, starting the goroutine which advances it every
+// LastUpdateResolution the first time it's called.
+func coarseNow() int64 {
+	if now := atomic.LoadInt64(&coarseClock); 0 != now {
+		return now
+	}
+	coarseClockOnce.Do(func() {
+		advanceCoarseClock(time.Now())
+		go func() {
+			for range time.Tick(LastUpdateResolution) {
+				advanceCoarseClock(time.Now())
+			}
+		}()
+	})
+	return atomic.LoadInt64(&coarseClock)
+}
+
+// advanceCoarseClock sets the coarse clock to now unless it's already later.
+func advanceCoarseClock(now time.Time) {
+	n := now.UnixNano()
+	for {
+		old := atomic.LoadInt64(&coarseClock)
+		if n <= old || atomic.CompareAndSwapInt64(&coarseClock, old, n) {
+			return
+		}
+	}
+}
+
+// loadLastUpdate returns the time stored by touchLastUpdate at p, or the zero
+// Time if none has been.
+func loadLastUpdate(p *int64) time.Time {
+	if n := atomic.LoadInt64(p); 0 != n {
+		return time.Unix(0, n)
+	}
+	return time.Time{}
+}
+
+// touchLastUpdate stores the time of the coarse clock at p, skipping the
+// store if it's already there so that concurrent updates within the same
+// tick of the clock only read the cache line.
+func touchLastUpdate(p *int64) {
+	if now := coarseNow(); atomic.LoadInt64(p) != now {
+		atomic.StoreInt64(p, now)
+	}
+}
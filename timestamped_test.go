@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastUpdate(t *testing.T) {
+	c, fc, g, gf := NewCounter(), NewFloatCounter(), NewGauge().(*StandardGauge), NewGaugeFloat64()
+	m := NewThisMeter()
+	defer m.Stop()
+	for _, test := range []struct {
+		name   string
+		metric interface{}
+		read   func()
+		update func()
+	}{
+		{"Counter.Inc", c, func() { c.Count() }, func() { c.Inc(1) }},
+		{"Counter.Dec", c, func() { c.Snapshot() }, func() { c.Dec(1) }},
+		{"FloatCounter.Inc", fc, func() { fc.Count() }, func() { fc.Inc(1.5) }},
+		{"Gauge.Update", g, func() { g.Value() }, func() { g.Update(47) }},
+		{"Gauge.UpdateMax", g, func() { g.Snapshot() }, func() { g.UpdateMax(48) }},
+		{"GaugeFloat64.Update", gf, func() { gf.Value() }, func() { gf.Update(4.7) }},
+		{"ThisMeter.Mark", m, func() { m.Count(); m.Rate1(); m.Snapshot() }, func() { m.Mark(1) }},
+	} {
+		tm := test.metric.(TimestampedMetric)
+		before := tm.LastUpdate()
+		time.Sleep(time.Millisecond)
+		advanceCoarseClock(time.Now())
+		test.read()
+		if last := tm.LastUpdate(); !before.Equal(last) {
+			t.Errorf("%s: read: %v != %v\n", test.name, before, last)
+		}
+		test.update()
+		if last := tm.LastUpdate(); !last.After(before) {
+			t.Errorf("%s: %v not after %v\n", test.name, last, before)
+		}
+	}
+}
+
+func TestLastUpdateNeverUpdated(t *testing.T) {
+	if last := NewCounter().(TimestampedMetric).LastUpdate(); !last.IsZero() {
+		t.Errorf("LastUpdate(): zero != %v\n", last)
+	}
+}
+
+func TestCoarseClock(t *testing.T) {
+	now := coarseNow()
+	if d := time.Since(time.Unix(0, now)); d < 0 || 2*LastUpdateResolution < d {
+		t.Errorf("coarseNow(): %v behind\n", d)
+	}
+	advanceCoarseClock(time.Unix(0, now-1))
+	if n := coarseNow(); n < now {
+		t.Errorf("coarseNow(): %v went back from %v\n", n, now)
+	}
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracer adds the marks of meters to the spans of traces, so that dips in
+// throughput can be correlated with particular traces.  Implementations
+// adapt it to a tracing library.
+type Tracer interface {
+
+	// MarkEvent adds an event for n events marked on m to the span active
+	// in ctx, if there is one.
+	MarkEvent(ctx context.Context, m ThisMeter, n int64)
+}
+
+// tracer holds a tracerHolder so that MarkContext can load it without
+// locking or allocating.
+var tracer atomic.Value
+
+// tracerHolder wraps a Tracer since an atomic.Value can't hold nil or values
+// of different concrete types.
+type tracerHolder struct {
+	Tracer
+}
+
+// SetTracer sets the Tracer MarkContext adds events with, or with nil stops
+// it adding events.
+func SetTracer(t Tracer) {
+	tracer.Store(tracerHolder{t})
+}
+
+// currentTracer returns the Tracer set by SetTracer, or nil.
+func currentTracer() Tracer {
+	h, _ := tracer.Load().(tracerHolder)
+	return h.Tracer
+}
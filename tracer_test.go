@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+)
+
+type spanKey struct{}
+
+// fakeTracer records the span in the context and the count of each event.
+type fakeTracer struct {
+	spans []string
+	ns    []int64
+}
+
+func (t *fakeTracer) MarkEvent(ctx context.Context, m ThisMeter, n int64) {
+	span, _ := ctx.Value(spanKey{}).(string)
+	t.spans = append(t.spans, span)
+	t.ns = append(t.ns, n)
+}
+
+func TestMeterMarkContext(t *testing.T) {
+	defer SetTracer(nil)
+	m := newStandardThisMeter()
+	ctx := context.WithValue(context.Background(), spanKey{}, "span")
+	m.MarkContext(ctx, 2)
+	tr := &fakeTracer{}
+	SetTracer(tr)
+	m.MarkContext(ctx, 3)
+	if count := m.Count(); 5 != count {
+		t.Errorf("m.Count(): 5 != %v\n", count)
+	}
+	if 1 != len(tr.spans) || "span" != tr.spans[0] || 3 != tr.ns[0] {
+		t.Errorf("events: [span 3] != %v %v\n", tr.spans, tr.ns)
+	}
+	SetTracer(nil)
+	m.MarkContext(ctx, 1)
+	if 1 != len(tr.spans) {
+		t.Errorf("len(tr.spans): 1 != %v\n", len(tr.spans))
+	}
+}
+
+func TestMeterMarkContextNoTracerAllocs(t *testing.T) {
+	m := newStandardThisMeter()
+	ctx := context.Background()
+	if allocs := testing.AllocsPerRun(100, func() { m.MarkContext(ctx, 1) }); 0 != allocs {
+		t.Errorf("allocs: 0 != %v\n", allocs)
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// WindowedCounters count events over a sliding time window rather than since
+// they were constructed.  Count returns the number of events in the whole
+// window.
+type WindowedCounter interface {
+	Counter
+	CountSince(time.Duration) int64
+}
+
+// GetOrRegisterWindowedCounter returns an existing WindowedCounter or
+// constructs and registers a new StandardWindowedCounter.
+func GetOrRegisterWindowedCounter(name string, r Registry, window time.Duration, buckets int) WindowedCounter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() WindowedCounter {
+		return NewWindowedCounter(window, buckets)
+	}).(WindowedCounter)
+}
+
+// NewRegisteredWindowedCounter constructs and registers a new
+// StandardWindowedCounter.
+func NewRegisteredWindowedCounter(name string, r Registry, window time.Duration, buckets int) WindowedCounter {
+	c := NewWindowedCounter(window, buckets)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// NewWindowedCounter constructs a new StandardWindowedCounter counting events
+// over the given window in the given number of buckets, at least one.
+func NewWindowedCounter(window time.Duration, buckets int) WindowedCounter {
+	if UseNilMetrics || UseNilCounters {
+		return NilWindowedCounter{}
+	}
+	return newStandardWindowedCounter(window, buckets, systemClock{})
+}
+
+// NilWindowedCounter is a no-op WindowedCounter.
+type NilWindowedCounter struct {
+	NilCounter
+}
+
+// CountSince is a no-op.
+func (NilWindowedCounter) CountSince(time.Duration) int64 { return 0 }
+
+// StandardWindowedCounter is the standard implementation of a
+// WindowedCounter.  It keeps a ring of buckets, each counting the events in
+// one window/buckets slice of time, which are reused as the window slides past
+// them, so counts are exact to within one bucket's width.
+type StandardWindowedCounter struct {
+	mutex   sync.Mutex
+	buckets []windowBucket
+	width   time.Duration
+	clock   Clock
+}
+
+// windowBucket counts the events in the epoch'th slice of time.
+type windowBucket struct {
+	epoch int64
+	count int64
+}
+
+func newStandardWindowedCounter(window time.Duration, buckets int, clock Clock) *StandardWindowedCounter {
+	if buckets < 1 {
+		buckets = 1
+	}
+	width := window / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+	return &StandardWindowedCounter{
+		buckets: make([]windowBucket, buckets),
+		width:   width,
+		clock:   clock,
+	}
+}
+
+// Clear sets the counter to zero.
+func (c *StandardWindowedCounter) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.buckets {
+		c.buckets[i] = windowBucket{}
+	}
+}
+
+// Count returns the number of events in the window.
+func (c *StandardWindowedCounter) Count() int64 {
+	return c.CountSince(time.Duration(len(c.buckets)) * c.width)
+}
+
+// CountSince returns the number of events in the buckets overlapping the last
+// d, which is at most the window.
+func (c *StandardWindowedCounter) CountSince(d time.Duration) int64 {
+	n := int64((d + c.width - 1) / c.width)
+	if n > int64(len(c.buckets)) {
+		n = int64(len(c.buckets))
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	epoch := c.epoch()
+	var count int64
+	for _, b := range c.buckets {
+		if b.epoch > epoch-n && b.epoch <= epoch {
+			count += b.count
+		}
+	}
+	return count
+}
+
+// Dec decrements the counter by the given amount.
+func (c *StandardWindowedCounter) Dec(i int64) {
+	c.add(-i)
+}
+
+// Inc increments the counter by the given amount.
+func (c *StandardWindowedCounter) Inc(i int64) {
+	c.add(i)
+}
+
+// Snapshot returns a read-only copy of the number of events in the window.
+func (c *StandardWindowedCounter) Snapshot() Counter {
+	return CounterSnapshot(c.Count())
+}
+
+func (c *StandardWindowedCounter) add(i int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	epoch := c.epoch()
+	b := &c.buckets[epoch%int64(len(c.buckets))]
+	if b.epoch != epoch {
+		b.epoch, b.count = epoch, 0
+	}
+	b.count += i
+}
+
+// epoch returns the index of the bucket-wide slice of time containing now.
+// Epochs start at one so that no live bucket is mistaken for an unused one.
+func (c *StandardWindowedCounter) epoch() int64 {
+	return c.clock.Now().UnixNano()/int64(c.width) + 1
+}
+
+//////////////////
+// Meter functions
+//////////////////
+
+func (c *StandardWindowedCounter) Mark(n int64) { c.Inc(n) }
+
+func (c *StandardWindowedCounter) Rate1() float64 { return 0.0 }
+
+func (c *StandardWindowedCounter) Rate5() float64 { return 0.0 }
+
+func (c *StandardWindowedCounter) Rate15() float64 { return 0.0 }
+
+func (c *StandardWindowedCounter) RateMean() float64 { return 0.0 }
+
+func (c *StandardWindowedCounter) Stop() {}
+
+//////////////////
+//////////////////
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWindowedCounterExpires(t *testing.T) {
+	clock := newManualClock()
+	c := newStandardWindowedCounter(time.Minute, 6, clock)
+	c.Inc(1)
+	clock.Add(10 * time.Second)
+	c.Inc(2)
+	clock.Add(10 * time.Second)
+	c.Inc(4)
+	if count := c.Count(); 7 != count {
+		t.Errorf("c.Count(): 7 != %v\n", count)
+	}
+	if count := c.CountSince(10 * time.Second); 4 != count {
+		t.Errorf("c.CountSince(10 * time.Second): 4 != %v\n", count)
+	}
+	if count := c.CountSince(15 * time.Second); 6 != count {
+		t.Errorf("c.CountSince(15 * time.Second): 6 != %v\n", count)
+	}
+	clock.Add(40 * time.Second)
+	if count := c.Count(); 6 != count {
+		t.Errorf("c.Count(): 6 != %v\n", count)
+	}
+	clock.Add(10 * time.Second)
+	c.Inc(8)
+	if count := c.Count(); 12 != count {
+		t.Errorf("c.Count(): 12 != %v\n", count)
+	}
+	clock.Add(time.Hour)
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestWindowedCounterClear(t *testing.T) {
+	c := NewWindowedCounter(time.Minute, 6)
+	c.Inc(1)
+	c.Clear()
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestWindowedCounterConcurrent(t *testing.T) {
+	c := NewWindowedCounter(time.Hour, 60)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Count(); 8000 != count {
+		t.Errorf("c.Count(): 8000 != %v\n", count)
+	}
+}
+
+func TestGetOrRegisterWindowedCounter(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredWindowedCounter("foo", r, time.Minute, 6).Inc(47)
+	if c := GetOrRegisterWindowedCounter("foo", r, time.Minute, 6); 47 != c.Count() {
+		t.Fatal(c)
+	}
+}
@@ -10,18 +10,26 @@ import (
 // Write sorts writes each metric in the given registry periodically to the
 // given io.Writer.
 func Write(r Registry, d time.Duration, w io.Writer) {
-	for _ = range time.Tick(d) {
+	ticker := NewFlushTicker(d, false)
+	defer ticker.Stop()
+	for _ = range ticker.C {
 		WriteOnce(r, w)
 	}
 }
 
 // WriteOnce sorts and writes metrics in the given registry to the given
-// io.Writer.
+// io.Writer.  The metrics are read from a single Snapshot of the registry.
 func WriteOnce(r Registry, w io.Writer) {
+	writeSnapshot(w, SnapshotRegistry(r))
+}
+
+// writeSnapshot sorts and writes the metrics in a snapshot to the given
+// io.Writer.
+func writeSnapshot(w io.Writer, snapshot map[string]interface{}) {
 	var namedMetrics namedMetricSlice
-	r.Each(func(name string, i interface{}) {
+	for name, i := range snapshot {
 		namedMetrics = append(namedMetrics, namedMetric{name, i})
-	})
+	}
 
 	sort.Sort(namedMetrics)
 	for _, namedMetric := range namedMetrics {
@@ -41,18 +49,17 @@ func WriteOnce(r Registry, w io.Writer) {
 			fmt.Fprintf(w, "  error:       %v\n", metric.Error())
 		case Histogram:
 			h := metric.Snapshot()
-			ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+			ps := ReportedPercentiles(h)
+			scores := h.Percentiles(ps)
 			fmt.Fprintf(w, "histogram %s\n", namedMetric.name)
 			fmt.Fprintf(w, "  count:       %9d\n", h.Count())
 			fmt.Fprintf(w, "  min:         %9d\n", h.Min())
 			fmt.Fprintf(w, "  max:         %9d\n", h.Max())
 			fmt.Fprintf(w, "  mean:        %12.2f\n", h.Mean())
 			fmt.Fprintf(w, "  stddev:      %12.2f\n", h.StdDev())
-			fmt.Fprintf(w, "  median:      %12.2f\n", ps[0])
-			fmt.Fprintf(w, "  75%%:         %12.2f\n", ps[1])
-			fmt.Fprintf(w, "  95%%:         %12.2f\n", ps[2])
-			fmt.Fprintf(w, "  99%%:         %12.2f\n", ps[3])
-			fmt.Fprintf(w, "  99.9%%:       %12.2f\n", ps[4])
+			for i, p := range ps {
+				fmt.Fprintf(w, "  %-13s%12.2f\n", percentileLabel(p)+":", scores[i])
+			}
 		case ThisMeter:
 			m := metric.Snapshot()
 			fmt.Fprintf(w, "meter %s\n", namedMetric.name)
@@ -63,18 +70,17 @@ func WriteOnce(r Registry, w io.Writer) {
 			fmt.Fprintf(w, "  mean rate:   %12.2f\n", m.RateMean())
 		case Timer:
 			t := metric.Snapshot()
-			ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+			ps := ReportedPercentiles(t)
+			scores := t.Percentiles(ps)
 			fmt.Fprintf(w, "timer %s\n", namedMetric.name)
 			fmt.Fprintf(w, "  count:       %9d\n", t.Count())
 			fmt.Fprintf(w, "  min:         %9d\n", t.Min())
 			fmt.Fprintf(w, "  max:         %9d\n", t.Max())
 			fmt.Fprintf(w, "  mean:        %12.2f\n", t.Mean())
 			fmt.Fprintf(w, "  stddev:      %12.2f\n", t.StdDev())
-			fmt.Fprintf(w, "  median:      %12.2f\n", ps[0])
-			fmt.Fprintf(w, "  75%%:         %12.2f\n", ps[1])
-			fmt.Fprintf(w, "  95%%:         %12.2f\n", ps[2])
-			fmt.Fprintf(w, "  99%%:         %12.2f\n", ps[3])
-			fmt.Fprintf(w, "  99.9%%:       %12.2f\n", ps[4])
+			for i, p := range ps {
+				fmt.Fprintf(w, "  %-13s%12.2f\n", percentileLabel(p)+":", scores[i])
+			}
 			fmt.Fprintf(w, "  1-min rate:  %12.2f\n", t.Rate1())
 			fmt.Fprintf(w, "  5-min rate:  %12.2f\n", t.Rate5())
 			fmt.Fprintf(w, "  15-min rate: %12.2f\n", t.Rate15())
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"bytes"
 	"sort"
 	"testing"
 )
@@ -20,3 +21,15 @@ func TestMetricsSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteOnce(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	NewRegisteredGauge("bar", r).Update(47)
+	var b bytes.Buffer
+	WriteOnce(r, &b)
+	want := "gauge bar\n  value:              47\ncounter foo\n  count:              47\n"
+	if s := b.String(); want != s {
+		t.Errorf("WriteOnce(r, &b): %q != %q\n", want, s)
+	}
+}